@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"digeon-backend/internal/config"
 	"digeon-backend/internal/database"
 	"digeon-backend/internal/handlers"
@@ -10,10 +11,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	echo_middleware "github.com/labstack/echo/v4/middleware"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -33,16 +37,64 @@ func main() {
 		log.Fatalf("Failed to run database migrations: %v", err)
 	}
 
+	// Redis is only connected when something is configured to use it
+	var redisClient *redis.Client
+	getRedis := func() *redis.Client {
+		if redisClient == nil {
+			if redisClient, err = config.ConnectRedis(); err != nil {
+				log.Fatalf("Failed to connect to redis: %v", err)
+			}
+		}
+		return redisClient
+	}
+
+	// Materialized home timelines
+	var timelineStore services.TimelineStore
+	switch os.Getenv("TIMELINE_STORE") {
+	case "redis":
+		timelineStore = services.NewRedisTimelineStore(getRedis())
+	case "", "postgres":
+		timelineStore = services.NewPostgresTimelineStore(db)
+	case "none":
+		// Home timelines are read straight from the posts table
+	default:
+		log.Fatalf("Unknown TIMELINE_STORE: %s", os.Getenv("TIMELINE_STORE"))
+	}
+
+	// Real-time events, shared across replicas unless EVENT_BACKEND=local
+	var eventBackend services.EventBackend
+	switch os.Getenv("EVENT_BACKEND") {
+	case "", "local":
+		eventBackend = services.NewLocalEventBackend()
+	case "postgres":
+		eventBackend = services.NewPostgresEventBackend(db, config.NewDatabaseConfig().DSN())
+	case "redis":
+		eventBackend = services.NewRedisEventBackend(getRedis())
+	default:
+		log.Fatalf("Unknown EVENT_BACKEND: %s", os.Getenv("EVENT_BACKEND"))
+	}
+	eventBroker := services.NewEventBroker(eventBackend)
+	eventBroker.Start(context.Background())
+
 	// Initialize services
 	userService := services.NewUserService(db)
 	mediaService := services.NewMediaService(db)
-	postService := services.NewPostService(db, mediaService)
-	timelineService := services.NewTimelineService(db)
-	notificationService := services.NewNotificationService(db)
-	likeService := services.NewLikeService(db, notificationService)
-	followService := services.NewFollowService(db, notificationService)
+	analyticsService := services.NewAnalyticsService(db)
+	notificationService := services.NewNotificationService(db, eventBroker)
+	linkPreviewService := services.NewLinkPreviewService(db, mediaService)
+	timelineService := services.NewTimelineService(db, timelineStore)
+	postService := services.NewPostService(db, mediaService, notificationService, analyticsService, linkPreviewService, timelineService, eventBroker)
+	likeService := services.NewLikeService(db, notificationService, analyticsService, eventBroker)
+	reactionService := services.NewReactionService(db, notificationService)
+	bookmarkService := services.NewBookmarkService(db)
+	followService := services.NewFollowService(db, notificationService, analyticsService, timelineService, eventBroker)
 	commentService := services.NewCommentService(db, postService, notificationService)
-	searchService := services.NewSearchService(db)
+	searchService := services.NewSearchService(db, notificationService)
+	listService := services.NewListService(db)
+	blockService := services.NewBlockService(db, timelineService, eventBroker)
+	muteService := services.NewMuteService(db)
+	counterService := services.NewCounterService(db)
+	streamService := services.NewStreamService(db, eventBroker, postService, notificationService)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userService)
@@ -50,17 +102,62 @@ func main() {
 	postHandler := handlers.NewPostHandler(postService)
 	timelineHandler := handlers.NewTimelineHandler(timelineService)
 	likeHandler := handlers.NewLikeHandler(likeService)
+	reactionHandler := handlers.NewReactionHandler(reactionService)
+	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkService)
 	followHandler := handlers.NewFollowHandler(followService)
 	commentHandler := handlers.NewCommentHandler(commentService)
 	searchHandler := handlers.NewSearchHandler(searchService)
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
 	mediaHandler := handlers.NewMediaHandler(mediaService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	streamHandler := handlers.NewStreamHandler(streamService)
+	listHandler := handlers.NewListHandler(listService, timelineService)
+	blockHandler := handlers.NewBlockHandler(blockService)
+	muteHandler := handlers.NewMuteHandler(muteService)
+
+	// Start background jobs
+	rollupInterval, err := time.ParseDuration(os.Getenv("ANALYTICS_ROLLUP_INTERVAL"))
+	if err != nil || rollupInterval <= 0 {
+		rollupInterval = 5 * time.Minute
+	}
+	analyticsService.StartRollupJob(rollupInterval)
+
+	suggestionsInterval, err := time.ParseDuration(os.Getenv("SUGGESTIONS_REFRESH_INTERVAL"))
+	if err != nil || suggestionsInterval <= 0 {
+		suggestionsInterval = 10 * time.Minute
+	}
+	followService.StartSuggestionRefreshJob(suggestionsInterval)
+	followService.ResumeFollowImports()
+	searchService.BackfillSearchVectorsAsync()
+
+	reconcileInterval, err := time.ParseDuration(os.Getenv("COUNTER_RECONCILE_INTERVAL"))
+	if err != nil || reconcileInterval <= 0 {
+		reconcileInterval = time.Hour
+	}
+	counterService.StartReconcileJob(reconcileInterval)
+
+	savedSearchInterval, err := time.ParseDuration(os.Getenv("SAVED_SEARCH_CHECK_INTERVAL"))
+	if err != nil || savedSearchInterval <= 0 {
+		savedSearchInterval = 5 * time.Minute
+	}
+	searchService.StartSavedSearchJob(savedSearchInterval)
+
+	rateLimitRequests, err := strconv.Atoi(os.Getenv("RATE_LIMIT_REQUESTS"))
+	if err != nil || rateLimitRequests <= 0 {
+		rateLimitRequests = 100
+	}
+	rateLimitWindow, err := time.ParseDuration(os.Getenv("RATE_LIMIT_WINDOW"))
+	if err != nil || rateLimitWindow <= 0 {
+		rateLimitWindow = time.Minute
+	}
 
 	// Initialize Echo
 	e := echo.New()
 
 	// ミドルウェア
 	e.Use(echo_middleware.Logger())
+	// Keep credentials in query strings out of the access log
+	e.Use(middleware.RedactQueryParams("ticket", "token"))
 	e.Use(echo_middleware.Recover())
 	
 	// CORS設定
@@ -102,18 +199,43 @@ func main() {
 
 	// ユーザールート
 	users := api.Group("/users")
-	users.GET("/:id", userHandler.GetUserByID)
-	users.GET("/username/:username", userHandler.GetUserByUsername)
+	users.GET("/:id", userHandler.GetUserByID, middleware.OptionalJWTMiddleware())
+	users.GET("/username/:username", userHandler.GetUserByUsername, middleware.OptionalJWTMiddleware())
 	users.PUT("/profile", userHandler.UpdateProfile, middleware.JWTMiddleware())
 	users.GET("/:user_id/posts", postHandler.GetUserPosts, middleware.OptionalJWTMiddleware())
 	users.GET("/:user_id/likes", likeHandler.GetUserLikes, middleware.OptionalJWTMiddleware())
 	users.POST("/:user_id/follow", followHandler.FollowUser, middleware.JWTMiddleware())
 	users.DELETE("/:user_id/follow", followHandler.UnfollowUser, middleware.JWTMiddleware())
+	users.POST("/:user_id/block", blockHandler.BlockUser, middleware.JWTMiddleware())
+	users.DELETE("/:user_id/block", blockHandler.UnblockUser, middleware.JWTMiddleware())
+	users.GET("/me/blocks", blockHandler.GetBlockedUsers, middleware.JWTMiddleware())
+	users.DELETE("/me/followers/:user_id", followHandler.RemoveFollower, middleware.JWTMiddleware())
+	users.GET("/me/followers/export", followHandler.ExportFollowers, middleware.JWTMiddleware())
+	users.GET("/me/following/export", followHandler.ExportFollowing, middleware.JWTMiddleware())
+	users.POST("/me/follow-imports", followHandler.ImportFollows, middleware.JWTMiddleware())
+	users.GET("/me/follow-imports/:id", followHandler.GetFollowImport, middleware.JWTMiddleware())
+	users.GET("/me/follow-requests", followHandler.GetFollowRequests, middleware.JWTMiddleware())
+	users.POST("/me/follow-requests/:user_id/approve", followHandler.ApproveFollowRequest, middleware.JWTMiddleware())
+	users.POST("/me/follow-requests/:user_id/reject", followHandler.RejectFollowRequest, middleware.JWTMiddleware())
+	users.POST("/:user_id/mute", muteHandler.MuteUser, middleware.JWTMiddleware())
+	users.DELETE("/:user_id/mute", muteHandler.UnmuteUser, middleware.JWTMiddleware())
+	users.GET("/me/mutes", muteHandler.GetMutedUsers, middleware.JWTMiddleware())
+	users.POST("/me/muted-words", muteHandler.MuteWord, middleware.JWTMiddleware())
+	users.GET("/me/muted-words", muteHandler.GetMutedWords, middleware.JWTMiddleware())
+	users.DELETE("/me/muted-words/:id", muteHandler.UnmuteWord, middleware.JWTMiddleware())
 	users.GET("/:user_id/followers", followHandler.GetFollowers, middleware.OptionalJWTMiddleware())
 	users.GET("/:user_id/following", followHandler.GetFollowing, middleware.OptionalJWTMiddleware())
 	users.GET("/:user_id/follow-status", followHandler.CheckFollowStatus, middleware.JWTMiddleware())
 	users.GET("/:user_id/follow-counts", followHandler.GetFollowCounts, middleware.OptionalJWTMiddleware())
 	users.GET("/suggested", followHandler.GetSuggestedUsers, middleware.JWTMiddleware())
+	users.GET("/relationships", followHandler.GetRelationships, middleware.JWTMiddleware())
+	users.POST("/suggested/:user_id/dismiss", followHandler.DismissSuggestion, middleware.JWTMiddleware())
+	users.GET("/me/analytics", analyticsHandler.GetMyAnalytics, middleware.JWTMiddleware())
+	users.GET("/me/preferences", userHandler.GetPreferences, middleware.JWTMiddleware())
+	users.GET("/me/bookmarks", bookmarkHandler.GetBookmarks, middleware.JWTMiddleware())
+	users.GET("/me/subscribed-lists", listHandler.GetSubscribedLists, middleware.JWTMiddleware())
+	users.GET("/:user_id/lists", listHandler.GetUserLists, middleware.OptionalJWTMiddleware())
+	users.PUT("/me/preferences", userHandler.UpdatePreferences, middleware.JWTMiddleware())
 
 	// 投稿ルート
 	posts := api.Group("/posts")
@@ -121,11 +243,20 @@ func main() {
 	posts.GET("/:id", postHandler.GetPostByID, middleware.OptionalJWTMiddleware())
 	posts.PUT("/:id", postHandler.UpdatePost, middleware.JWTMiddleware())
 	posts.DELETE("/:id", postHandler.DeletePost, middleware.JWTMiddleware())
+	posts.GET("/:id/analytics", analyticsHandler.GetPostAnalytics, middleware.JWTMiddleware())
 	posts.GET("/:post_id/replies", timelineHandler.GetPostReplies, middleware.OptionalJWTMiddleware())
 	posts.POST("/:post_id/like", likeHandler.LikePost, middleware.JWTMiddleware())
 	posts.DELETE("/:post_id/like", likeHandler.UnlikePost, middleware.JWTMiddleware())
 	posts.GET("/:post_id/likes", likeHandler.GetPostLikes, middleware.OptionalJWTMiddleware())
 	posts.GET("/:post_id/like-status", likeHandler.CheckLikeStatus, middleware.JWTMiddleware())
+	posts.POST("/:post_id/reactions/:emoji", reactionHandler.AddReaction, middleware.JWTMiddleware())
+	posts.DELETE("/:post_id/reactions/:emoji", reactionHandler.RemoveReaction, middleware.JWTMiddleware())
+	posts.GET("/:post_id/reactions", reactionHandler.GetPostReactions, middleware.OptionalJWTMiddleware())
+	posts.GET("/:post_id/reaction-status", reactionHandler.GetReactionStatus, middleware.JWTMiddleware())
+	posts.POST("/:post_id/bookmark", bookmarkHandler.BookmarkPost, middleware.JWTMiddleware())
+	posts.DELETE("/:post_id/bookmark", bookmarkHandler.RemoveBookmark, middleware.JWTMiddleware())
+	posts.POST("/:post_id/repost", postHandler.Repost, middleware.JWTMiddleware())
+	posts.DELETE("/:post_id/repost", postHandler.UndoRepost, middleware.JWTMiddleware())
 	posts.POST("/:post_id/comments", commentHandler.CreateComment, middleware.JWTMiddleware())
 	posts.GET("/:post_id/comments", commentHandler.GetComments, middleware.OptionalJWTMiddleware())
 
@@ -139,8 +270,23 @@ func main() {
 	// タイムラインルート
 	timeline := api.Group("/timeline")
 	timeline.GET("/home", timelineHandler.GetHomeTimeline, middleware.JWTMiddleware())
+	timeline.GET("/home/new-count", timelineHandler.GetNewHomePostsCount, middleware.JWTMiddleware())
 	timeline.GET("/explore", timelineHandler.GetExploreTimeline, middleware.OptionalJWTMiddleware())
 	timeline.GET("/trending", timelineHandler.GetTrendingTimeline, middleware.OptionalJWTMiddleware())
+	timeline.GET("/for-you", timelineHandler.GetForYouTimeline, middleware.JWTMiddleware())
+
+	// リストルート
+	lists := api.Group("/lists")
+	lists.POST("", listHandler.CreateList, middleware.JWTMiddleware())
+	lists.GET("/:list_id", listHandler.GetList, middleware.OptionalJWTMiddleware())
+	lists.PUT("/:list_id", listHandler.UpdateList, middleware.JWTMiddleware())
+	lists.DELETE("/:list_id", listHandler.DeleteList, middleware.JWTMiddleware())
+	lists.GET("/:list_id/timeline", listHandler.GetListTimeline, middleware.OptionalJWTMiddleware())
+	lists.GET("/:list_id/members", listHandler.GetMembers, middleware.OptionalJWTMiddleware())
+	lists.POST("/:list_id/members/:user_id", listHandler.AddMember, middleware.JWTMiddleware())
+	lists.DELETE("/:list_id/members/:user_id", listHandler.RemoveMember, middleware.JWTMiddleware())
+	lists.POST("/:list_id/subscribe", listHandler.Subscribe, middleware.JWTMiddleware())
+	lists.DELETE("/:list_id/subscribe", listHandler.Unsubscribe, middleware.JWTMiddleware())
 
 	// 検索ルート
 	search := api.Group("/search")
@@ -150,6 +296,13 @@ func main() {
 	search.GET("/hashtags", searchHandler.SearchHashtags, middleware.OptionalJWTMiddleware())
 	search.GET("/hashtags/:hashtag/posts", searchHandler.GetHashtagPosts, middleware.OptionalJWTMiddleware())
 	search.GET("/trending-hashtags", searchHandler.GetTrendingHashtags, middleware.OptionalJWTMiddleware())
+	search.GET("/history", searchHandler.GetSearchHistory, middleware.JWTMiddleware())
+	search.DELETE("/history", searchHandler.ClearSearchHistory, middleware.JWTMiddleware())
+	search.DELETE("/history/:id", searchHandler.DeleteSearchHistoryEntry, middleware.JWTMiddleware())
+	search.GET("/saved", searchHandler.GetSavedSearches, middleware.JWTMiddleware())
+	search.POST("/saved", searchHandler.CreateSavedSearch, middleware.JWTMiddleware())
+	search.PUT("/saved/:id", searchHandler.UpdateSavedSearch, middleware.JWTMiddleware())
+	search.DELETE("/saved/:id", searchHandler.DeleteSavedSearch, middleware.JWTMiddleware())
 
 	// 通知ルート
 	notifications := api.Group("/notifications")
@@ -160,6 +313,17 @@ func main() {
 	notifications.DELETE("/:notification_id", notificationHandler.DeleteNotification, middleware.JWTMiddleware())
 	notifications.DELETE("/all", notificationHandler.DeleteAllNotifications, middleware.JWTMiddleware())
 
+	// ストリーミングルート
+	api.POST("/stream/tickets", streamHandler.CreateTicket, middleware.JWTMiddleware())
+	api.GET("/stream", streamHandler.Stream, middleware.StreamAuthMiddleware(streamService.RedeemStreamTicket))
+
+	// リアクションルート
+	api.GET("/reactions/emojis", reactionHandler.GetEmojis)
+
+	// アナリティクスルート
+	analytics := api.Group("/analytics")
+	analytics.POST("/events", analyticsHandler.RecordEvents, middleware.OptionalJWTMiddleware(), middleware.RateLimit(rateLimitRequests, rateLimitWindow))
+
 	// メディアルート
 	media := api.Group("/media")
 	media.POST("/upload", mediaHandler.UploadFile, middleware.JWTMiddleware())
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisConfig struct {
+	Host     string
+	Port     string
+	Password string
+	DB       int
+}
+
+func NewRedisConfig() *RedisConfig {
+	db, err := strconv.Atoi(getEnvOrDefault("REDIS_DB", "0"))
+	if err != nil {
+		db = 0
+	}
+
+	return &RedisConfig{
+		Host:     getEnvOrDefault("REDIS_HOST", "localhost"),
+		Port:     getEnvOrDefault("REDIS_PORT", "6379"),
+		Password: getEnvOrDefault("REDIS_PASSWORD", ""),
+		DB:       db,
+	}
+}
+
+func (config *RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%s", config.Host, config.Port)
+}
+
+func ConnectRedis() (*redis.Client, error) {
+	config := NewRedisConfig()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Addr(),
+		Password: config.Password,
+		DB:       config.DB,
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	log.Println("Successfully connected to redis")
+	return client, nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"digeon-backend/internal/models"
+	"fmt"
 	"log"
 
 	"gorm.io/gorm"
@@ -20,11 +21,34 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.User{},
 		&models.Post{},
 		&models.Hashtag{},
+		&models.PostMention{},
+		&models.LinkCard{},
 		&models.Like{},
+		&models.Reaction{},
+		&models.Bookmark{},
 		&models.Follow{},
+		&models.FollowRequest{},
+		&models.FollowImport{},
+		&models.UserSuggestion{},
+		&models.SuggestionState{},
+		&models.DismissedSuggestion{},
+		&models.Block{},
+		&models.MutedUser{},
+		&models.MutedWord{},
+		&models.List{},
+		&models.ListMember{},
+		&models.ListSubscription{},
 		&models.Comment{},
 		&models.Notification{},
+		&models.StreamTicket{},
+		&models.SearchHistory{},
+		&models.SavedSearch{},
 		&models.Media{},
+		&models.AnalyticsEvent{},
+		&models.PostMetricHourly{},
+		&models.UserMetricDaily{},
+		&models.TimelineEntry{},
+		&models.TimelineState{},
 	)
 	
 	if err != nil {
@@ -50,14 +74,54 @@ func createIndexes(db *gorm.DB) error {
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_author_created ON posts(author_id, created_at DESC)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at DESC)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_type ON posts(type)")
+	if err := dedupeReposts(db); err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_user_repost ON posts(author_id, original_post_id) WHERE type = 'repost' AND deleted_at IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to create idx_unique_user_repost: %w", err)
+	}
+	
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_post_mentions_user_created ON post_mentions(user_id, created_at DESC)")
 	
 	// Likes indexes
 	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_user_post_like ON likes(user_id, post_id) WHERE deleted_at IS NULL")
 	
+	// Reactions indexes
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_user_post_reaction ON reactions(user_id, post_id, emoji) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_reactions_post_emoji_created ON reactions(post_id, emoji, created_at DESC)")
+	
+	// Bookmarks indexes
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_user_post_bookmark ON bookmarks(user_id, post_id) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created ON bookmarks(user_id, created_at DESC)")
+	
 	// Follows indexes
 	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_follower_following ON follows(follower_id, following_id) WHERE deleted_at IS NULL")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_follows_follower ON follows(follower_id)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_follows_following ON follows(following_id)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_follow_request ON follow_requests(requester_id, target_id) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_follow_requests_target_created ON follow_requests(target_id, created_at DESC)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_follow_imports_active ON follow_imports(status) WHERE status IN ('pending', 'running')")
+	
+	// Suggestions indexes
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_user_suggestions_user_rank ON user_suggestions(user_id, rank)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_dismissed_suggestion ON dismissed_suggestions(user_id, dismissed_user_id)")
+	
+	// Blocks indexes
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_blocker_blocked ON blocks(blocker_id, blocked_id) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_blocks_blocker_created ON blocks(blocker_id, created_at DESC)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_blocks_blocked ON blocks(blocked_id)")
+	
+	// Mutes indexes
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_muted_user ON muted_users(user_id, muted_user_id) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_muted_users_user_created ON muted_users(user_id, created_at DESC)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_muted_word ON muted_words(user_id, word, is_hashtag) WHERE deleted_at IS NULL")
+	
+	// Lists indexes
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_lists_owner_created ON lists(owner_id, created_at DESC)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_list_member ON list_members(list_id, user_id) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_list_members_list_created ON list_members(list_id, created_at DESC)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_list_subscription ON list_subscriptions(list_id, user_id) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_list_subscriptions_user_created ON list_subscriptions(user_id, created_at DESC)")
 	
 	// Comments indexes
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_comments_post_created ON comments(post_id, created_at DESC)")
@@ -67,28 +131,72 @@ func createIndexes(db *gorm.DB) error {
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id, is_read, created_at DESC)")
 	
+	// Search history and saved searches indexes
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_search_history ON search_histories(user_id, query)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_search_histories_user_searched ON search_histories(user_id, searched_at DESC)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_saved_search_name ON saved_searches(user_id, name) WHERE deleted_at IS NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_saved_searches_notify ON saved_searches(checked_at) WHERE notify = true AND deleted_at IS NULL")
+	
 	// Media indexes
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_media_post_order ON media(post_id, \"order\")")
 	
+	// Analytics indexes
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_analytics_events_created ON analytics_events(created_at)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_analytics_events_post_created ON analytics_events(post_id, created_at) WHERE post_id IS NOT NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_analytics_events_target_created ON analytics_events(target_user_id, created_at)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_user_metrics_daily_day ON user_metrics_daily(day)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_post_metrics_hourly_bucket ON post_metrics_hourly(bucket_start)")
+	
+	// Timeline indexes
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_timeline_entries_user_created ON timeline_entries(user_id, created_at DESC, post_id DESC)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_timeline_entries_user_author ON timeline_entries(user_id, author_id)")
+	
 	// Full-text search indexes
-	db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_content_gin ON posts USING gin(to_tsvector('english', content))")
+	// Posts are searched through search_vector, which also covers Japanese
+	db.Exec("DROP INDEX IF EXISTS idx_posts_content_gin")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING gin(search_vector)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_search_vector_missing ON posts(id) WHERE search_vector IS NULL")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_users_username_gin ON users USING gin(to_tsvector('english', username))")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_users_display_name_gin ON users USING gin(to_tsvector('english', display_name))")
 	
 	return nil
 }
 
+// dedupeReposts deletes all but the first of each user's reposts of a post,
+// which could be duplicated before idx_unique_user_repost existed. The
+// counter reconciliation job corrects the repost and post counts.
+func dedupeReposts(db *gorm.DB) error {
+	result := db.Exec(`
+		UPDATE posts SET deleted_at = NOW()
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY author_id, original_post_id ORDER BY created_at, id
+				) AS n
+				FROM posts
+				WHERE type = 'repost' AND deleted_at IS NULL AND original_post_id IS NOT NULL
+			) reposts
+			WHERE n > 1
+		)`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete duplicate reposts: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Deleted %d duplicate reposts", result.RowsAffected)
+	}
+	return nil
+}
+
 func createConstraints(db *gorm.DB) error {
 	// User constraints
 	db.Exec("ALTER TABLE users ADD CONSTRAINT IF NOT EXISTS chk_username_length CHECK (length(username) >= 3)")
 	db.Exec("ALTER TABLE users ADD CONSTRAINT IF NOT EXISTS chk_email_format CHECK (email ~* '^[A-Za-z0-9._%-]+@[A-Za-z0-9.-]+\\.[A-Za-z]{2,4}$')")
 	
-	// Post constraints
-	db.Exec("ALTER TABLE posts ADD CONSTRAINT IF NOT EXISTS chk_content_length CHECK (length(content) <= 280)")
+	// Post constraints (content length is weighted and URLs count as a
+	// fixed length, so it is validated by the text package instead)
 	db.Exec("ALTER TABLE posts ADD CONSTRAINT IF NOT EXISTS chk_no_self_reply CHECK (id != parent_post_id)")
 	
-	// Comment constraints
-	db.Exec("ALTER TABLE comments ADD CONSTRAINT IF NOT EXISTS chk_comment_length CHECK (length(content) <= 280)")
+	// Comment constraints (content length is validated like posts)
 	db.Exec("ALTER TABLE comments ADD CONSTRAINT IF NOT EXISTS chk_no_self_reply CHECK (id != parent_comment_id)")
 	
 	// Follow constraints
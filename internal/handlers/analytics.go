@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+func (h *AnalyticsHandler) GetPostAnalytics(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	granularity := c.QueryParam("granularity")
+	if granularity == "" {
+		granularity = "hour"
+	}
+
+	defaultRange := 24 * time.Hour
+	if granularity == "day" {
+		defaultRange = 30 * 24 * time.Hour
+	}
+
+	since, until, err := h.getTimeRange(c, defaultRange)
+	if err != nil {
+		return err
+	}
+
+	analytics, err := h.analyticsService.GetPostAnalytics(postID, userID, granularity, since, until)
+	if err != nil {
+		if err.Error() == "invalid granularity" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "unauthorized to view analytics" {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch post analytics")
+	}
+
+	return c.JSON(http.StatusOK, analytics)
+}
+
+func (h *AnalyticsHandler) GetMyAnalytics(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	since, until, err := h.getTimeRange(c, 30*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("top_limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	analytics, err := h.analyticsService.GetAccountAnalytics(userID, since, until, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch account analytics")
+	}
+
+	return c.JSON(http.StatusOK, analytics)
+}
+
+func (h *AnalyticsHandler) RecordEvents(c echo.Context) error {
+	// Get user ID from context (optional)
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		userID = uuid.Nil
+	}
+
+	var req struct {
+		Events []services.RecordEventRequest `json:"events"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Events) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "events are required")
+	}
+	if len(req.Events) > 100 {
+		return echo.NewHTTPError(http.StatusBadRequest, "too many events")
+	}
+
+	if err := h.analyticsService.RecordClientEvents(userID, req.Events); err != nil {
+		switch err.Error() {
+		case "invalid post ID", "invalid user ID", "post_id is required":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if strings.HasPrefix(err.Error(), "unsupported event type: ") {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to record events")
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h *AnalyticsHandler) getTimeRange(c echo.Context, defaultRange time.Duration) (time.Time, time.Time, error) {
+	until := time.Now()
+	if untilParam := c.QueryParam("until"); untilParam != "" {
+		parsed, err := time.Parse(time.RFC3339, untilParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "invalid until parameter")
+		}
+		until = parsed
+	}
+
+	since := until.Add(-defaultRange)
+	if sinceParam := c.QueryParam("since"); sinceParam != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "invalid since parameter")
+		}
+		since = parsed
+	}
+
+	if !since.Before(until) {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "since must be before until")
+	}
+
+	return since, until, nil
+}
@@ -79,7 +79,7 @@ func (h *AuthHandler) Me(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
 	}
 
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(userID, userID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "user not found")
 	}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type BlockHandler struct {
+	blockService *services.BlockService
+}
+
+func NewBlockHandler(blockService *services.BlockService) *BlockHandler {
+	return &BlockHandler{
+		blockService: blockService,
+	}
+}
+
+func (h *BlockHandler) BlockUser(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	blockedIDParam := c.Param("user_id")
+	blockedID, err := uuid.Parse(blockedIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.blockService.BlockUser(userID, blockedID); err != nil {
+		if err.Error() == "cannot block yourself" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "user already blocked" {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if err.Error() == "user not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to block user")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "user blocked successfully",
+	})
+}
+
+func (h *BlockHandler) UnblockUser(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	blockedIDParam := c.Param("user_id")
+	blockedID, err := uuid.Parse(blockedIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.blockService.UnblockUser(userID, blockedID); err != nil {
+		if err.Error() == "block not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unblock user")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "user unblocked successfully",
+	})
+}
+
+func (h *BlockHandler) GetBlockedUsers(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	page := getPageRequest(c)
+
+	users, pageInfo, err := h.blockService.GetBlockedUsers(userID, page)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch blocked users")
+	}
+
+	response := map[string]interface{}{
+		"users":       users,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
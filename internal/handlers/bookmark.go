@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type BookmarkHandler struct {
+	bookmarkService *services.BookmarkService
+}
+
+func NewBookmarkHandler(bookmarkService *services.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkService: bookmarkService,
+	}
+}
+
+func (h *BookmarkHandler) BookmarkPost(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	if err := h.bookmarkService.BookmarkPost(userID, postID); err != nil {
+		if err.Error() == "post already bookmarked" {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to bookmark post")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "post bookmarked successfully",
+	})
+}
+
+func (h *BookmarkHandler) RemoveBookmark(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	if err := h.bookmarkService.RemoveBookmark(userID, postID); err != nil {
+		if err.Error() == "bookmark not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to remove bookmark")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "bookmark removed successfully",
+	})
+}
+
+func (h *BookmarkHandler) GetBookmarks(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	page := getPageRequest(c)
+
+	bookmarks, err := h.bookmarkService.GetBookmarks(userID, page)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch bookmarks")
+	}
+
+	return c.JSON(http.StatusOK, bookmarks)
+}
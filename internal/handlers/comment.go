@@ -4,7 +4,6 @@ import (
 	"digeon-backend/internal/middleware"
 	"digeon-backend/internal/services"
 	"net/http"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -89,18 +88,30 @@ func (h *CommentHandler) GetComments(c echo.Context) error {
 		userID = uuid.Nil
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	comments, err := h.commentService.GetComments(postID, userID, limit, offset)
+	comments, pageInfo, err := h.commentService.GetComments(postID, userID, page)
 	if err != nil {
+		switch err.Error() {
+		case "post not found":
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case "invalid cursor":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch comments")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"comments": comments,
-		"limit":    limit,
-		"offset":   offset,
-	})
+	response := map[string]interface{}{
+		"comments":    comments,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *CommentHandler) GetReplies(c echo.Context) error {
@@ -116,18 +127,30 @@ func (h *CommentHandler) GetReplies(c echo.Context) error {
 		userID = uuid.Nil
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	replies, err := h.commentService.GetReplies(commentID, userID, limit, offset)
+	replies, pageInfo, err := h.commentService.GetReplies(commentID, userID, page)
 	if err != nil {
+		switch err.Error() {
+		case "comment not found":
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case "invalid cursor":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch replies")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"replies": replies,
-		"limit":   limit,
-		"offset":  offset,
-	})
+	response := map[string]interface{}{
+		"replies":     replies,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *CommentHandler) UpdateComment(c echo.Context) error {
@@ -176,20 +199,3 @@ func (h *CommentHandler) DeleteComment(c echo.Context) error {
 		"message": "comment deleted successfully",
 	})
 }
-
-func (h *CommentHandler) getPaginationParams(c echo.Context) (int, int) {
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	return limit, offset
-}
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"digeon-backend/internal/services"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -32,11 +33,12 @@ func (h *FollowHandler) FollowUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	if err := h.followService.FollowUser(userID, followingID); err != nil {
+	requested, err := h.followService.FollowUser(userID, followingID)
+	if err != nil {
 		if err.Error() == "cannot follow yourself" {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
-		if err.Error() == "already following user" {
+		if err.Error() == "already following user" || err.Error() == "follow request already sent" {
 			return echo.NewHTTPError(http.StatusConflict, err.Error())
 		}
 		if err.Error() == "user not found" {
@@ -45,6 +47,12 @@ func (h *FollowHandler) FollowUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to follow user")
 	}
 
+	if requested {
+		return c.JSON(http.StatusAccepted, map[string]string{
+			"message": "follow request sent",
+		})
+	}
+
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "user followed successfully",
 	})
@@ -77,6 +85,107 @@ func (h *FollowHandler) UnfollowUser(c echo.Context) error {
 	})
 }
 
+func (h *FollowHandler) RemoveFollower(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	followerID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.followService.RemoveFollower(userID, followerID); err != nil {
+		if err.Error() == "cannot remove yourself" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "follower not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to remove follower")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "follower removed successfully",
+	})
+}
+
+func (h *FollowHandler) GetFollowRequests(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	page := getPageRequest(c)
+
+	requesters, pageInfo, err := h.followService.GetFollowRequests(userID, page)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch follow requests")
+	}
+
+	response := map[string]interface{}{
+		"requests":    requesters,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *FollowHandler) ApproveFollowRequest(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	requesterID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.followService.ApproveFollowRequest(userID, requesterID); err != nil {
+		if err.Error() == "follow request not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to approve follow request")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "follow request approved",
+	})
+}
+
+func (h *FollowHandler) RejectFollowRequest(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	requesterID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.followService.RejectFollowRequest(userID, requesterID); err != nil {
+		if err.Error() == "follow request not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to reject follow request")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "follow request rejected",
+	})
+}
+
 func (h *FollowHandler) GetFollowers(c echo.Context) error {
 	userIDParam := c.Param("user_id")
 	userID, err := uuid.Parse(userIDParam)
@@ -84,18 +193,33 @@ func (h *FollowHandler) GetFollowers(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
+
+	// Get viewer ID from context (optional)
+	viewerID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		viewerID = uuid.Nil
+	}
 
-	followers, err := h.followService.GetFollowers(userID, limit, offset)
+	followers, pageInfo, err := h.followService.GetFollowers(userID, viewerID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch followers")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"followers": followers,
-		"limit":     limit,
-		"offset":    offset,
-	})
+	response := map[string]interface{}{
+		"followers":   followers,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *FollowHandler) GetFollowing(c echo.Context) error {
@@ -105,17 +229,68 @@ func (h *FollowHandler) GetFollowing(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
+
+	// Get viewer ID from context (optional)
+	viewerID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		viewerID = uuid.Nil
+	}
 
-	following, err := h.followService.GetFollowing(userID, limit, offset)
+	following, pageInfo, err := h.followService.GetFollowing(userID, viewerID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch following")
 	}
 
+	response := map[string]interface{}{
+		"following":   following,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *FollowHandler) GetRelationships(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, param := range strings.Split(c.QueryParam("ids"), ",") {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	relationships, err := h.followService.GetRelationships(userID, ids)
+	if err != nil {
+		if err.Error() == "ids is required" || err.Error() == "at most 100 ids are allowed" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch relationships")
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"following": following,
-		"limit":     limit,
-		"offset":    offset,
+		"relationships": relationships,
 	})
 }
 
@@ -184,19 +359,28 @@ func (h *FollowHandler) GetSuggestedUsers(c echo.Context) error {
 	})
 }
 
-func (h *FollowHandler) getPaginationParams(c echo.Context) (int, int) {
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
-	if err != nil || limit <= 0 {
-		limit = 20
+func (h *FollowHandler) DismissSuggestion(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
 	}
-	if limit > 100 {
-		limit = 100
+
+	dismissedUserID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
+	if err := h.followService.DismissSuggestion(userID, dismissedUserID); err != nil {
+		if err.Error() == "cannot dismiss yourself" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "user not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to dismiss suggestion")
 	}
 
-	return limit, offset
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "suggestion dismissed",
+	})
 }
\ No newline at end of file
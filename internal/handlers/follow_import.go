@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Largest CSV accepted by ImportFollows
+const maxFollowImportFileSize = 1 << 20
+
+func (h *FollowHandler) ImportFollows(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "no file uploaded")
+	}
+	if fileHeader.Size > maxFollowImportFileSize {
+		return echo.NewHTTPError(http.StatusBadRequest, "file must be 1MB or less")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read file")
+	}
+	defer file.Close()
+
+	followImport, err := h.followService.ImportFollows(userID, file)
+	if err != nil {
+		switch err.Error() {
+		case "invalid CSV file", "no usernames found", "at most 5000 usernames are allowed":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case "a follow import is already running":
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to import follows")
+	}
+
+	return c.JSON(http.StatusAccepted, followImport)
+}
+
+func (h *FollowHandler) GetFollowImport(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	importID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid import ID")
+	}
+
+	followImport, err := h.followService.GetFollowImport(userID, importID)
+	if err != nil {
+		if err.Error() == "follow import not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch follow import")
+	}
+
+	return c.JSON(http.StatusOK, followImport)
+}
+
+func (h *FollowHandler) ExportFollowing(c echo.Context) error {
+	return h.exportFollows(c, "following.csv", h.followService.ExportFollowing)
+}
+
+func (h *FollowHandler) ExportFollowers(c echo.Context) error {
+	return h.exportFollows(c, "followers.csv", h.followService.ExportFollowers)
+}
+
+// exportFollows streams an export as a CSV download
+func (h *FollowHandler) exportFollows(c echo.Context, filename string, export func(uuid.UUID, io.Writer) error) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	response.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	response.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so a failure can only cut the file short
+	if err := export(userID, response); err != nil {
+		log.Printf("Follow export for user %s failed: %v", userID, err)
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"digeon-backend/internal/middleware"
 	"digeon-backend/internal/services"
 	"net/http"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -81,18 +80,27 @@ func (h *LikeHandler) GetPostLikes(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	users, err := h.likeService.GetPostLikes(postID, limit, offset)
+	users, pageInfo, err := h.likeService.GetPostLikes(postID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch post likes")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"users":  users,
-		"limit":  limit,
-		"offset": offset,
-	})
+	response := map[string]interface{}{
+		"users":       users,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *LikeHandler) GetUserLikes(c echo.Context) error {
@@ -102,18 +110,33 @@ func (h *LikeHandler) GetUserLikes(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	// Get viewer ID from context (optional)
+	viewerID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		viewerID = uuid.Nil
+	}
+
+	page := getPageRequest(c)
 
-	posts, err := h.likeService.GetUserLikes(userID, limit, offset)
+	posts, pageInfo, err := h.likeService.GetUserLikes(userID, viewerID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch user likes")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"posts":  posts,
-		"limit":  limit,
-		"offset": offset,
-	})
+	response := map[string]interface{}{
+		"posts":       posts,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *LikeHandler) CheckLikeStatus(c echo.Context) error {
@@ -137,20 +160,3 @@ func (h *LikeHandler) CheckLikeStatus(c echo.Context) error {
 		"is_liked": isLiked,
 	})
 }
-
-func (h *LikeHandler) getPaginationParams(c echo.Context) (int, int) {
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	return limit, offset
-}
\ No newline at end of file
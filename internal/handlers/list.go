@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type ListHandler struct {
+	listService     *services.ListService
+	timelineService *services.TimelineService
+}
+
+func NewListHandler(listService *services.ListService, timelineService *services.TimelineService) *ListHandler {
+	return &ListHandler{
+		listService:     listService,
+		timelineService: timelineService,
+	}
+}
+
+func (h *ListHandler) CreateList(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	var req services.CreateListRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	list, err := h.listService.CreateList(userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, list)
+}
+
+func (h *ListHandler) GetList(c echo.Context) error {
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		userID = uuid.Nil
+	}
+
+	list, err := h.listService.GetList(listID, userID)
+	if err != nil {
+		if err.Error() == "list not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch list")
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+func (h *ListHandler) UpdateList(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	var req services.UpdateListRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	list, err := h.listService.UpdateList(listID, userID, req)
+	if err != nil {
+		if err.Error() == "list not found or unauthorized" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+func (h *ListHandler) DeleteList(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	if err := h.listService.DeleteList(listID, userID); err != nil {
+		if err.Error() == "list not found or unauthorized" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete list")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "list deleted successfully",
+	})
+}
+
+func (h *ListHandler) GetUserLists(c echo.Context) error {
+	ownerID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		userID = uuid.Nil
+	}
+
+	page := getPageRequest(c)
+
+	lists, err := h.listService.GetUserLists(ownerID, userID, page)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch lists")
+	}
+
+	return c.JSON(http.StatusOK, lists)
+}
+
+func (h *ListHandler) GetSubscribedLists(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	page := getPageRequest(c)
+
+	lists, err := h.listService.GetSubscribedLists(userID, page)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch subscribed lists")
+	}
+
+	return c.JSON(http.StatusOK, lists)
+}
+
+func (h *ListHandler) AddMember(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.listService.AddMember(listID, userID, memberID); err != nil {
+		if err.Error() == "list not found or unauthorized" || err.Error() == "user not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "user already in list" {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to add list member")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "member added successfully",
+	})
+}
+
+func (h *ListHandler) RemoveMember(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.listService.RemoveMember(listID, userID, memberID); err != nil {
+		if err.Error() == "list not found or unauthorized" || err.Error() == "member not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to remove list member")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "member removed successfully",
+	})
+}
+
+func (h *ListHandler) GetMembers(c echo.Context) error {
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		userID = uuid.Nil
+	}
+
+	page := getPageRequest(c)
+
+	members, pageInfo, err := h.listService.GetMembers(listID, userID, page)
+	if err != nil {
+		if err.Error() == "list not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch list members")
+	}
+
+	response := map[string]interface{}{
+		"members":     members,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ListHandler) Subscribe(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	if err := h.listService.Subscribe(listID, userID); err != nil {
+		if err.Error() == "list not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "cannot subscribe to your own list" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "already subscribed to list" {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to subscribe to list")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "subscribed to list successfully",
+	})
+}
+
+func (h *ListHandler) Unsubscribe(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	if err := h.listService.Unsubscribe(listID, userID); err != nil {
+		if err.Error() == "subscription not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unsubscribe from list")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "unsubscribed from list successfully",
+	})
+}
+
+func (h *ListHandler) GetListTimeline(c echo.Context) error {
+	listID, err := uuid.Parse(c.Param("list_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid list ID")
+	}
+
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		// For anonymous users, we'll use a zero UUID
+		userID = uuid.Nil
+	}
+
+	page := getPageRequest(c)
+
+	timeline, err := h.timelineService.GetListTimeline(listID, userID, page)
+	if err != nil {
+		if err.Error() == "list not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch list timeline")
+	}
+
+	return c.JSON(http.StatusOK, timeline)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type MuteHandler struct {
+	muteService *services.MuteService
+}
+
+func NewMuteHandler(muteService *services.MuteService) *MuteHandler {
+	return &MuteHandler{
+		muteService: muteService,
+	}
+}
+
+func (h *MuteHandler) MuteUser(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	mutedUserIDParam := c.Param("user_id")
+	mutedUserID, err := uuid.Parse(mutedUserIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	// The body is optional; without one the mute never expires
+	var req services.MuteUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := h.muteService.MuteUser(userID, mutedUserID, req); err != nil {
+		if err.Error() == "user not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "cannot mute yourself" || err.Error() == "expires_at must be in the future" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to mute user")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "user muted successfully",
+	})
+}
+
+func (h *MuteHandler) UnmuteUser(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	mutedUserIDParam := c.Param("user_id")
+	mutedUserID, err := uuid.Parse(mutedUserIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	if err := h.muteService.UnmuteUser(userID, mutedUserID); err != nil {
+		if err.Error() == "mute not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unmute user")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "user unmuted successfully",
+	})
+}
+
+func (h *MuteHandler) GetMutedUsers(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	page := getPageRequest(c)
+
+	mutes, pageInfo, err := h.muteService.GetMutedUsers(userID, page)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch muted users")
+	}
+
+	response := map[string]interface{}{
+		"mutes":       mutes,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *MuteHandler) MuteWord(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	var req services.MuteWordRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	mutedWord, err := h.muteService.MuteWord(userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, mutedWord)
+}
+
+func (h *MuteHandler) GetMutedWords(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	mutedWords, err := h.muteService.GetMutedWords(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch muted words")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"muted_words": mutedWords,
+	})
+}
+
+func (h *MuteHandler) UnmuteWord(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	mutedWordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid muted word ID")
+	}
+
+	if err := h.muteService.UnmuteWord(userID, mutedWordID); err != nil {
+		if err.Error() == "muted word not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unmute word")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "word unmuted successfully",
+	})
+}
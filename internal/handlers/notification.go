@@ -4,7 +4,6 @@ import (
 	"digeon-backend/internal/middleware"
 	"digeon-backend/internal/services"
 	"net/http"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -26,18 +25,27 @@ func (h *NotificationHandler) GetNotifications(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	notifications, err := h.notificationService.GetNotifications(userID, limit, offset)
+	notifications, pageInfo, err := h.notificationService.GetNotifications(userID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch notifications")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"notifications": notifications,
-		"limit":         limit,
-		"offset":        offset,
-	})
+		"limit":         page.Limit,
+		"next_cursor":   pageInfo.NextCursor,
+		"prev_cursor":   pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *NotificationHandler) GetUnreadCount(c echo.Context) error {
@@ -132,21 +140,4 @@ func (h *NotificationHandler) DeleteAllNotifications(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "all notifications deleted",
 	})
-}
-
-func (h *NotificationHandler) getPaginationParams(c echo.Context) (int, int) {
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	return limit, offset
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"digeon-backend/internal/services"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getPageRequest reads the limit, cursor and include_total query parameters
+// used by cursor-paginated listings
+func getPageRequest(c echo.Context) services.PageRequest {
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	includeTotal, _ := strconv.ParseBool(c.QueryParam("include_total"))
+
+	return services.PageRequest{
+		Limit:        limit,
+		Cursor:       c.QueryParam("cursor"),
+		IncludeTotal: includeTotal,
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"digeon-backend/internal/middleware"
 	"digeon-backend/internal/services"
 	"net/http"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -49,12 +48,8 @@ func (h *PostHandler) GetPostByID(c echo.Context) error {
 	// Get user ID from context (optional)
 	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
 	if !ok {
-		// If user is not authenticated, just return the post without user-specific details
-		post, err := h.postService.GetPostByID(postID)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusNotFound, "post not found")
-		}
-		return c.JSON(http.StatusOK, post)
+		// Anonymous viewers get no user-specific details
+		userID = uuid.Nil
 	}
 
 	post, err := h.postService.GetPostWithDetails(postID, userID)
@@ -72,30 +67,36 @@ func (h *PostHandler) GetUserPosts(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	// Get pagination parameters
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
+	page := getPageRequest(c)
 
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
+	// Get viewer ID from context (optional)
+	viewerID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		viewerID = uuid.Nil
 	}
 
-	posts, err := h.postService.GetPostsByUserID(targetUserID, limit, offset)
+	posts, pageInfo, err := h.postService.GetPostsByUserID(targetUserID, viewerID, page)
 	if err != nil {
+		if err.Error() == "user not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch posts")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"posts":  posts,
-		"limit":  limit,
-		"offset": offset,
-	})
+	response := map[string]interface{}{
+		"posts":       posts,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *PostHandler) UpdatePost(c echo.Context) error {
@@ -143,4 +144,51 @@ func (h *PostHandler) DeletePost(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "post deleted successfully",
 	})
-}
\ No newline at end of file
+}
+
+func (h *PostHandler) Repost(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	post, err := h.postService.Repost(userID, postID)
+	if err != nil {
+		if err.Error() == "original post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to repost")
+	}
+
+	return c.JSON(http.StatusOK, post)
+}
+
+func (h *PostHandler) UndoRepost(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	if err := h.postService.UndoRepost(userID, postID); err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to undo repost")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "repost removed successfully",
+	})
+}
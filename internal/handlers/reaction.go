@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type ReactionHandler struct {
+	reactionService *services.ReactionService
+}
+
+func NewReactionHandler(reactionService *services.ReactionService) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: reactionService,
+	}
+}
+
+func (h *ReactionHandler) AddReaction(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	emoji := h.getEmojiParam(c)
+
+	if err := h.reactionService.AddReaction(userID, postID, emoji); err != nil {
+		if err.Error() == "unsupported reaction" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "reaction already exists" {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to add reaction")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "reaction added successfully",
+	})
+}
+
+func (h *ReactionHandler) RemoveReaction(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	emoji := h.getEmojiParam(c)
+
+	if err := h.reactionService.RemoveReaction(userID, postID, emoji); err != nil {
+		if err.Error() == "unsupported reaction" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "reaction not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to remove reaction")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "reaction removed successfully",
+	})
+}
+
+func (h *ReactionHandler) GetPostReactions(c echo.Context) error {
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	// Get user ID from context (optional)
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		userID = uuid.Nil
+	}
+
+	emoji := c.QueryParam("emoji")
+	page := getPageRequest(c)
+
+	reactions, pageInfo, err := h.reactionService.GetPostReactions(postID, userID, emoji, page)
+	if err != nil {
+		switch err.Error() {
+		case "post not found":
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case "invalid cursor":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch post reactions")
+	}
+
+	response := map[string]interface{}{
+		"reactions":   reactions,
+		"limit":       page.Limit,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if pageInfo.Total != nil {
+		response["total"] = *pageInfo.Total
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ReactionHandler) GetReactionStatus(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	postIDParam := c.Param("post_id")
+	postID, err := uuid.Parse(postIDParam)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+	}
+
+	emojis, err := h.reactionService.GetUserReactions(userID, postID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to check reaction status")
+	}
+
+	return c.JSON(http.StatusOK, map[string][]string{
+		"reactions": emojis,
+	})
+}
+
+func (h *ReactionHandler) GetEmojis(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string][]string{
+		"emojis": h.reactionService.Emojis(),
+	})
+}
+
+// getEmojiParam returns the emoji path parameter, decoding it if the router
+// left it percent-encoded
+func (h *ReactionHandler) getEmojiParam(c echo.Context) string {
+	emoji := c.Param("emoji")
+	if decoded, err := url.PathUnescape(emoji); err == nil {
+		return decoded
+	}
+	return emoji
+}
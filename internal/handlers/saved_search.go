@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *SearchHandler) CreateSavedSearch(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	var req services.SavedSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	savedSearch, err := h.searchService.CreateSavedSearch(userID, req)
+	if err != nil {
+		if err.Error() == "a saved search with this name already exists" {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, savedSearch)
+}
+
+func (h *SearchHandler) GetSavedSearches(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	savedSearches, err := h.searchService.GetSavedSearches(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch saved searches")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"saved_searches": savedSearches,
+	})
+}
+
+func (h *SearchHandler) UpdateSavedSearch(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	savedSearchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid saved search ID")
+	}
+
+	var req services.UpdateSavedSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	savedSearch, err := h.searchService.UpdateSavedSearch(userID, savedSearchID, req)
+	if err != nil {
+		switch err.Error() {
+		case "saved search not found":
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case "a saved search with this name already exists":
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, savedSearch)
+}
+
+func (h *SearchHandler) DeleteSavedSearch(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	savedSearchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid saved search ID")
+	}
+
+	if err := h.searchService.DeleteSavedSearch(userID, savedSearchID); err != nil {
+		if err.Error() == "saved search not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete saved search")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "saved search deleted successfully",
+	})
+}
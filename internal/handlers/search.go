@@ -5,6 +5,7 @@ import (
 	"digeon-backend/internal/services"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -44,6 +45,7 @@ func (h *SearchHandler) SearchAll(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "search failed")
 	}
+	h.recordSearch(userID, query)
 
 	return c.JSON(http.StatusOK, results)
 }
@@ -56,10 +58,19 @@ func (h *SearchHandler) SearchUsers(c echo.Context) error {
 
 	limit, offset := h.getPaginationParams(c)
 
-	results, err := h.searchService.SearchUsers(query, limit, offset)
+	// Get user ID from context (optional)
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		userID = uuid.Nil
+	}
+
+	results, err := h.searchService.SearchUsers(query, userID, limit, offset)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "user search failed")
 	}
+	if offset == 0 {
+		h.recordSearch(userID, query)
+	}
 
 	return c.JSON(http.StatusOK, results)
 }
@@ -76,12 +87,22 @@ func (h *SearchHandler) SearchPosts(c echo.Context) error {
 		userID = uuid.Nil
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
+
+	sort := services.SearchSort(c.QueryParam("sort"))
 
-	results, err := h.searchService.SearchPosts(query, userID, limit, offset)
+	results, err := h.searchService.SearchPosts(query, userID, sort, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" || err.Error() == "invalid sort" ||
+			strings.HasPrefix(err.Error(), "invalid search query: ") {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "post search failed")
 	}
+	// Later pages are the same search
+	if page.Cursor == "" {
+		h.recordSearch(userID, query)
+	}
 
 	return c.JSON(http.StatusOK, results)
 }
@@ -114,10 +135,13 @@ func (h *SearchHandler) GetHashtagPosts(c echo.Context) error {
 		userID = uuid.Nil
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	results, err := h.searchService.GetHashtagPosts(hashtag, userID, limit, offset)
+	results, err := h.searchService.GetHashtagPosts(hashtag, userID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch hashtag posts")
 	}
 
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// recordSearch adds a query to a signed-in viewer's search history. A
+// failure there shouldn't fail the search, so it is only logged.
+func (h *SearchHandler) recordSearch(userID uuid.UUID, query string) {
+	if userID == uuid.Nil {
+		return
+	}
+	if err := h.searchService.RecordSearch(userID, query); err != nil {
+		log.Printf("Recording search for user %s failed: %v", userID, err)
+	}
+}
+
+func (h *SearchHandler) GetSearchHistory(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	history, err := h.searchService.GetSearchHistory(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch search history")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"history": history,
+	})
+}
+
+func (h *SearchHandler) DeleteSearchHistoryEntry(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	entryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid search history entry ID")
+	}
+
+	if err := h.searchService.DeleteSearchHistoryEntry(userID, entryID); err != nil {
+		if err.Error() == "search history entry not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete search history entry")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "search history entry deleted successfully",
+	})
+}
+
+func (h *SearchHandler) ClearSearchHistory(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	if err := h.searchService.ClearSearchHistory(userID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to clear search history")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "search history cleared successfully",
+	})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"digeon-backend/internal/middleware"
+	"digeon-backend/internal/services"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type StreamHandler struct {
+	streamService *services.StreamService
+}
+
+func NewStreamHandler(streamService *services.StreamService) *StreamHandler {
+	return &StreamHandler{
+		streamService: streamService,
+	}
+}
+
+// CreateTicket issues a single-use ticket for opening the stream from an
+// EventSource
+func (h *StreamHandler) CreateTicket(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+	username, _ := c.Get(middleware.UsernameKey).(string)
+
+	ticket, err := h.streamService.CreateStreamTicket(userID, username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create stream ticket")
+	}
+
+	return c.JSON(http.StatusCreated, ticket)
+}
+
+// Stream serves the user's real-time events as server-sent events. Clients
+// can pass the IDs of the posts on screen in "posts" to receive their
+// like/repost counts.
+func (h *StreamHandler) Stream(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	var watched []uuid.UUID
+	if postsParam := c.QueryParam("posts"); postsParam != "" {
+		for _, idParam := range strings.Split(postsParam, ",") {
+			postID, err := uuid.Parse(strings.TrimSpace(idParam))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
+			}
+			watched = append(watched, postID)
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// Keep reverse proxies from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	send := func(event string, data interface{}) error {
+		if event == "" {
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return err
+			}
+			res.Flush()
+			return nil
+		}
+
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	}
+
+	// The response has started, so errors can only be logged
+	if err := h.streamService.Stream(c.Request().Context(), userID, watched, send); err != nil {
+		log.Printf("Stream for user %s ended: %v", userID, err)
+	}
+	return nil
+}
@@ -26,16 +26,54 @@ func (h *TimelineHandler) GetHomeTimeline(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
+
+	sinceID := uuid.Nil
+	if sinceParam := c.QueryParam("since_id"); sinceParam != "" {
+		var err error
+		if sinceID, err = uuid.Parse(sinceParam); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid since_id")
+		}
+	}
 
-	timeline, err := h.timelineService.GetHomeTimeline(userID, limit, offset)
+	timeline, err := h.timelineService.GetHomeTimeline(userID, page, sinceID)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch home timeline")
 	}
 
 	return c.JSON(http.StatusOK, timeline)
 }
 
+func (h *TimelineHandler) GetNewHomePostsCount(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	sinceID, err := uuid.Parse(c.QueryParam("since_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid since_id")
+	}
+
+	count, err := h.timelineService.GetNewHomePostsCount(userID, sinceID)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to count new posts")
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{
+		"count": count,
+	})
+}
+
 func (h *TimelineHandler) GetExploreTimeline(c echo.Context) error {
 	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
 	if !ok {
@@ -43,10 +81,13 @@ func (h *TimelineHandler) GetExploreTimeline(c echo.Context) error {
 		userID = uuid.Nil
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	timeline, err := h.timelineService.GetExploreTimeline(userID, limit, offset)
+	timeline, err := h.timelineService.GetExploreTimeline(userID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch explore timeline")
 	}
 
@@ -70,6 +111,22 @@ func (h *TimelineHandler) GetTrendingTimeline(c echo.Context) error {
 	return c.JSON(http.StatusOK, timeline)
 }
 
+func (h *TimelineHandler) GetForYouTimeline(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	limit, offset := h.getPaginationParams(c)
+
+	timeline, err := h.timelineService.GetForYouTimeline(userID, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch for you timeline")
+	}
+
+	return c.JSON(http.StatusOK, timeline)
+}
+
 func (h *TimelineHandler) GetPostReplies(c echo.Context) error {
 	postIDParam := c.Param("post_id")
 	postID, err := uuid.Parse(postIDParam)
@@ -83,10 +140,13 @@ func (h *TimelineHandler) GetPostReplies(c echo.Context) error {
 		userID = uuid.Nil
 	}
 
-	limit, offset := h.getPaginationParams(c)
+	page := getPageRequest(c)
 
-	replies, err := h.timelineService.GetPostReplies(postID, userID, limit, offset)
+	replies, err := h.timelineService.GetPostReplies(postID, userID, page)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch post replies")
 	}
 
@@ -26,7 +26,13 @@ func (h *UserHandler) GetUserByID(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	user, err := h.userService.GetUserByID(userID)
+	// Get viewer ID from context (optional)
+	viewerID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		viewerID = uuid.Nil
+	}
+
+	user, err := h.userService.GetUserByID(userID, viewerID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "user not found")
 	}
@@ -40,7 +46,13 @@ func (h *UserHandler) GetUserByUsername(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "username is required")
 	}
 
-	user, err := h.userService.GetUserByUsername(username)
+	// Get viewer ID from context (optional)
+	viewerID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		viewerID = uuid.Nil
+	}
+
+	user, err := h.userService.GetUserByUsername(username, viewerID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "user not found")
 	}
@@ -66,4 +78,37 @@ func (h *UserHandler) UpdateProfile(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "profile updated successfully",
 	})
-}
\ No newline at end of file
+}
+
+func (h *UserHandler) GetPreferences(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	preferences, err := h.userService.GetPreferences(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	return c.JSON(http.StatusOK, preferences)
+}
+
+func (h *UserHandler) UpdatePreferences(c echo.Context) error {
+	userID, ok := c.Get(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
+	}
+
+	var req services.UpdatePreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	preferences, err := h.userService.UpdatePreferences(userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, preferences)
+}
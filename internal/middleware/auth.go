@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -60,4 +61,44 @@ func OptionalJWTMiddleware() echo.MiddlewareFunc {
 			return next(c)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// StreamAuthMiddleware works like JWTMiddleware but also accepts a stream
+// ticket in the "ticket" query parameter, since EventSource can't set
+// headers. Access tokens are never accepted in the URL, where they would
+// end up in access logs.
+func StreamAuthMiddleware(redeemTicket func(ticket string) (uuid.UUID, string, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if auth := c.Request().Header.Get("Authorization"); auth != "" {
+				tokenString := strings.TrimPrefix(auth, "Bearer ")
+				if tokenString == auth {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
+				}
+
+				claims, err := utils.ValidateToken(tokenString)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+				}
+
+				c.Set(UserIDKey, claims.UserID)
+				c.Set(UsernameKey, claims.Username)
+				return next(c)
+			}
+
+			ticket := c.QueryParam("ticket")
+			if ticket == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header or stream ticket")
+			}
+
+			userID, username, err := redeemTicket(ticket)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid stream ticket")
+			}
+
+			c.Set(UserIDKey, userID)
+			c.Set(UsernameKey, username)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	echo_middleware "github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit allows each client a burst of requests, refilled evenly over
+// window. Signed-in users are told apart by user ID and everyone else by
+// IP, so it has to run after the JWT middleware.
+func RateLimit(requests int, window time.Duration) echo.MiddlewareFunc {
+	return echo_middleware.RateLimiterWithConfig(echo_middleware.RateLimiterConfig{
+		Store: echo_middleware.NewRateLimiterMemoryStoreWithConfig(echo_middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(float64(requests) / window.Seconds()),
+			Burst:     requests,
+			ExpiresIn: window,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			if userID, ok := c.Get(UserIDKey).(uuid.UUID); ok {
+				return "user:" + userID.String(), nil
+			}
+			return "ip:" + c.RealIP(), nil
+		},
+	})
+}
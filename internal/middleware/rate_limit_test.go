@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRateLimit(t *testing.T) {
+	e := echo.New()
+	alice, bob := uuid.New(), uuid.New()
+	users := map[string]uuid.UUID{"alice": alice, "bob": bob}
+	e.POST("/events", func(c echo.Context) error {
+		return c.NoContent(http.StatusAccepted)
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		// Stands in for the JWT middleware
+		return func(c echo.Context) error {
+			if userID, ok := users[c.Request().Header.Get("X-Test-User")]; ok {
+				c.Set(UserIDKey, userID)
+			}
+			return next(c)
+		}
+	}, RateLimit(2, time.Hour))
+
+	send := func(user, ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/events", nil)
+		req.Header.Set("X-Test-User", user)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	steps := []struct {
+		user, ip string
+		want     int
+	}{
+		{"alice", "192.0.2.1", http.StatusAccepted},
+		{"alice", "192.0.2.2", http.StatusAccepted},
+		// Counted per user, whichever address they come from
+		{"alice", "192.0.2.3", http.StatusTooManyRequests},
+		{"bob", "192.0.2.1", http.StatusAccepted},
+		// Anonymous clients are counted per address
+		{"", "192.0.2.1", http.StatusAccepted},
+		{"", "192.0.2.1", http.StatusAccepted},
+		{"", "192.0.2.1", http.StatusTooManyRequests},
+		{"", "192.0.2.9", http.StatusAccepted},
+	}
+	for i, step := range steps {
+		if got := send(step.user, step.ip); got != step.want {
+			t.Errorf("request %d from %q at %s = %d, want %d", i+1, step.user, step.ip, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RedactQueryParams hides the values of the named query parameters in the
+// request URI seen by middleware registered before it, such as the access
+// logger. Handlers still read the real values.
+func RedactQueryParams(names ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.URL.RawQuery != "" {
+				req.RequestURI = redactQuery(req.RequestURI, names)
+			}
+			return next(c)
+		}
+	}
+}
+
+// redactQuery replaces the values of the named parameters in uri's query
+// with REDACTED, keeping everything else as sent
+func redactQuery(uri string, names []string) string {
+	path, rawQuery, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		for _, name := range names {
+			if key == name {
+				pairs[i] = name + "=REDACTED"
+				break
+			}
+		}
+	}
+	return path + "?" + strings.Join(pairs, "&")
+}
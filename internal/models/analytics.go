@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AnalyticsEventType string
+
+const (
+	AnalyticsEventImpression   AnalyticsEventType = "impression"
+	AnalyticsEventLike         AnalyticsEventType = "like"
+	AnalyticsEventUnlike       AnalyticsEventType = "unlike"
+	AnalyticsEventRepost       AnalyticsEventType = "repost"
+	AnalyticsEventQuote        AnalyticsEventType = "quote"
+	AnalyticsEventReply        AnalyticsEventType = "reply"
+	AnalyticsEventProfileClick AnalyticsEventType = "profile_click"
+	AnalyticsEventLinkClick    AnalyticsEventType = "link_click"
+	AnalyticsEventFollow       AnalyticsEventType = "follow"
+	AnalyticsEventUnfollow     AnalyticsEventType = "unfollow"
+)
+
+// AnalyticsEvent is an append-only record of a single interaction.
+// Rows are never updated or deleted; rollup jobs aggregate them into
+// PostMetricHourly and UserMetricDaily.
+type AnalyticsEvent struct {
+	ID           uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type         AnalyticsEventType `gorm:"not null;size:32" json:"type"`
+	ActorID      *uuid.UUID         `gorm:"type:uuid" json:"actor_id,omitempty"` // nil for anonymous viewers
+	TargetUserID uuid.UUID          `gorm:"type:uuid;not null" json:"target_user_id"`
+	PostID       *uuid.UUID         `gorm:"type:uuid" json:"post_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (e *AnalyticsEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// PostMetricHourly is the hourly rollup of analytics events per post
+type PostMetricHourly struct {
+	PostID      uuid.UUID          `gorm:"type:uuid;primaryKey" json:"post_id"`
+	BucketStart time.Time          `gorm:"primaryKey" json:"bucket_start"`
+	EventType   AnalyticsEventType `gorm:"primaryKey;size:32" json:"event_type"`
+	Count       int64              `gorm:"not null;default:0" json:"count"`
+}
+
+func (PostMetricHourly) TableName() string {
+	return "post_metrics_hourly"
+}
+
+// UserMetricDaily is the daily rollup of analytics events per target user
+type UserMetricDaily struct {
+	UserID    uuid.UUID          `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Day       time.Time          `gorm:"type:date;primaryKey" json:"day"`
+	EventType AnalyticsEventType `gorm:"primaryKey;size:32" json:"event_type"`
+	Count     int64              `gorm:"not null;default:0" json:"count"`
+}
+
+func (UserMetricDaily) TableName() string {
+	return "user_metrics_daily"
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Block struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BlockerID uuid.UUID `gorm:"type:uuid;not null;index" json:"blocker_id"`
+	BlockedID uuid.UUID `gorm:"type:uuid;not null;index" json:"blocked_id"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Blocker User `gorm:"foreignKey:BlockerID" json:"blocker"`
+	Blocked User `gorm:"foreignKey:BlockedID" json:"blocked"`
+}
+
+func (b *Block) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Block) TableName() string {
+	return "blocks"
+}
@@ -11,7 +11,7 @@ type Comment struct {
 	ID      uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID  uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
 	PostID  uuid.UUID `gorm:"type:uuid;not null;index" json:"post_id"`
-	Content string    `gorm:"size:280;not null" json:"content"`
+	Content string    `gorm:"type:text;not null" json:"content"` // length and size are enforced by text.IsValidLength
 	
 	// For nested comments (replies to comments)
 	ParentCommentID *uuid.UUID `gorm:"type:uuid;index" json:"parent_comment_id,omitempty"`
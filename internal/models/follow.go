@@ -31,4 +31,30 @@ func (f *Follow) BeforeCreate(tx *gorm.DB) error {
 // Ensure unique constraint on follower_id and following_id
 func (Follow) TableName() string {
 	return "follows"
+}
+
+// FollowRequest is a pending request to follow a private account
+type FollowRequest struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RequesterID uuid.UUID `gorm:"type:uuid;not null;index" json:"requester_id"`
+	TargetID    uuid.UUID `gorm:"type:uuid;not null;index" json:"target_id"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Requester User `gorm:"foreignKey:RequesterID" json:"requester"`
+	Target    User `gorm:"foreignKey:TargetID" json:"target"`
+}
+
+func (r *FollowRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (FollowRequest) TableName() string {
+	return "follow_requests"
 }
\ No newline at end of file
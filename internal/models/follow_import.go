@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FollowImportStatus string
+
+const (
+	FollowImportStatusPending   FollowImportStatus = "pending"
+	FollowImportStatusRunning   FollowImportStatus = "running"
+	FollowImportStatusCompleted FollowImportStatus = "completed"
+	FollowImportStatusFailed    FollowImportStatus = "failed"
+)
+
+// FollowImport is a background job following every username from an
+// uploaded CSV. Progress is saved as it runs, so an interrupted import
+// resumes from Processed.
+type FollowImport struct {
+	ID        uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status    FollowImportStatus `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Usernames []string           `gorm:"type:jsonb;serializer:json;not null" json:"-"`
+
+	Total     int `gorm:"not null" json:"total"`
+	Processed int `gorm:"not null;default:0" json:"processed"`
+	Followed  int `gorm:"not null;default:0" json:"followed"`
+	Requested int `gorm:"not null;default:0" json:"requested"`
+	// Already followed or requested, or the importing user themselves
+	Skipped int `gorm:"not null;default:0" json:"skipped"`
+	Failed  int `gorm:"not null;default:0" json:"failed"`
+	// Usernames that don't belong to any account the user can follow
+	UnresolvedUsernames []string `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"unresolved_usernames"`
+	Error               string   `gorm:"size:500" json:"error,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (i *FollowImport) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (FollowImport) TableName() string {
+	return "follow_imports"
+}
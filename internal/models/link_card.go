@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkCard is the unfurled preview of a URL, shared by every post that links
+// to the same normalized URL until it expires
+type LinkCard struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	URL         string     `gorm:"uniqueIndex;not null;size:2048" json:"url"`
+	Title       string     `gorm:"size:300" json:"title"`
+	Description string     `gorm:"size:1000" json:"description"`
+	SiteName    string     `gorm:"size:200" json:"site_name"`
+	ImageURL    string     `gorm:"size:500" json:"image_url,omitempty"`
+	MediaID     *uuid.UUID `gorm:"type:uuid" json:"media_id,omitempty"`
+	FetchError  string     `gorm:"size:500" json:"-"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (l *LinkCard) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// List is a curated set of accounts whose posts can be read as a timeline
+type List struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OwnerID     uuid.UUID `gorm:"type:uuid;not null;index" json:"owner_id"`
+	Name        string    `gorm:"not null;size:25" json:"name"`
+	Description string    `gorm:"size:100" json:"description"`
+	// Private lists are only visible to their owner
+	IsPrivate bool `gorm:"default:false" json:"is_private"`
+
+	MembersCount     int `gorm:"default:0" json:"members_count"`
+	SubscribersCount int `gorm:"default:0" json:"subscribers_count"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Owner User `gorm:"foreignKey:OwnerID" json:"owner"`
+}
+
+func (l *List) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (List) TableName() string {
+	return "lists"
+}
+
+type ListMember struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ListID uuid.UUID `gorm:"type:uuid;not null;index" json:"list_id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	List List `gorm:"foreignKey:ListID" json:"list"`
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
+
+func (m *ListMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListMember) TableName() string {
+	return "list_members"
+}
+
+type ListSubscription struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ListID uuid.UUID `gorm:"type:uuid;not null;index" json:"list_id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	List List `gorm:"foreignKey:ListID" json:"list"`
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
+
+func (s *ListSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListSubscription) TableName() string {
+	return "list_subscriptions"
+}
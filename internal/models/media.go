@@ -27,6 +27,7 @@ type Media struct {
 	Height      int       `gorm:"default:0" json:"height"`
 	Duration    int       `gorm:"default:0" json:"duration"` // For videos in seconds
 	AltText     string    `gorm:"size:500" json:"alt_text"`
+	IsSensitive bool      `gorm:"default:false" json:"is_sensitive"`
 	Order       int       `gorm:"default:0" json:"order"` // For ordering multiple media in a post
 	
 	CreatedAt time.Time `json:"created_at"`
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostMention is a resolved @username reference inside a post.
+// Start and End are character (code point) offsets into Post.Content,
+// with End exclusive, so clients can linkify the mention in place.
+type PostMention struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PostID   uuid.UUID `gorm:"type:uuid;not null;index" json:"post_id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Username string    `gorm:"size:50;not null" json:"username"`
+	Start    int       `gorm:"not null" json:"start"`
+	End      int       `gorm:"not null" json:"end"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m *PostMention) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
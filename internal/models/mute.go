@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MutedUser hides a user's posts and notifications from the muting user
+// until ExpiresAt, or indefinitely when it is nil. The muted user isn't told.
+type MutedUser struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	MutedUserID uuid.UUID  `gorm:"type:uuid;not null;index" json:"muted_user_id"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User      User `gorm:"foreignKey:UserID" json:"-"`
+	MutedUser User `gorm:"foreignKey:MutedUserID" json:"muted_user"`
+}
+
+func (m *MutedUser) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (MutedUser) TableName() string {
+	return "muted_users"
+}
+
+type MuteScope string
+
+const (
+	// Only the home timeline
+	MuteScopeHome MuteScope = "home"
+	// Only notifications
+	MuteScopeNotifications MuteScope = "notifications"
+	// Every timeline, search and notifications
+	MuteScopeAll MuteScope = "all"
+)
+
+// IsValid reports whether s is one of the known scopes
+func (s MuteScope) IsValid() bool {
+	switch s {
+	case MuteScopeHome, MuteScopeNotifications, MuteScopeAll:
+		return true
+	}
+	return false
+}
+
+// MutedWord hides posts containing a word, or tagged with a hashtag, within
+// Scope. Word is stored normalized; Pattern is the regular expression that
+// matches it in normalized post content.
+type MutedWord struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Word      string     `gorm:"not null;size:100" json:"word"`
+	IsHashtag bool       `gorm:"default:false" json:"is_hashtag"`
+	Pattern   string     `gorm:"size:1000" json:"-"`
+	Scope     MuteScope  `gorm:"size:20;not null;default:'all'" json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (m *MutedWord) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (MutedWord) TableName() string {
+	return "muted_words"
+}
@@ -10,12 +10,15 @@ import (
 type NotificationType string
 
 const (
-	NotificationTypeFollow    NotificationType = "follow"
-	NotificationTypeLike      NotificationType = "like"
-	NotificationTypeComment   NotificationType = "comment"
-	NotificationTypeRepost    NotificationType = "repost"
-	NotificationTypeQuote     NotificationType = "quote"
-	NotificationTypeMention   NotificationType = "mention"
+	NotificationTypeFollow        NotificationType = "follow"
+	NotificationTypeLike          NotificationType = "like"
+	NotificationTypeComment       NotificationType = "comment"
+	NotificationTypeRepost        NotificationType = "repost"
+	NotificationTypeQuote         NotificationType = "quote"
+	NotificationTypeMention       NotificationType = "mention"
+	NotificationTypeReaction      NotificationType = "reaction"
+	NotificationTypeFollowRequest NotificationType = "follow_request"
+	NotificationTypeSavedSearch   NotificationType = "saved_search"
 )
 
 type Notification struct {
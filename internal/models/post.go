@@ -1,10 +1,13 @@
 package models
 
 import (
+	"context"
+	"digeon-backend/internal/text"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostType string
@@ -19,49 +22,111 @@ const (
 type Post struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	AuthorID    uuid.UUID `gorm:"type:uuid;not null;index" json:"author_id"`
-	Content     string    `gorm:"size:280" json:"content"`
+	Content     string    `gorm:"type:text" json:"content"` // length and size are enforced by text.IsValidLength
 	Type        PostType  `gorm:"default:'original'" json:"type"`
 	IsPublic    bool      `gorm:"default:true" json:"is_public"`
 	IsDraft     bool      `gorm:"default:false" json:"is_draft"`
 	
+	// Set by the author or a content warning, and always while any attached
+	// media is sensitive
+	IsSensitive    bool   `gorm:"default:false" json:"is_sensitive"`
+	ContentWarning string `gorm:"size:200" json:"content_warning,omitempty"`
+	
+	// ISO 639 code given by the author or guessed from the content's script;
+	// empty when unknown
+	Lang string `gorm:"size:8;index" json:"lang,omitempty"`
+	
 	// For reposts and quotes
 	OriginalPostID *uuid.UUID `gorm:"type:uuid;index" json:"original_post_id,omitempty"`
 	
 	// For replies
 	ParentPostID *uuid.UUID `gorm:"type:uuid;index" json:"parent_post_id,omitempty"`
 	
+	// Preview of the first URL in the content, filled in asynchronously
+	LinkCardID *uuid.UUID `gorm:"type:uuid" json:"link_card_id,omitempty"`
+	
 	// Metrics
 	LikesCount    int `gorm:"default:0" json:"likes_count"`
 	RepostsCount  int `gorm:"default:0" json:"reposts_count"`
 	CommentsCount int `gorm:"default:0" json:"comments_count"`
 	ViewsCount    int `gorm:"default:0" json:"views_count"`
 	
+	// Reaction counts keyed by emoji
+	ReactionCounts map[string]int `gorm:"type:jsonb;serializer:json;not null;default:'{}'" json:"reaction_counts"`
+	
+	// Full-text search index of Content, set whenever the content is saved.
+	// Never read back.
+	SearchVector TSVector `gorm:"type:tsvector;->:false" json:"-"`
+	
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
-	Author       User      `gorm:"foreignKey:AuthorID" json:"author"`
-	OriginalPost *Post     `gorm:"foreignKey:OriginalPostID" json:"original_post,omitempty"`
-	ParentPost   *Post     `gorm:"foreignKey:ParentPostID" json:"parent_post,omitempty"`
-	Media        []Media   `gorm:"foreignKey:PostID" json:"media,omitempty"`
-	Likes        []Like    `gorm:"foreignKey:PostID" json:"likes,omitempty"`
-	Comments     []Comment `gorm:"foreignKey:PostID" json:"comments,omitempty"`
-	Hashtags     []Hashtag `gorm:"many2many:post_hashtags;" json:"hashtags,omitempty"`
+	Author       User          `gorm:"foreignKey:AuthorID" json:"author"`
+	OriginalPost *Post         `gorm:"foreignKey:OriginalPostID" json:"original_post,omitempty"`
+	ParentPost   *Post         `gorm:"foreignKey:ParentPostID" json:"parent_post,omitempty"`
+	Media        []Media       `gorm:"foreignKey:PostID" json:"media,omitempty"`
+	Likes        []Like        `gorm:"foreignKey:PostID" json:"likes,omitempty"`
+	Comments     []Comment     `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	Hashtags     []Hashtag     `gorm:"many2many:post_hashtags;" json:"hashtags,omitempty"`
+	Mentions     []PostMention `gorm:"foreignKey:PostID" json:"mentions,omitempty"`
+	LinkCard     *LinkCard     `gorm:"foreignKey:LinkCardID" json:"card,omitempty"`
+
+	// Hashtags, mentions, URLs and cashtags with offsets, derived from Content
+	Entities []text.Entity `gorm:"-" json:"entities,omitempty"`
 }
 
 func (p *Post) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
 	}
+	if p.ReactionCounts == nil {
+		p.ReactionCounts = map[string]int{}
+	}
+	p.SearchVector = TSVector(text.SearchVector(p.Content))
+	if p.Lang == "" {
+		p.Lang = text.DetectLanguage(p.Content)
+	}
+	return nil
+}
+
+// TSVector is a tsvector literal, such as one built by text.SearchVector
+type TSVector string
+
+// GormValue has PostgreSQL parse the literal as a tsvector
+func (v TSVector) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return clause.Expr{SQL: "?::tsvector", Vars: []interface{}{string(v)}}
+}
+
+func (p *Post) AfterFind(tx *gorm.DB) error {
+	p.Entities = text.Extract(p.Content)
 	return nil
 }
 
 type PostWithDetails struct {
 	Post
-	IsLiked      bool `json:"is_liked"`
-	IsReposted   bool `json:"is_reposted"`
-	IsBookmarked bool `json:"is_bookmarked"`
+	IsLiked           bool `json:"is_liked"`
+	IsReposted        bool `json:"is_reposted"`
+	IsBookmarked      bool `json:"is_bookmarked"`
+	IsFollowingAuthor bool `json:"is_following_author"`
+	// Clients should hide the content behind the content warning until tapped
+	IsBlurred bool `json:"is_blurred"`
+	// Only set on search results
+	SearchMatch *SearchMatch `json:"search_match,omitempty"`
+}
+
+// SearchMatch shows where a search query matched a post's content.
+// Highlights are offsets into Snippet.
+type SearchMatch struct {
+	Snippet    string           `json:"snippet"`
+	Highlights []text.Highlight `json:"highlights"`
+}
+
+// HasSensitiveContent reports whether the post or the post it reposts or
+// quotes is marked as sensitive
+func (p *Post) HasSensitiveContent() bool {
+	return p.IsSensitive || (p.OriginalPost != nil && p.OriginalPost.IsSensitive)
 }
 
 type Hashtag struct {
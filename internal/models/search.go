@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SearchHistory is a query a user searched for recently. Each query is kept
+// once per user, and searching it again moves it back to the top.
+type SearchHistory struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+	Query      string    `gorm:"size:500;not null" json:"query"`
+	SearchedAt time.Time `gorm:"not null" json:"searched_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (h *SearchHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+func (SearchHistory) TableName() string {
+	return "search_histories"
+}
+
+// SavedSearch is a post search query kept under a name. When Notify is set,
+// the owner is notified of new posts matching it; CheckedAt is when posts
+// were last matched.
+type SavedSearch struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Query     string    `gorm:"size:500;not null" json:"query"`
+	Notify    bool      `gorm:"default:false" json:"notify"`
+	CheckedAt time.Time `gorm:"not null" json:"-"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (s *SavedSearch) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.CheckedAt.IsZero() {
+		s.CheckedAt = time.Now()
+	}
+	return nil
+}
+
+func (SavedSearch) TableName() string {
+	return "saved_searches"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StreamTicket lets an EventSource, which can't send an Authorization
+// header, open the event stream. It is short-lived and used once, so it is
+// harmless if it ends up in a log. Only a hash of the ticket is stored.
+type StreamTicket struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+	Username  string    `gorm:"size:50;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (t *StreamTicket) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (StreamTicket) TableName() string {
+	return "stream_tickets"
+}
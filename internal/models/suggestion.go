@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserSuggestion is one cached follow suggestion. A user's suggestions are
+// recomputed together and replaced as a whole.
+type UserSuggestion struct {
+	UserID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	SuggestedUserID uuid.UUID `gorm:"type:uuid;primaryKey" json:"suggested_user_id"`
+	Rank            int       `gorm:"not null" json:"rank"`
+	Score           float64   `gorm:"not null" json:"score"`
+	Reason          string    `gorm:"size:200" json:"reason"`
+	MutualCount     int       `gorm:"not null;default:0" json:"mutual_count"`
+
+	// Relationships
+	SuggestedUser User `gorm:"foreignKey:SuggestedUserID" json:"suggested_user"`
+}
+
+func (UserSuggestion) TableName() string {
+	return "user_suggestions"
+}
+
+// SuggestionState records when a user's suggestions were last computed and
+// last asked for, so the background refresh can skip inactive users
+type SuggestionState struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RefreshedAt time.Time `gorm:"not null" json:"refreshed_at"`
+	RequestedAt time.Time `gorm:"not null;index" json:"requested_at"`
+}
+
+func (SuggestionState) TableName() string {
+	return "suggestion_states"
+}
+
+// DismissedSuggestion keeps a user out of another user's suggestions
+type DismissedSuggestion struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID          uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	DismissedUserID uuid.UUID `gorm:"type:uuid;not null" json:"dismissed_user_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (d *DismissedSuggestion) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (DismissedSuggestion) TableName() string {
+	return "dismissed_suggestions"
+}
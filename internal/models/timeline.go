@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TimelineEntry is one post in a user's materialized home timeline. Entries
+// are written when a post is created and trimmed to the newest few hundred
+// per user.
+type TimelineEntry struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	PostID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	AuthorID  uuid.UUID `gorm:"type:uuid;not null" json:"author_id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (TimelineEntry) TableName() string {
+	return "timeline_entries"
+}
+
+// TimelineState records when a user's materialized timeline was last built
+// from scratch
+type TimelineState struct {
+	UserID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	BuiltAt time.Time `gorm:"not null" json:"built_at"`
+}
+
+func (TimelineState) TableName() string {
+	return "timeline_states"
+}
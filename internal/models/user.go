@@ -20,6 +20,19 @@ type User struct {
 	Website         string    `gorm:"size:200" json:"website"`
 	IsVerified      bool      `gorm:"default:false" json:"is_verified"`
 	IsActive        bool      `gorm:"default:true" json:"is_active"`
+	// Private accounts approve each follower, and only approved followers
+	// see their posts
+	IsPrivate       bool      `gorm:"default:false" json:"is_private"`
+	
+	// How posts marked as sensitive are shown to this user
+	SensitiveContent SensitiveContentPreference `gorm:"size:10;default:'blur'" json:"-"`
+	
+	// Counters kept up to date with follows and posts, and repaired by the
+	// counter reconciliation job if they drift
+	FollowersCount  int64     `gorm:"not null;default:0" json:"-"`
+	FollowingCount  int64     `gorm:"not null;default:0" json:"-"`
+	PostsCount      int64     `gorm:"not null;default:0" json:"-"`
+	
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -42,6 +55,23 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+type SensitiveContentPreference string
+
+const (
+	SensitiveContentShow SensitiveContentPreference = "show"
+	SensitiveContentBlur SensitiveContentPreference = "blur"
+	SensitiveContentHide SensitiveContentPreference = "hide"
+)
+
+// IsValid reports whether p is one of the known preferences
+func (p SensitiveContentPreference) IsValid() bool {
+	switch p {
+	case SensitiveContentShow, SensitiveContentBlur, SensitiveContentHide:
+		return true
+	}
+	return false
+}
+
 type UserPublic struct {
 	ID              uuid.UUID `json:"id"`
 	Username        string    `json:"username"`
@@ -52,6 +82,7 @@ type UserPublic struct {
 	Location        string    `json:"location"`
 	Website         string    `json:"website"`
 	IsVerified      bool      `json:"is_verified"`
+	IsPrivate       bool      `json:"is_private"`
 	CreatedAt       time.Time `json:"created_at"`
 	FollowersCount  int64     `json:"followers_count"`
 	FollowingCount  int64     `json:"following_count"`
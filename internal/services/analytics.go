@@ -0,0 +1,416 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsService(db *gorm.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+type RecordEventRequest struct {
+	Type   string  `json:"type"`
+	PostID *string `json:"post_id,omitempty"`
+	UserID *string `json:"user_id,omitempty"`
+}
+
+type AnalyticsMetrics struct {
+	Impressions   int64 `json:"impressions"`
+	Likes         int64 `json:"likes"`
+	Reposts       int64 `json:"reposts"`
+	Replies       int64 `json:"replies"`
+	ProfileClicks int64 `json:"profile_clicks"`
+	LinkClicks    int64 `json:"link_clicks"`
+}
+
+type AnalyticsBucket struct {
+	Start time.Time `json:"start"`
+	AnalyticsMetrics
+}
+
+type PostAnalyticsResponse struct {
+	PostID      uuid.UUID         `json:"post_id"`
+	Granularity string            `json:"granularity"`
+	Since       time.Time         `json:"since"`
+	Until       time.Time         `json:"until"`
+	Totals      AnalyticsMetrics  `json:"totals"`
+	Buckets     []AnalyticsBucket `json:"buckets"`
+}
+
+type FollowerGrowthBucket struct {
+	Day             time.Time `json:"day"`
+	FollowersGained int64     `json:"followers_gained"`
+	FollowersLost   int64     `json:"followers_lost"`
+	Net             int64     `json:"net"`
+}
+
+type TopPost struct {
+	Post        models.Post `json:"post"`
+	Impressions int64       `json:"impressions"`
+	Engagements int64       `json:"engagements"`
+}
+
+type AccountAnalyticsResponse struct {
+	Since          time.Time              `json:"since"`
+	Until          time.Time              `json:"until"`
+	FollowersCount int64                  `json:"followers_count"`
+	FollowerGrowth []FollowerGrowthBucket `json:"follower_growth"`
+	ProfileClicks  int64                  `json:"profile_clicks"`
+	TopPosts       []TopPost              `json:"top_posts"`
+}
+
+// clientEventTypes are the event types clients may report directly.
+// Everything else is recorded server-side by the owning service.
+var clientEventTypes = map[models.AnalyticsEventType]bool{
+	models.AnalyticsEventImpression:   true,
+	models.AnalyticsEventProfileClick: true,
+	models.AnalyticsEventLinkClick:    true,
+}
+
+// RecordEvent appends an event to the analytics log
+func (s *AnalyticsService) RecordEvent(eventType models.AnalyticsEventType, actorID *uuid.UUID, targetUserID uuid.UUID, postID *uuid.UUID) error {
+	event := models.AnalyticsEvent{
+		Type:         eventType,
+		ActorID:      actorID,
+		TargetUserID: targetUserID,
+		PostID:       postID,
+	}
+
+	if err := s.db.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record analytics event: %w", err)
+	}
+	return nil
+}
+
+// RecordPostEvent records an event against a post, attributing it to the post's author
+func (s *AnalyticsService) RecordPostEvent(eventType models.AnalyticsEventType, actorID, postID uuid.UUID) error {
+	var post models.Post
+	if err := s.db.Select("id", "author_id").First(&post, postID).Error; err != nil {
+		return err
+	}
+	return s.RecordEvent(eventType, &actorID, post.AuthorID, &postID)
+}
+
+// RecordClientEvents records impression and click events reported by clients.
+// viewerID is uuid.Nil for anonymous viewers. Events a user generates on their
+// own content are ignored so authors don't inflate their own numbers, as are
+// events on posts or users that don't exist.
+func (s *AnalyticsService) RecordClientEvents(viewerID uuid.UUID, reqs []RecordEventRequest) error {
+	var actorID *uuid.UUID
+	if viewerID != uuid.Nil {
+		actorID = &viewerID
+	}
+
+	// Validate every event first so the referenced posts and users can be
+	// loaded with one query each
+	events := make([]models.AnalyticsEvent, 0, len(reqs))
+	var postIDs, userIDs []uuid.UUID
+	for _, req := range reqs {
+		eventType := models.AnalyticsEventType(req.Type)
+		if !clientEventTypes[eventType] {
+			return fmt.Errorf("unsupported event type: %s", req.Type)
+		}
+
+		event := models.AnalyticsEvent{
+			Type:    eventType,
+			ActorID: actorID,
+		}
+
+		switch {
+		case req.PostID != nil:
+			postID, err := uuid.Parse(*req.PostID)
+			if err != nil {
+				return errors.New("invalid post ID")
+			}
+			event.PostID = &postID
+			postIDs = append(postIDs, postID)
+		case req.UserID != nil && eventType == models.AnalyticsEventProfileClick:
+			userID, err := uuid.Parse(*req.UserID)
+			if err != nil {
+				return errors.New("invalid user ID")
+			}
+			event.TargetUserID = userID
+			userIDs = append(userIDs, userID)
+		default:
+			return errors.New("post_id is required")
+		}
+
+		events = append(events, event)
+	}
+
+	authors := make(map[uuid.UUID]uuid.UUID)
+	if len(postIDs) > 0 {
+		var posts []models.Post
+		if err := s.db.Select("id", "author_id").Where("id IN ?", postIDs).Find(&posts).Error; err != nil {
+			return fmt.Errorf("failed to find posts: %w", err)
+		}
+		for _, post := range posts {
+			authors[post.ID] = post.AuthorID
+		}
+	}
+
+	users := make(map[uuid.UUID]bool)
+	if len(userIDs) > 0 {
+		var existing []uuid.UUID
+		if err := s.db.Model(&models.User{}).Where("id IN ?", userIDs).Pluck("id", &existing).Error; err != nil {
+			return fmt.Errorf("failed to find users: %w", err)
+		}
+		for _, id := range existing {
+			users[id] = true
+		}
+	}
+
+	recorded := events[:0]
+	for _, event := range events {
+		if event.PostID != nil {
+			authorID, ok := authors[*event.PostID]
+			if !ok {
+				continue
+			}
+			event.TargetUserID = authorID
+		} else if !users[event.TargetUserID] {
+			continue
+		}
+
+		if event.TargetUserID == viewerID {
+			continue
+		}
+		recorded = append(recorded, event)
+	}
+
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	if err := s.db.Create(&recorded).Error; err != nil {
+		return fmt.Errorf("failed to record analytics events: %w", err)
+	}
+	return nil
+}
+
+// GetPostAnalytics returns bucketed metrics for a post. Only the author may view them.
+func (s *AnalyticsService) GetPostAnalytics(postID, userID uuid.UUID, granularity string, since, until time.Time) (*PostAnalyticsResponse, error) {
+	if granularity != "hour" && granularity != "day" {
+		return nil, errors.New("invalid granularity")
+	}
+
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("post not found")
+		}
+		return nil, fmt.Errorf("failed to find post: %w", err)
+	}
+	if post.AuthorID != userID {
+		return nil, errors.New("unauthorized to view analytics")
+	}
+
+	var rows []struct {
+		Bucket    time.Time
+		EventType models.AnalyticsEventType
+		Total     int64
+	}
+	if err := s.db.Model(&models.PostMetricHourly{}).
+		Select("date_trunc(?, bucket_start) AS bucket, event_type, SUM(count) AS total", granularity).
+		Where("post_id = ? AND bucket_start >= ? AND bucket_start < ?", postID, since, until).
+		Group("bucket, event_type").
+		Order("bucket ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch post metrics: %w", err)
+	}
+
+	response := &PostAnalyticsResponse{
+		PostID:      postID,
+		Granularity: granularity,
+		Since:       since,
+		Until:       until,
+		Buckets:     []AnalyticsBucket{},
+	}
+
+	for _, row := range rows {
+		if n := len(response.Buckets); n == 0 || !response.Buckets[n-1].Start.Equal(row.Bucket) {
+			response.Buckets = append(response.Buckets, AnalyticsBucket{Start: row.Bucket})
+		}
+		bucket := &response.Buckets[len(response.Buckets)-1]
+		bucket.add(row.EventType, row.Total)
+		response.Totals.add(row.EventType, row.Total)
+	}
+
+	return response, nil
+}
+
+// GetAccountAnalytics returns follower growth and top posts for a user
+func (s *AnalyticsService) GetAccountAnalytics(userID uuid.UUID, since, until time.Time, topLimit int) (*AccountAnalyticsResponse, error) {
+	response := &AccountAnalyticsResponse{
+		Since:          since,
+		Until:          until,
+		FollowerGrowth: []FollowerGrowthBucket{},
+		TopPosts:       []TopPost{},
+	}
+
+	if err := s.db.Model(&models.User{}).Select("followers_count").Where("id = ?", userID).
+		Scan(&response.FollowersCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count followers: %w", err)
+	}
+
+	// Follower growth
+	var metrics []models.UserMetricDaily
+	if err := s.db.Where("user_id = ? AND day >= ? AND day < ?", userID, since, until).
+		Order("day ASC").
+		Find(&metrics).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch account metrics: %w", err)
+	}
+
+	for _, metric := range metrics {
+		if metric.EventType == models.AnalyticsEventProfileClick {
+			response.ProfileClicks += metric.Count
+			continue
+		}
+		if metric.EventType != models.AnalyticsEventFollow && metric.EventType != models.AnalyticsEventUnfollow {
+			continue
+		}
+
+		if n := len(response.FollowerGrowth); n == 0 || !response.FollowerGrowth[n-1].Day.Equal(metric.Day) {
+			response.FollowerGrowth = append(response.FollowerGrowth, FollowerGrowthBucket{Day: metric.Day})
+		}
+		bucket := &response.FollowerGrowth[len(response.FollowerGrowth)-1]
+		if metric.EventType == models.AnalyticsEventFollow {
+			bucket.FollowersGained += metric.Count
+		} else {
+			bucket.FollowersLost += metric.Count
+		}
+		bucket.Net = bucket.FollowersGained - bucket.FollowersLost
+	}
+
+	// Top posts by engagement within the window
+	var top []struct {
+		PostID      uuid.UUID
+		Impressions int64
+		Engagements int64
+	}
+	if err := s.db.Model(&models.PostMetricHourly{}).
+		Select(`post_metrics_hourly.post_id,
+			SUM(CASE WHEN event_type = ? THEN count ELSE 0 END) AS impressions,
+			SUM(CASE WHEN event_type IN ? THEN count ELSE 0 END) AS engagements`,
+			models.AnalyticsEventImpression,
+			[]models.AnalyticsEventType{models.AnalyticsEventLike, models.AnalyticsEventRepost, models.AnalyticsEventQuote, models.AnalyticsEventReply, models.AnalyticsEventLinkClick}).
+		Joins("JOIN posts ON posts.id = post_metrics_hourly.post_id").
+		Where("posts.author_id = ? AND posts.deleted_at IS NULL", userID).
+		Where("bucket_start >= ? AND bucket_start < ?", since, until).
+		Group("post_metrics_hourly.post_id").
+		Order("engagements DESC, impressions DESC").
+		Limit(topLimit).
+		Scan(&top).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch top posts: %w", err)
+	}
+
+	if len(top) == 0 {
+		return response, nil
+	}
+
+	postIDs := make([]uuid.UUID, len(top))
+	for i, row := range top {
+		postIDs[i] = row.PostID
+	}
+
+	var posts []models.Post
+	if err := s.db.Where("id IN ?", postIDs).Preload("Media").Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch top posts: %w", err)
+	}
+	postsByID := make(map[uuid.UUID]models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	for _, row := range top {
+		post, ok := postsByID[row.PostID]
+		if !ok {
+			continue
+		}
+		response.TopPosts = append(response.TopPosts, TopPost{
+			Post:        post,
+			Impressions: row.Impressions,
+			Engagements: row.Engagements,
+		})
+	}
+
+	return response, nil
+}
+
+// Rollup aggregates raw events into the hourly post and daily user rollup tables.
+// Buckets from the most recent rolled-up bucket onwards are recomputed from
+// scratch, so running it repeatedly (or concurrently on several replicas) is safe.
+func (s *AnalyticsService) Rollup() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO post_metrics_hourly (post_id, bucket_start, event_type, count)
+			SELECT post_id, date_trunc('hour', created_at), type, COUNT(*)
+			FROM analytics_events
+			WHERE post_id IS NOT NULL
+				AND created_at >= COALESCE((SELECT MAX(bucket_start) FROM post_metrics_hourly), '-infinity')
+			GROUP BY 1, 2, 3
+			ON CONFLICT (post_id, bucket_start, event_type) DO UPDATE SET count = EXCLUDED.count
+		`).Error; err != nil {
+			return fmt.Errorf("failed to roll up post metrics: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO user_metrics_daily (user_id, day, event_type, count)
+			SELECT target_user_id, date_trunc('day', created_at)::date, type, COUNT(*)
+			FROM analytics_events
+			WHERE type IN (?, ?, ?)
+				AND created_at >= COALESCE((SELECT MAX(day) FROM user_metrics_daily), '-infinity')
+			GROUP BY 1, 2, 3
+			ON CONFLICT (user_id, day, event_type) DO UPDATE SET count = EXCLUDED.count
+		`, models.AnalyticsEventFollow, models.AnalyticsEventUnfollow, models.AnalyticsEventProfileClick).Error; err != nil {
+			return fmt.Errorf("failed to roll up user metrics: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// StartRollupJob runs Rollup every interval in the background
+func (s *AnalyticsService) StartRollupJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Rollup(); err != nil {
+				log.Printf("Analytics rollup failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (m *AnalyticsMetrics) add(eventType models.AnalyticsEventType, count int64) {
+	switch eventType {
+	case models.AnalyticsEventImpression:
+		m.Impressions += count
+	case models.AnalyticsEventLike:
+		m.Likes += count
+	case models.AnalyticsEventUnlike:
+		m.Likes -= count
+	case models.AnalyticsEventRepost, models.AnalyticsEventQuote:
+		m.Reposts += count
+	case models.AnalyticsEventReply:
+		m.Replies += count
+	case models.AnalyticsEventProfileClick:
+		m.ProfileClicks += count
+	case models.AnalyticsEventLinkClick:
+		m.LinkClicks += count
+	}
+}
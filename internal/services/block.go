@@ -0,0 +1,178 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockService struct {
+	db              *gorm.DB
+	timelineService *TimelineService
+	broker          *EventBroker
+}
+
+func NewBlockService(db *gorm.DB, timelineService *TimelineService, broker *EventBroker) *BlockService {
+	return &BlockService{
+		db:              db,
+		timelineService: timelineService,
+		broker:          broker,
+	}
+}
+
+// BlockUser blocks a user and removes any follows between the two of them
+func (s *BlockService) BlockUser(blockerID, blockedID uuid.UUID) error {
+	if blockerID == blockedID {
+		return errors.New("cannot block yourself")
+	}
+
+	// Check if user exists
+	var blockedUser models.User
+	if err := s.db.First(&blockedUser, blockedID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	// Check if already blocked
+	var existingBlock models.Block
+	if err := s.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).First(&existingBlock).Error; err == nil {
+		return errors.New("user already blocked")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// The unique index decides between concurrent requests
+		block := models.Block{
+			BlockerID: blockerID,
+			BlockedID: blockedID,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create block: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user already blocked")
+		}
+
+		var follows []models.Follow
+		if err := tx.Where("(follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).
+			Find(&follows).Error; err != nil {
+			return fmt.Errorf("failed to find follows: %w", err)
+		}
+		for _, follow := range follows {
+			// Skip the counters when a concurrent unfollow got there first
+			result := tx.Delete(&follow)
+			if result.Error != nil {
+				return fmt.Errorf("failed to remove follows: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := adjustFollowCounts(tx, follow.FollowerID, follow.FollowingID, -1); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("(requester_id = ? AND target_id = ?) OR (requester_id = ? AND target_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).
+			Delete(&models.FollowRequest{}).Error; err != nil {
+			return fmt.Errorf("failed to remove follow requests: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Clear each other's posts out of their home timelines
+	if s.timelineService != nil {
+		if err := s.timelineService.RemoveFollow(blockerID, blockedID); err != nil {
+			return fmt.Errorf("failed to update timeline: %w", err)
+		}
+		if err := s.timelineService.RemoveFollow(blockedID, blockerID); err != nil {
+			return fmt.Errorf("failed to update timeline: %w", err)
+		}
+	}
+	s.broker.Publish(Event{Type: EventTypeFollowsChanged, UserID: blockerID})
+	s.broker.Publish(Event{Type: EventTypeFollowsChanged, UserID: blockedID})
+
+	return nil
+}
+
+// UnblockUser lifts a block. Follows removed by the block are not restored.
+func (s *BlockService) UnblockUser(blockerID, blockedID uuid.UUID) error {
+	var block models.Block
+	if err := s.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).First(&block).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("block not found")
+		}
+		return fmt.Errorf("failed to find block: %w", err)
+	}
+
+	if err := s.db.Delete(&block).Error; err != nil {
+		return fmt.Errorf("failed to delete block: %w", err)
+	}
+
+	return nil
+}
+
+// GetBlockedUsers returns the users the user has blocked, most recent first
+func (s *BlockService) GetBlockedUsers(userID uuid.UUID, page PageRequest) ([]models.UserPublic, PageInfo, error) {
+	query := s.db.Where("blocker_id = ?", userID).
+		Preload("Blocked")
+
+	blocks, pageInfo, err := paginate(query, page, "blocks", false, blockCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+
+	users := make([]models.UserPublic, 0, len(blocks))
+	for _, block := range blocks {
+		users = append(users, toUserPublic(block.Blocked))
+	}
+
+	return users, pageInfo, nil
+}
+
+// isBlockedBetween reports whether either user has blocked the other
+func isBlockedBetween(db *gorm.DB, a, b uuid.UUID) (bool, error) {
+	if a == uuid.Nil || b == uuid.Nil || a == b {
+		return false, nil
+	}
+
+	var count int64
+	if err := db.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check blocks: %w", err)
+	}
+	return count > 0, nil
+}
+
+// excludeBlocked drops rows where column holds a user who blocked the
+// viewer or whom the viewer blocked. column must be trusted SQL.
+func excludeBlocked(query *gorm.DB, viewerID uuid.UUID, column string) *gorm.DB {
+	if viewerID == uuid.Nil {
+		return query
+	}
+	return query.Where(`NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE blocks.deleted_at IS NULL AND (
+			(blocks.blocker_id = ? AND blocks.blocked_id = `+column+`) OR
+			(blocks.blocked_id = ? AND blocks.blocker_id = `+column+`)
+		)
+	)`, viewerID, viewerID)
+}
+
+// filterBlockedPosts drops posts by users the viewer blocked or was blocked
+// by, along with reposts and quotes of their posts
+func filterBlockedPosts(query *gorm.DB, viewerID uuid.UUID) *gorm.DB {
+	query = excludeBlocked(query, viewerID, "posts.author_id")
+	return excludeBlocked(query, viewerID, "(SELECT original.author_id FROM posts original WHERE original.id = posts.original_post_id)")
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"context"
+	"digeon-backend/internal/database"
+	"digeon-backend/internal/models"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var migrateIntegrationDB sync.Once
+
+// newIntegrationDB connects to the database in TEST_DATABASE_URL, skipping
+// the test when it isn't set. Tests create their own users, so they can
+// share a database with each other and with earlier runs.
+func newIntegrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get the test database connection: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	var migrateErr error
+	migrateIntegrationDB.Do(func() { migrateErr = database.AutoMigrate(db) })
+	if migrateErr != nil {
+		t.Fatalf("failed to migrate the test database: %v", migrateErr)
+	}
+	return db
+}
+
+// blockingFixture wires the services the way main does. The home timeline
+// is read from the posts table, since fan-out to a timeline store happens
+// in the background.
+type blockingFixture struct {
+	db            *gorm.DB
+	users         *UserService
+	posts         *PostService
+	timeline      *TimelineService
+	follows       *FollowService
+	likes         *LikeService
+	reactions     *ReactionService
+	bookmarks     *BookmarkService
+	comments      *CommentService
+	search        *SearchService
+	lists         *ListService
+	blocks        *BlockService
+	notifications *NotificationService
+	stream        *StreamService
+}
+
+func newBlockingFixture(t *testing.T) *blockingFixture {
+	db := newIntegrationDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	broker := NewEventBroker(NewLocalEventBackend())
+	broker.Start(ctx)
+
+	mediaService := NewMediaService(db)
+	analyticsService := NewAnalyticsService(db)
+	notificationService := NewNotificationService(db, broker)
+	timelineService := NewTimelineService(db, nil)
+	postService := NewPostService(db, mediaService, notificationService, analyticsService, NewLinkPreviewService(db, mediaService), timelineService, broker)
+
+	return &blockingFixture{
+		db:            db,
+		users:         NewUserService(db),
+		posts:         postService,
+		timeline:      timelineService,
+		follows:       NewFollowService(db, notificationService, analyticsService, timelineService, broker),
+		likes:         NewLikeService(db, notificationService, analyticsService, broker),
+		reactions:     NewReactionService(db, notificationService),
+		bookmarks:     NewBookmarkService(db),
+		comments:      NewCommentService(db, postService, notificationService),
+		search:        NewSearchService(db, notificationService),
+		lists:         NewListService(db),
+		blocks:        NewBlockService(db, timelineService, broker),
+		notifications: notificationService,
+		stream:        NewStreamService(db, broker, postService, notificationService),
+	}
+}
+
+// createUser creates a public user with a unique username
+func (f *blockingFixture) createUser(t *testing.T) models.User {
+	t.Helper()
+	name := "u" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+	user := models.User{Username: name, Email: name + "@example.com", PasswordHash: "not-a-real-hash"}
+	if err := f.db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+func (f *blockingFixture) createPost(t *testing.T, authorID uuid.UUID, req CreatePostRequest) *models.PostWithDetails {
+	t.Helper()
+	if req.Type == "" {
+		req.Type = string(models.PostTypeOriginal)
+	}
+	post, err := f.posts.CreatePost(authorID, req)
+	if err != nil {
+		t.Fatalf("CreatePost() error = %v", err)
+	}
+	return post
+}
+
+func (f *blockingFixture) follow(t *testing.T, followerID, followingID uuid.UUID) {
+	t.Helper()
+	if _, err := f.follows.FollowUser(followerID, followingID); err != nil {
+		t.Fatalf("FollowUser() error = %v", err)
+	}
+}
+
+func (f *blockingFixture) block(t *testing.T, blockerID, blockedID uuid.UUID) {
+	t.Helper()
+	if err := f.blocks.BlockUser(blockerID, blockedID); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+}
+
+// postIDs returns the IDs of posts as a set
+func postIDs(posts []models.PostWithDetails) map[uuid.UUID]bool {
+	ids := make(map[uuid.UUID]bool, len(posts))
+	for _, post := range posts {
+		ids[post.ID] = true
+	}
+	return ids
+}
+
+// assertPosts checks which of the named posts a listing contains
+func assertPosts(t *testing.T, listing string, posts []models.PostWithDetails, want, hidden map[string]uuid.UUID) {
+	t.Helper()
+	ids := postIDs(posts)
+	for name, id := range want {
+		if !ids[id] {
+			t.Errorf("%s is missing %s", listing, name)
+		}
+	}
+	for name, id := range hidden {
+		if ids[id] {
+			t.Errorf("%s shows %s", listing, name)
+		}
+	}
+}
+
+func TestBlockRemovesFollowsBothWays(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, blocked := f.createUser(t), f.createUser(t)
+	f.follow(t, blocker.ID, blocked.ID)
+	f.follow(t, blocked.ID, blocker.ID)
+
+	f.block(t, blocker.ID, blocked.ID)
+
+	for _, pair := range [][2]models.User{{blocker, blocked}, {blocked, blocker}} {
+		following, err := f.follows.IsFollowing(pair[0].ID, pair[1].ID)
+		if err != nil {
+			t.Fatalf("IsFollowing() error = %v", err)
+		}
+		if following {
+			t.Errorf("%s still follows %s after the block", pair[0].Username, pair[1].Username)
+		}
+	}
+
+	var counts []models.User
+	if err := f.db.Select("id", "followers_count", "following_count").
+		Find(&counts, []uuid.UUID{blocker.ID, blocked.ID}).Error; err != nil {
+		t.Fatalf("failed to load users: %v", err)
+	}
+	for _, user := range counts {
+		if user.FollowersCount != 0 || user.FollowingCount != 0 {
+			t.Errorf("user %s has %d followers and %d following, want none", user.ID, user.FollowersCount, user.FollowingCount)
+		}
+	}
+}
+
+func TestBlockHidesPosts(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, viewer, friend := f.createUser(t), f.createUser(t), f.createUser(t)
+	f.follow(t, viewer.ID, blocker.ID)
+	f.follow(t, viewer.ID, friend.ID)
+
+	list, err := f.lists.CreateList(viewer.ID, CreateListRequest{Name: "people"})
+	if err != nil {
+		t.Fatalf("CreateList() error = %v", err)
+	}
+	for _, member := range []uuid.UUID{blocker.ID, friend.ID} {
+		if err := f.lists.AddMember(list.ID, viewer.ID, member); err != nil {
+			t.Fatalf("AddMember() error = %v", err)
+		}
+	}
+
+	// A word only this test's posts contain, so that searches only match them
+	word := "blk" + strings.ReplaceAll(uuid.New().String(), "-", "")[:10]
+	blockerPost := f.createPost(t, blocker.ID, CreatePostRequest{Content: "from the blocker " + word})
+	blockerPostID := blockerPost.ID.String()
+	repost, err := f.posts.Repost(friend.ID, blockerPost.ID)
+	if err != nil {
+		t.Fatalf("Repost() error = %v", err)
+	}
+	quote := f.createPost(t, friend.ID, CreatePostRequest{Content: "quoting " + word, Type: string(models.PostTypeQuote), OriginalPostID: &blockerPostID})
+	friendPost := f.createPost(t, friend.ID, CreatePostRequest{Content: "from a friend " + word})
+	friendPostID := friendPost.ID.String()
+	blockerReply := f.createPost(t, blocker.ID, CreatePostRequest{Content: "blocker reply", Type: string(models.PostTypeReply), ParentPostID: &friendPostID})
+	viewerReply := f.createPost(t, viewer.ID, CreatePostRequest{Content: "viewer reply", Type: string(models.PostTypeReply), ParentPostID: &friendPostID})
+
+	f.block(t, blocker.ID, viewer.ID)
+
+	hidden := map[string]uuid.UUID{
+		"the blocker's post":      blockerPost.ID,
+		"a repost of the blocker": repost.ID,
+		"a quote of the blocker":  quote.ID,
+		"the blocker's reply":     blockerReply.ID,
+	}
+	visible := map[string]uuid.UUID{"the friend's post": friendPost.ID}
+	page := PageRequest{Limit: 50}
+
+	home, err := f.timeline.GetHomeTimeline(viewer.ID, page, uuid.Nil)
+	if err != nil {
+		t.Fatalf("GetHomeTimeline() error = %v", err)
+	}
+	assertPosts(t, "home timeline", home.Posts, visible, hidden)
+
+	listTimeline, err := f.timeline.GetListTimeline(list.ID, viewer.ID, page)
+	if err != nil {
+		t.Fatalf("GetListTimeline() error = %v", err)
+	}
+	assertPosts(t, "list timeline", listTimeline.Posts, visible, hidden)
+
+	for _, sort := range []SearchSort{SearchSortRelevance, SearchSortRecent} {
+		results, err := f.search.SearchPosts(word, viewer.ID, sort, page)
+		if err != nil {
+			t.Fatalf("SearchPosts(%s) error = %v", sort, err)
+		}
+		assertPosts(t, "search sorted by "+string(sort), results.Posts, visible, hidden)
+	}
+
+	replies, err := f.timeline.GetPostReplies(friendPost.ID, viewer.ID, page)
+	if err != nil {
+		t.Fatalf("GetPostReplies() error = %v", err)
+	}
+	assertPosts(t, "replies seen by the viewer", replies.Posts,
+		map[string]uuid.UUID{"the viewer's reply": viewerReply.ID},
+		map[string]uuid.UUID{"the blocker's reply": blockerReply.ID})
+
+	// The block hides the viewer from the blocker just the same
+	replies, err = f.timeline.GetPostReplies(friendPost.ID, blocker.ID, page)
+	if err != nil {
+		t.Fatalf("GetPostReplies() error = %v", err)
+	}
+	assertPosts(t, "replies seen by the blocker", replies.Posts,
+		map[string]uuid.UUID{"the blocker's reply": blockerReply.ID},
+		map[string]uuid.UUID{"the viewer's reply": viewerReply.ID})
+}
+
+func TestBlockHidesProfileAndPosts(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, blocked, other := f.createUser(t), f.createUser(t), f.createUser(t)
+	blockerPost := f.createPost(t, blocker.ID, CreatePostRequest{Content: "hello"})
+	repost, err := f.posts.Repost(other.ID, blockerPost.ID)
+	if err != nil {
+		t.Fatalf("Repost() error = %v", err)
+	}
+	otherPost := f.createPost(t, other.ID, CreatePostRequest{Content: "unrelated"})
+
+	f.block(t, blocker.ID, blocked.ID)
+
+	// Both sides of the block get the same not found errors
+	for _, pair := range [][2]models.User{{blocked, blocker}, {blocker, blocked}} {
+		viewer, target := pair[0], pair[1]
+		if _, err := f.users.GetUserByID(target.ID, viewer.ID); err == nil || err.Error() != "user not found" {
+			t.Errorf("GetUserByID(%s) for %s error = %v, want user not found", target.Username, viewer.Username, err)
+		}
+		if _, _, err := f.posts.GetPostsByUserID(target.ID, viewer.ID, PageRequest{Limit: 20}); err == nil || err.Error() != "user not found" {
+			t.Errorf("GetPostsByUserID(%s) for %s error = %v, want user not found", target.Username, viewer.Username, err)
+		}
+	}
+
+	if _, err := f.posts.GetPostWithDetails(blockerPost.ID, blocked.ID); err == nil {
+		t.Error("GetPostWithDetails() returned the blocker's post to the blocked user")
+	}
+	if _, err := f.posts.GetPostWithDetails(repost.ID, blocked.ID); err == nil {
+		t.Error("GetPostWithDetails() returned a repost of the blocker's post to the blocked user")
+	}
+	if _, err := f.posts.GetPostWithDetails(otherPost.ID, blocked.ID); err != nil {
+		t.Errorf("GetPostWithDetails() on an unrelated post error = %v", err)
+	}
+
+	// A third user's profile drops their reposts of the blocker
+	posts, _, err := f.posts.GetPostsByUserID(other.ID, blocked.ID, PageRequest{Limit: 20})
+	if err != nil {
+		t.Fatalf("GetPostsByUserID() error = %v", err)
+	}
+	assertPosts(t, "a third user's profile", posts,
+		map[string]uuid.UUID{"their own post": otherPost.ID},
+		map[string]uuid.UUID{"a repost of the blocker": repost.ID})
+}
+
+func TestBlockRefusesInteractions(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, blocked := f.createUser(t), f.createUser(t)
+	blockerPost := f.createPost(t, blocker.ID, CreatePostRequest{Content: "hello"})
+	blockedPost := f.createPost(t, blocked.ID, CreatePostRequest{Content: "hi"})
+
+	f.block(t, blocker.ID, blocked.ID)
+
+	for _, pair := range []struct {
+		actor models.User
+		post  *models.PostWithDetails
+	}{{blocked, blockerPost}, {blocker, blockedPost}} {
+		actor, postID := pair.actor, pair.post.ID
+		postIDString := postID.String()
+
+		if err := f.likes.LikePost(actor.ID, postID); err == nil || err.Error() != "post not found" {
+			t.Errorf("LikePost() by %s error = %v, want post not found", actor.Username, err)
+		}
+		if _, err := f.comments.CreateComment(actor.ID, postID, CreateCommentRequest{Content: "hey"}); err == nil || err.Error() != "post not found" {
+			t.Errorf("CreateComment() by %s error = %v, want post not found", actor.Username, err)
+		}
+		if _, err := f.posts.CreatePost(actor.ID, CreatePostRequest{Content: "hey", Type: string(models.PostTypeReply), ParentPostID: &postIDString}); err == nil || err.Error() != "parent post not found" {
+			t.Errorf("reply by %s error = %v, want parent post not found", actor.Username, err)
+		}
+		if _, err := f.posts.CreatePost(actor.ID, CreatePostRequest{Content: "look", Type: string(models.PostTypeQuote), OriginalPostID: &postIDString}); err == nil || err.Error() != "original post not found" {
+			t.Errorf("quote by %s error = %v, want original post not found", actor.Username, err)
+		}
+		if _, err := f.posts.Repost(actor.ID, postID); err == nil {
+			t.Errorf("Repost() by %s succeeded", actor.Username)
+		}
+	}
+
+	for _, pair := range [][2]models.User{{blocked, blocker}, {blocker, blocked}} {
+		if _, err := f.follows.FollowUser(pair[0].ID, pair[1].ID); err == nil || err.Error() != "user not found" {
+			t.Errorf("FollowUser() by %s error = %v, want user not found", pair[0].Username, err)
+		}
+	}
+}
+
+func TestBlockSuppressesNotifications(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, blocked, other := f.createUser(t), f.createUser(t), f.createUser(t)
+	blockerPost := f.createPost(t, blocker.ID, CreatePostRequest{Content: "hello"})
+
+	// Notifications from before the block are hidden once it exists
+	if err := f.likes.LikePost(blocked.ID, blockerPost.ID); err != nil {
+		t.Fatalf("LikePost() error = %v", err)
+	}
+
+	f.block(t, blocker.ID, blocked.ID)
+
+	// Mentions across the block don't notify, in either direction
+	f.createPost(t, blocked.ID, CreatePostRequest{Content: "hey @" + blocker.Username})
+	f.createPost(t, blocker.ID, CreatePostRequest{Content: "hey @" + blocked.Username})
+	f.createPost(t, other.ID, CreatePostRequest{Content: "hey @" + blocker.Username})
+
+	for _, user := range []models.User{blocker, blocked} {
+		notifications, _, err := f.notifications.GetNotifications(user.ID, PageRequest{Limit: 50})
+		if err != nil {
+			t.Fatalf("GetNotifications() error = %v", err)
+		}
+		for _, notification := range notifications {
+			if notification.Actor.ID == blocker.ID || notification.Actor.ID == blocked.ID {
+				t.Errorf("%s has a %s notification from %s", user.Username, notification.Type, notification.Actor.Username)
+			}
+		}
+		if user.ID == blocker.ID && len(notifications) != 1 {
+			t.Errorf("blocker has %d notifications, want only the mention by the other user", len(notifications))
+		}
+	}
+
+	var stored int64
+	if err := f.db.Model(&models.Notification{}).
+		Where("user_id = ? AND actor_id = ? AND type = ?", blocker.ID, blocked.ID, models.NotificationTypeMention).
+		Count(&stored).Error; err != nil {
+		t.Fatalf("failed to count notifications: %v", err)
+	}
+	if stored != 0 {
+		t.Errorf("stored %d mention notifications across the block, want none", stored)
+	}
+}
+
+func TestBlockHidesPostsFromStream(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, viewer, friend := f.createUser(t), f.createUser(t), f.createUser(t)
+	f.follow(t, viewer.ID, friend.ID)
+	blockerPost := f.createPost(t, blocker.ID, CreatePostRequest{Content: "hello"})
+	f.block(t, blocker.ID, viewer.ID)
+
+	type message struct {
+		event string
+		data  interface{}
+	}
+	messages := make(chan message, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- f.stream.Stream(ctx, viewer.ID, nil, func(event string, data interface{}) error {
+			messages <- message{event, data}
+			return nil
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	next := func() message {
+		t.Helper()
+		for {
+			select {
+			case msg := <-messages:
+				if msg.event != "" {
+					return msg
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for a stream message")
+			}
+		}
+	}
+
+	// The unread count is sent once the stream is subscribed
+	if msg := next(); msg.event != "unread_count" {
+		t.Fatalf("first stream event = %q, want unread_count", msg.event)
+	}
+
+	// The friend's repost of the blocker is skipped; the post after it is sent
+	if _, err := f.posts.Repost(friend.ID, blockerPost.ID); err != nil {
+		t.Fatalf("Repost() error = %v", err)
+	}
+	friendPost := f.createPost(t, friend.ID, CreatePostRequest{Content: "hello friends"})
+
+	msg := next()
+	post, ok := msg.data.(*models.PostWithDetails)
+	if msg.event != "post" || !ok {
+		t.Fatalf("stream sent %q %T, want a post", msg.event, msg.data)
+	}
+	if post.ID != friendPost.ID {
+		t.Errorf("stream sent post %s, want the friend's post %s", post.ID, friendPost.ID)
+	}
+}
+
+func TestBlockHidesBookmarksAndReactions(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, blocked, other := f.createUser(t), f.createUser(t), f.createUser(t)
+	blockerPost := f.createPost(t, blocker.ID, CreatePostRequest{Content: "hello"})
+	otherPost := f.createPost(t, other.ID, CreatePostRequest{Content: "unrelated"})
+
+	for _, postID := range []uuid.UUID{blockerPost.ID, otherPost.ID} {
+		if err := f.bookmarks.BookmarkPost(blocked.ID, postID); err != nil {
+			t.Fatalf("BookmarkPost() error = %v", err)
+		}
+	}
+	for _, user := range []models.User{blocker, blocked} {
+		if err := f.reactions.AddReaction(user.ID, otherPost.ID, defaultReactionEmojis[0]); err != nil {
+			t.Fatalf("AddReaction() error = %v", err)
+		}
+	}
+
+	f.block(t, blocker.ID, blocked.ID)
+
+	bookmarks, err := f.bookmarks.GetBookmarks(blocked.ID, PageRequest{Limit: 20})
+	if err != nil {
+		t.Fatalf("GetBookmarks() error = %v", err)
+	}
+	assertPosts(t, "bookmarks", bookmarks.Posts,
+		map[string]uuid.UUID{"the other user's post": otherPost.ID},
+		map[string]uuid.UUID{"the blocker's post": blockerPost.ID})
+
+	// Each side of the block only sees their own reaction
+	for _, pair := range [][2]models.User{{blocked, blocker}, {blocker, blocked}} {
+		viewer, hidden := pair[0], pair[1]
+		reactions, _, err := f.reactions.GetPostReactions(otherPost.ID, viewer.ID, "", PageRequest{Limit: 20})
+		if err != nil {
+			t.Fatalf("GetPostReactions() error = %v", err)
+		}
+		if len(reactions) != 1 || reactions[0].User.ID != viewer.ID {
+			t.Errorf("GetPostReactions() for %s = %+v, want only their own reaction", viewer.Username, reactions)
+		}
+		for _, reaction := range reactions {
+			if reaction.User.ID == hidden.ID {
+				t.Errorf("GetPostReactions() for %s lists %s", viewer.Username, hidden.Username)
+			}
+		}
+	}
+}
+
+func TestBlockUserTwice(t *testing.T) {
+	f := newBlockingFixture(t)
+	blocker, blocked := f.createUser(t), f.createUser(t)
+
+	// Concurrent blocks are settled by the unique index
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() { errs <- f.blocks.BlockUser(blocker.ID, blocked.ID) }()
+	}
+	succeeded := 0
+	for i := 0; i < cap(errs); i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+			succeeded++
+		case err.Error() != "user already blocked":
+			t.Errorf("BlockUser() error = %v, want user already blocked", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d concurrent blocks succeeded, want 1", succeeded)
+	}
+}
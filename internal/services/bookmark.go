@@ -0,0 +1,111 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BookmarkService struct {
+	db *gorm.DB
+}
+
+func NewBookmarkService(db *gorm.DB) *BookmarkService {
+	return &BookmarkService{db: db}
+}
+
+type BookmarksResponse struct {
+	Posts []models.PostWithDetails `json:"posts"`
+	Limit int                      `json:"limit"`
+	PageInfo
+}
+
+func (s *BookmarkService) BookmarkPost(userID, postID uuid.UUID) error {
+	// Check if post exists
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("post not found")
+		}
+		return fmt.Errorf("failed to find post: %w", err)
+	}
+
+	// Check if already bookmarked
+	var existingBookmark models.Bookmark
+	if err := s.db.Where("user_id = ? AND post_id = ?", userID, postID).First(&existingBookmark).Error; err == nil {
+		return errors.New("post already bookmarked")
+	}
+
+	bookmark := models.Bookmark{
+		UserID: userID,
+		PostID: postID,
+	}
+
+	if err := s.db.Create(&bookmark).Error; err != nil {
+		return fmt.Errorf("failed to create bookmark: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BookmarkService) RemoveBookmark(userID, postID uuid.UUID) error {
+	var bookmark models.Bookmark
+	if err := s.db.Where("user_id = ? AND post_id = ?", userID, postID).First(&bookmark).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("bookmark not found")
+		}
+		return fmt.Errorf("failed to find bookmark: %w", err)
+	}
+
+	if err := s.db.Delete(&bookmark).Error; err != nil {
+		return fmt.Errorf("failed to delete bookmark: %w", err)
+	}
+
+	return nil
+}
+
+// GetBookmarks returns the user's bookmarked posts, most recently bookmarked first
+func (s *BookmarkService) GetBookmarks(userID uuid.UUID, page PageRequest) (*BookmarksResponse, error) {
+	// Posts by private accounts the user no longer follows, and posts by
+	// users in a block with them, are left out
+	visible := filterPrivatePosts(s.db.Model(&models.Post{}).Select("posts.id"), userID)
+	visible = filterBlockedPosts(visible, userID)
+	query := s.db.Where("bookmarks.user_id = ? AND bookmarks.post_id IN (?)", userID, visible).
+		Preload("Post").
+		Preload("Post.Author").
+		Preload("Post.Media").
+		Preload("Post.Mentions").
+		Preload("Post.LinkCard").
+		Preload("Post.OriginalPost").
+		Preload("Post.OriginalPost.Author").
+		Preload("Post.ParentPost").
+		Preload("Post.ParentPost.Author")
+
+	bookmarks, pageInfo, err := paginate(query, page, "bookmarks", false, bookmarkCursorKey)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]models.Post, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		// Skip bookmarks whose post has since been deleted
+		if bookmark.Post.ID == uuid.Nil {
+			continue
+		}
+		posts = append(posts, bookmark.Post)
+	}
+
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BookmarksResponse{
+		Posts:    postsWithDetails,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
+	}, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"digeon-backend/internal/models"
+	"digeon-backend/internal/text"
 	"errors"
 	"fmt"
 
@@ -42,11 +43,14 @@ type CommentResponse struct {
 }
 
 func (s *CommentService) CreateComment(userID, postID uuid.UUID, req CreateCommentRequest) (*CommentResponse, error) {
+	// Normalize so the length checked is the length stored
+	req.Content = text.Normalize(req.Content)
+
 	// Validate input
 	if len(req.Content) == 0 {
 		return nil, errors.New("comment content is required")
 	}
-	if len(req.Content) > 280 {
+	if !text.IsValidLength(req.Content) {
 		return nil, errors.New("comment content exceeds 280 characters")
 	}
 
@@ -58,6 +62,16 @@ func (s *CommentService) CreateComment(userID, postID uuid.UUID, req CreateComme
 		}
 		return nil, fmt.Errorf("failed to find post: %w", err)
 	}
+	if blocked, err := isBlockedBetween(s.db, userID, post.AuthorID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, errors.New("post not found")
+	}
+	if visible, err := canViewPosts(s.db, post.AuthorID, userID); err != nil {
+		return nil, err
+	} else if !visible {
+		return nil, errors.New("post not found")
+	}
 
 	// Create comment as a reply post
 	postIDStr := postID.String()
@@ -93,6 +107,7 @@ func (s *CommentService) CreateComment(userID, postID uuid.UUID, req CreateComme
 			Location:        postWithDetails.Author.Location,
 			Website:         postWithDetails.Author.Website,
 			IsVerified:      postWithDetails.Author.IsVerified,
+			IsPrivate:       postWithDetails.Author.IsPrivate,
 			CreatedAt:       postWithDetails.Author.CreatedAt,
 		},
 		IsLiked: postWithDetails.IsLiked,
@@ -111,11 +126,14 @@ func (s *CommentService) CreateComment(userID, postID uuid.UUID, req CreateComme
 }
 
 func (s *CommentService) CreateReply(userID, commentID uuid.UUID, req CreateCommentRequest) (*CommentResponse, error) {
+	// Normalize so the length checked is the length stored
+	req.Content = text.Normalize(req.Content)
+
 	// Validate input
 	if len(req.Content) == 0 {
 		return nil, errors.New("reply content is required")
 	}
-	if len(req.Content) > 280 {
+	if !text.IsValidLength(req.Content) {
 		return nil, errors.New("reply content exceeds 280 characters")
 	}
 
@@ -127,6 +145,16 @@ func (s *CommentService) CreateReply(userID, commentID uuid.UUID, req CreateComm
 		}
 		return nil, fmt.Errorf("failed to find comment: %w", err)
 	}
+	if blocked, err := isBlockedBetween(s.db, userID, comment.AuthorID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, errors.New("comment not found")
+	}
+	if visible, err := canViewPosts(s.db, comment.AuthorID, userID); err != nil {
+		return nil, err
+	} else if !visible {
+		return nil, errors.New("comment not found")
+	}
 
 	// Create reply as a nested comment
 	commentIDStr := commentID.String()
@@ -163,6 +191,7 @@ func (s *CommentService) CreateReply(userID, commentID uuid.UUID, req CreateComm
 			Location:        postWithDetails.Author.Location,
 			Website:         postWithDetails.Author.Website,
 			IsVerified:      postWithDetails.Author.IsVerified,
+			IsPrivate:       postWithDetails.Author.IsPrivate,
 			CreatedAt:       postWithDetails.Author.CreatedAt,
 		},
 		IsLiked: postWithDetails.IsLiked,
@@ -171,28 +200,29 @@ func (s *CommentService) CreateReply(userID, commentID uuid.UUID, req CreateComm
 	return commentResponse, nil
 }
 
-func (s *CommentService) GetComments(postID uuid.UUID, userID uuid.UUID, limit, offset int) ([]CommentResponse, error) {
-	var posts []models.Post
+func (s *CommentService) GetComments(postID uuid.UUID, userID uuid.UUID, page PageRequest) ([]CommentResponse, PageInfo, error) {
+	if _, err := findVisiblePost(s.db, postID, userID); err != nil {
+		return nil, PageInfo{}, err
+	}
+
 	query := s.db.Where("parent_post_id = ? AND type = ? AND is_draft = false", postID, models.PostTypeReply).
-		Preload("Author").
-		Order("created_at ASC").
-		Limit(limit).
-		Offset(offset)
+		Preload("Author")
+	query = filterBlockedPosts(query, userID)
+	query = filterPrivatePosts(query, userID)
 
-	if err := query.Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	// Oldest first, so that a thread reads in order
+	posts, pageInfo, err := paginate(query, page, "posts", true, postCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, userID)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
 	var comments []CommentResponse
-	for _, post := range posts {
-		// Check if user liked this comment
-		isLiked := false
-		if userID != uuid.Nil {
-			var like models.Like
-			if err := s.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error; err == nil {
-				isLiked = true
-			}
-		}
+	for _, post := range postsWithDetails {
 
 		comment := CommentResponse{
 			ID:           post.ID,
@@ -213,9 +243,10 @@ func (s *CommentService) GetComments(postID uuid.UUID, userID uuid.UUID, limit,
 				Location:        post.Author.Location,
 				Website:         post.Author.Website,
 				IsVerified:      post.Author.IsVerified,
+				IsPrivate:       post.Author.IsPrivate,
 				CreatedAt:       post.Author.CreatedAt,
 			},
-			IsLiked: isLiked,
+			IsLiked: post.IsLiked,
 		}
 
 		if post.ParentPostID != nil {
@@ -225,37 +256,36 @@ func (s *CommentService) GetComments(postID uuid.UUID, userID uuid.UUID, limit,
 		comments = append(comments, comment)
 	}
 
-	return comments, nil
+	return comments, pageInfo, nil
 }
 
-func (s *CommentService) GetReplies(commentID uuid.UUID, userID uuid.UUID, limit, offset int) ([]CommentResponse, error) {
-	var posts []models.Post
+func (s *CommentService) GetReplies(commentID uuid.UUID, userID uuid.UUID, page PageRequest) ([]CommentResponse, PageInfo, error) {
+	parentComment, err := findVisiblePost(s.db, commentID, userID)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return nil, PageInfo{}, errors.New("comment not found")
+		}
+		return nil, PageInfo{}, err
+	}
+
 	query := s.db.Where("parent_post_id = ? AND type = ? AND is_draft = false", commentID, models.PostTypeReply).
-		Preload("Author").
-		Order("created_at ASC").
-		Limit(limit).
-		Offset(offset)
+		Preload("Author")
+	query = filterBlockedPosts(query, userID)
+	query = filterPrivatePosts(query, userID)
 
-	if err := query.Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch replies: %w", err)
+	// Oldest first, so that a thread reads in order
+	posts, pageInfo, err := paginate(query, page, "posts", true, postCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
-	// Get the original comment to find the post ID
-	var parentComment models.Post
-	if err := s.db.First(&parentComment, commentID).Error; err != nil {
-		return nil, fmt.Errorf("failed to find parent comment: %w", err)
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, userID)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
 	var replies []CommentResponse
-	for _, post := range posts {
-		// Check if user liked this reply
-		isLiked := false
-		if userID != uuid.Nil {
-			var like models.Like
-			if err := s.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error; err == nil {
-				isLiked = true
-			}
-		}
+	for _, post := range postsWithDetails {
 
 		reply := CommentResponse{
 			ID:           post.ID,
@@ -277,15 +307,16 @@ func (s *CommentService) GetReplies(commentID uuid.UUID, userID uuid.UUID, limit
 				Location:        post.Author.Location,
 				Website:         post.Author.Website,
 				IsVerified:      post.Author.IsVerified,
+				IsPrivate:       post.Author.IsPrivate,
 				CreatedAt:       post.Author.CreatedAt,
 			},
-			IsLiked: isLiked,
+			IsLiked: post.IsLiked,
 		}
 
 		replies = append(replies, reply)
 	}
 
-	return replies, nil
+	return replies, pageInfo, nil
 }
 
 func (s *CommentService) DeleteComment(commentID, userID uuid.UUID) error {
@@ -294,11 +325,14 @@ func (s *CommentService) DeleteComment(commentID, userID uuid.UUID) error {
 }
 
 func (s *CommentService) UpdateComment(commentID, userID uuid.UUID, req CreateCommentRequest) error {
+	// Normalize so the length checked is the length stored
+	req.Content = text.Normalize(req.Content)
+
 	// Validate input
 	if len(req.Content) == 0 {
 		return errors.New("comment content is required")
 	}
-	if len(req.Content) > 280 {
+	if !text.IsValidLength(req.Content) {
 		return errors.New("comment content exceeds 280 characters")
 	}
 
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CounterService repairs the denormalized counters on users and posts
+type CounterService struct {
+	db *gorm.DB
+}
+
+func NewCounterService(db *gorm.DB) *CounterService {
+	return &CounterService{db: db}
+}
+
+// CounterDrift is how far one counter had drifted from the rows it counts
+type CounterDrift struct {
+	Counter string `json:"counter"`
+	// Rows whose counter was wrong
+	Rows int64 `json:"rows"`
+	// Sum of the absolute differences that were corrected
+	Drift int64 `json:"drift"`
+}
+
+// counterDefinition describes a counter column and the query recomputing
+// it. Actual selects id and count for every row with a non-zero count.
+type counterDefinition struct {
+	table  string
+	column string
+	actual string
+}
+
+var reconciledCounters = []counterDefinition{
+	{"users", "followers_count", `
+		SELECT following_id AS id, COUNT(*) AS count FROM follows
+		WHERE deleted_at IS NULL GROUP BY following_id`},
+	{"users", "following_count", `
+		SELECT follower_id AS id, COUNT(*) AS count FROM follows
+		WHERE deleted_at IS NULL GROUP BY follower_id`},
+	{"users", "posts_count", `
+		SELECT author_id AS id, COUNT(*) AS count FROM posts
+		WHERE deleted_at IS NULL GROUP BY author_id`},
+	{"posts", "likes_count", `
+		SELECT post_id AS id, COUNT(*) AS count FROM likes
+		WHERE deleted_at IS NULL GROUP BY post_id`},
+	{"posts", "reposts_count", `
+		SELECT original_post_id AS id, COUNT(*) AS count FROM posts
+		WHERE deleted_at IS NULL AND type = 'repost' AND original_post_id IS NOT NULL
+		GROUP BY original_post_id`},
+	{"posts", "comments_count", `
+		SELECT parent_post_id AS id, COUNT(*) AS count FROM posts
+		WHERE deleted_at IS NULL AND parent_post_id IS NOT NULL
+		GROUP BY parent_post_id`},
+}
+
+// Reconcile recomputes every counter from the rows it counts and fixes the
+// ones that drifted, reporting the drift found per counter
+func (s *CounterService) Reconcile() ([]CounterDrift, error) {
+	drifts := make([]CounterDrift, 0, len(reconciledCounters))
+	for _, counter := range reconciledCounters {
+		drift, err := s.reconcileCounter(counter)
+		if err != nil {
+			return drifts, err
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// reconcileCounter fixes one counter in a single statement. The old value
+// is read from a self-join, which sees the rows as they were before the
+// update.
+func (s *CounterService) reconcileCounter(counter counterDefinition) (CounterDrift, error) {
+	drift := CounterDrift{Counter: counter.table + "." + counter.column}
+	err := s.db.Raw(`
+		WITH actual AS (`+counter.actual+`),
+		fixed AS (
+			UPDATE `+counter.table+` target SET `+counter.column+` = COALESCE(actual.count, 0)
+			FROM `+counter.table+` old
+			LEFT JOIN actual ON actual.id = old.id
+			WHERE target.id = old.id AND old.deleted_at IS NULL
+				AND old.`+counter.column+` <> COALESCE(actual.count, 0)
+			RETURNING ABS(old.`+counter.column+` - COALESCE(actual.count, 0)) AS drift
+		)
+		SELECT COUNT(*) AS fixed_rows, COALESCE(SUM(drift), 0) AS total_drift FROM fixed
+	`).Row().Scan(&drift.Rows, &drift.Drift)
+	if err != nil {
+		return drift, fmt.Errorf("failed to reconcile %s: %w", drift.Counter, err)
+	}
+	return drift, nil
+}
+
+// StartReconcileJob runs Reconcile every interval in the background,
+// logging the drift it repairs
+func (s *CounterService) StartReconcileJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			drifts, err := s.Reconcile()
+			if err != nil {
+				log.Printf("Counter reconciliation failed: %v", err)
+			}
+			for _, drift := range drifts {
+				if drift.Rows > 0 {
+					log.Printf("Counter reconciliation fixed %s on %d rows (total drift %d)", drift.Counter, drift.Rows, drift.Drift)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// adjustFollowCounts moves the follow counters of both sides of a follow by
+// delta
+func adjustFollowCounts(tx *gorm.DB, followerID, followingID uuid.UUID, delta int) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", followerID).
+		UpdateColumn("following_count", gorm.Expr("GREATEST(following_count + ?, 0)", delta)).Error; err != nil {
+		return fmt.Errorf("failed to update following count: %w", err)
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", followingID).
+		UpdateColumn("followers_count", gorm.Expr("GREATEST(followers_count + ?, 0)", delta)).Error; err != nil {
+		return fmt.Errorf("failed to update followers count: %w", err)
+	}
+	return nil
+}
+
+// adjustPostsCount moves the author's post counter by delta
+func adjustPostsCount(tx *gorm.DB, authorID uuid.UUID, delta int) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", authorID).
+		UpdateColumn("posts_count", gorm.Expr("GREATEST(posts_count + ?, 0)", delta)).Error; err != nil {
+		return fmt.Errorf("failed to update posts count: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"digeon-backend/internal/models"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EventType string
+
+const (
+	// A post was published; delivered to the author's followers
+	EventTypeTimelinePost EventType = "timeline.post"
+	// A notification was created for UserID
+	EventTypeNotification EventType = "notification"
+	// UserID's unread notifications changed without a new notification
+	EventTypeNotificationsRead EventType = "notifications.read"
+	// A post's like or repost count changed
+	EventTypePostCounts EventType = "post.counts"
+	// UserID followed or unfollowed someone
+	EventTypeFollowsChanged EventType = "follows.changed"
+)
+
+// Event is published through the EventBroker. Events only carry IDs and
+// counters so they stay well under the 8000 byte NOTIFY payload limit;
+// subscribers load whatever they need to render them.
+type Event struct {
+	Type EventType `json:"type"`
+	// Recipient of the event, or uuid.Nil for events that every
+	// subscriber filters for itself
+	UserID         uuid.UUID   `json:"user_id"`
+	PostID         uuid.UUID   `json:"post_id"`
+	AuthorID       uuid.UUID   `json:"author_id"`
+	NotificationID uuid.UUID   `json:"notification_id"`
+	Counts         *PostCounts `json:"counts,omitempty"`
+}
+
+type PostCounts struct {
+	LikesCount   int `json:"likes_count"`
+	RepostsCount int `json:"reposts_count"`
+}
+
+// EventBackend carries published events to every replica, including the one
+// that published them
+type EventBackend interface {
+	Publish(ctx context.Context, payload []byte) error
+	// Listen calls handle for every published payload until ctx is done
+	Listen(ctx context.Context, handle func(payload []byte)) error
+}
+
+// EventBroker fans events out to the subscriptions held by this process.
+// A nil broker drops everything published to it.
+type EventBroker struct {
+	backend EventBackend
+
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// Subscription receives the events addressed to one user plus the events
+// addressed to nobody in particular
+type Subscription struct {
+	UserID uuid.UUID
+	Events chan Event
+
+	broker *EventBroker
+}
+
+const subscriptionBuffer = 64
+
+func NewEventBroker(backend EventBackend) *EventBroker {
+	return &EventBroker{
+		backend:     backend,
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Start listens on the backend in the background until ctx is done
+func (b *EventBroker) Start(ctx context.Context) {
+	go func() {
+		if err := b.backend.Listen(ctx, b.dispatch); err != nil && ctx.Err() == nil {
+			log.Printf("Event listener stopped: %v", err)
+		}
+	}()
+}
+
+func (b *EventBroker) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+	if err := b.backend.Publish(context.Background(), payload); err != nil {
+		log.Printf("Failed to publish %s event: %v", event.Type, err)
+	}
+}
+
+func (b *EventBroker) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		UserID: userID,
+		Events: make(chan Event, subscriptionBuffer),
+		broker: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Close stops delivery to the subscription
+func (sub *Subscription) Close() {
+	b := sub.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers[sub.UserID], sub)
+	if len(b.subscribers[sub.UserID]) == 0 {
+		delete(b.subscribers, sub.UserID)
+	}
+}
+
+func (b *EventBroker) dispatch(payload []byte) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		log.Printf("Failed to decode event: %v", err)
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	deliver := func(subs map[*Subscription]struct{}) {
+		for sub := range subs {
+			// Never block the listener on a slow client; it will catch up
+			// from the REST endpoints
+			select {
+			case sub.Events <- event:
+			default:
+			}
+		}
+	}
+
+	if event.UserID != uuid.Nil {
+		deliver(b.subscribers[event.UserID])
+		return
+	}
+	for _, subs := range b.subscribers {
+		deliver(subs)
+	}
+}
+
+// publishPostCounts publishes the current like and repost counts of a post
+func publishPostCounts(db *gorm.DB, broker *EventBroker, postID uuid.UUID) {
+	if broker == nil {
+		return
+	}
+
+	var post models.Post
+	if err := db.Select("id", "likes_count", "reposts_count").First(&post, postID).Error; err != nil {
+		return
+	}
+	broker.Publish(Event{
+		Type:   EventTypePostCounts,
+		PostID: postID,
+		Counts: &PostCounts{LikesCount: post.LikesCount, RepostsCount: post.RepostsCount},
+	})
+}
+
+// LocalEventBackend delivers events within a single process
+type LocalEventBackend struct {
+	mu     sync.RWMutex
+	handle func(payload []byte)
+}
+
+func NewLocalEventBackend() *LocalEventBackend {
+	return &LocalEventBackend{}
+}
+
+func (l *LocalEventBackend) Publish(ctx context.Context, payload []byte) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.handle != nil {
+		l.handle(payload)
+	}
+	return nil
+}
+
+func (l *LocalEventBackend) Listen(ctx context.Context, handle func(payload []byte)) error {
+	l.mu.Lock()
+	l.handle = handle
+	l.mu.Unlock()
+
+	<-ctx.Done()
+
+	l.mu.Lock()
+	l.handle = nil
+	l.mu.Unlock()
+	return ctx.Err()
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const eventsChannel = "digeon_events"
+
+// PostgresEventBackend publishes with NOTIFY and listens on a dedicated
+// connection, since LISTEN doesn't work through the GORM pool
+type PostgresEventBackend struct {
+	db  *gorm.DB
+	dsn string
+}
+
+func NewPostgresEventBackend(db *gorm.DB, dsn string) *PostgresEventBackend {
+	return &PostgresEventBackend{db: db, dsn: dsn}
+}
+
+func (p *PostgresEventBackend) Publish(ctx context.Context, payload []byte) error {
+	if err := p.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", eventsChannel, string(payload)).Error; err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+	return nil
+}
+
+// Listen reconnects with a growing delay whenever the connection drops.
+// Events published while disconnected are lost.
+func (p *PostgresEventBackend) Listen(ctx context.Context, handle func(payload []byte)) error {
+	backoff := time.Second
+	for {
+		err := p.listen(ctx, handle, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Event listener disconnected, retrying in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (p *PostgresEventBackend) listen(ctx context.Context, handle func(payload []byte), connected func()) error {
+	conn, err := pgx.Connect(ctx, p.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+eventsChannel); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	connected()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle([]byte(notification.Payload))
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisEventBackend publishes events on a Redis pub/sub channel
+type RedisEventBackend struct {
+	client *redis.Client
+}
+
+func NewRedisEventBackend(client *redis.Client) *RedisEventBackend {
+	return &RedisEventBackend{client: client}
+}
+
+func (r *RedisEventBackend) Publish(ctx context.Context, payload []byte) error {
+	if err := r.client.Publish(ctx, eventsChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+	return nil
+}
+
+// Listen relies on the client to resubscribe after reconnecting
+func (r *RedisEventBackend) Listen(ctx context.Context, handle func(payload []byte)) error {
+	pubsub := r.client.Subscribe(ctx, eventsChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("subscription closed")
+			}
+			handle([]byte(message.Payload))
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"digeon-backend/internal/models"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -12,34 +13,56 @@ import (
 type FollowService struct {
 	db                  *gorm.DB
 	notificationService *NotificationService
+	analyticsService    *AnalyticsService
+	timelineService     *TimelineService
+	broker              *EventBroker
+
+	// User IDs whose suggestions are being refreshed in the background
+	refreshingSuggestions sync.Map
+	// IDs of follow imports running in the background
+	runningImports sync.Map
 }
 
-func NewFollowService(db *gorm.DB, notificationService *NotificationService) *FollowService {
+func NewFollowService(db *gorm.DB, notificationService *NotificationService, analyticsService *AnalyticsService, timelineService *TimelineService, broker *EventBroker) *FollowService {
 	return &FollowService{
 		db:                  db,
 		notificationService: notificationService,
+		analyticsService:    analyticsService,
+		timelineService:     timelineService,
+		broker:              broker,
 	}
 }
 
-func (s *FollowService) FollowUser(followerID, followingID uuid.UUID) error {
+// FollowUser follows a user. Following a private account sends a follow
+// request instead, in which case requested is true.
+func (s *FollowService) FollowUser(followerID, followingID uuid.UUID) (requested bool, err error) {
 	// Check if trying to follow themselves
 	if followerID == followingID {
-		return errors.New("cannot follow yourself")
+		return false, errors.New("cannot follow yourself")
 	}
 
 	// Check if following user exists
 	var followingUser models.User
 	if err := s.db.First(&followingUser, followingID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return false, errors.New("user not found")
 		}
-		return fmt.Errorf("failed to find user: %w", err)
+		return false, fmt.Errorf("failed to find user: %w", err)
+	}
+	if blocked, err := isBlockedBetween(s.db, followerID, followingID); err != nil {
+		return false, err
+	} else if blocked {
+		return false, errors.New("user not found")
 	}
 
 	// Check if already following
 	var existingFollow models.Follow
 	if err := s.db.Where("follower_id = ? AND following_id = ?", followerID, followingID).First(&existingFollow).Error; err == nil {
-		return errors.New("already following user")
+		return false, errors.New("already following user")
+	}
+
+	if followingUser.IsPrivate {
+		return true, s.requestFollow(followerID, followingID)
 	}
 
 	// Create follow relationship
@@ -48,16 +71,37 @@ func (s *FollowService) FollowUser(followerID, followingID uuid.UUID) error {
 		FollowingID: followingID,
 	}
 
-	if err := s.db.Create(&follow).Error; err != nil {
-		return fmt.Errorf("failed to create follow relationship: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&follow).Error; err != nil {
+			return fmt.Errorf("failed to create follow relationship: %w", err)
+		}
+		return adjustFollowCounts(tx, followerID, followingID, 1)
+	})
+	if err != nil {
+		return false, err
 	}
+	s.followCreated(followerID, followingID)
 
 	// Create notification
 	if s.notificationService != nil {
 		s.notificationService.CreateFollowNotification(followerID, followingID)
 	}
 
-	return nil
+	return false, nil
+}
+
+// followCreated updates timelines, streams and analytics for a new follow
+func (s *FollowService) followCreated(followerID, followingID uuid.UUID) {
+	// Copy the followed user's recent posts into the follower's timeline
+	if s.timelineService != nil {
+		s.timelineService.BackfillFollowAsync(followerID, followingID)
+	}
+	s.broker.Publish(Event{Type: EventTypeFollowsChanged, UserID: followerID})
+
+	// Record analytics event
+	if s.analyticsService != nil {
+		s.analyticsService.RecordEvent(models.AnalyticsEventFollow, &followerID, followingID, nil)
+	}
 }
 
 func (s *FollowService) UnfollowUser(followerID, followingID uuid.UUID) error {
@@ -70,28 +114,98 @@ func (s *FollowService) UnfollowUser(followerID, followingID uuid.UUID) error {
 	var follow models.Follow
 	if err := s.db.Where("follower_id = ? AND following_id = ?", followerID, followingID).First(&follow).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Withdraw a pending follow request instead
+			if err := s.CancelFollowRequest(followerID, followingID); err == nil {
+				return nil
+			}
 			return errors.New("follow relationship not found")
 		}
 		return fmt.Errorf("failed to find follow relationship: %w", err)
 	}
 
 	// Delete follow relationship
-	if err := s.db.Delete(&follow).Error; err != nil {
-		return fmt.Errorf("failed to delete follow relationship: %w", err)
+	if err := s.deleteFollow(&follow); err != nil {
+		return err
+	}
+
+	// Drop the unfollowed user's posts from the follower's timeline
+	if s.timelineService != nil {
+		if err := s.timelineService.RemoveFollow(followerID, followingID); err != nil {
+			return fmt.Errorf("failed to update timeline: %w", err)
+		}
+	}
+	s.broker.Publish(Event{Type: EventTypeFollowsChanged, UserID: followerID})
+
+	// Record analytics event
+	if s.analyticsService != nil {
+		s.analyticsService.RecordEvent(models.AnalyticsEventUnfollow, &followerID, followingID, nil)
+	}
+
+	return nil
+}
+
+// deleteFollow deletes a follow and updates both users' counters. A
+// concurrent unfollow, removal or block may have deleted it first, in which
+// case the counters were already updated.
+func (s *FollowService) deleteFollow(follow *models.Follow) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(follow)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete follow relationship: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("follow relationship not found")
+		}
+		return adjustFollowCounts(tx, follow.FollowerID, follow.FollowingID, -1)
+	})
+}
+
+// RemoveFollower ends another user's follow of the user without blocking
+// them. The follower isn't notified and may follow again.
+func (s *FollowService) RemoveFollower(userID, followerID uuid.UUID) error {
+	if userID == followerID {
+		return errors.New("cannot remove yourself")
+	}
+
+	var follow models.Follow
+	if err := s.db.Where("follower_id = ? AND following_id = ?", followerID, userID).First(&follow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("follower not found")
+		}
+		return fmt.Errorf("failed to find follow relationship: %w", err)
+	}
+
+	if err := s.deleteFollow(&follow); err != nil {
+		if err.Error() == "follow relationship not found" {
+			return errors.New("follower not found")
+		}
+		return err
+	}
+
+	// Drop the user's posts from the removed follower's timeline
+	if s.timelineService != nil {
+		if err := s.timelineService.RemoveFollow(followerID, userID); err != nil {
+			return fmt.Errorf("failed to update timeline: %w", err)
+		}
+	}
+	s.broker.Publish(Event{Type: EventTypeFollowsChanged, UserID: followerID})
+
+	// Counts towards the user's follower growth like an unfollow
+	if s.analyticsService != nil {
+		s.analyticsService.RecordEvent(models.AnalyticsEventUnfollow, &followerID, userID, nil)
 	}
 
 	return nil
 }
 
-func (s *FollowService) GetFollowers(userID uuid.UUID, limit, offset int) ([]models.UserPublic, error) {
-	var follows []models.Follow
-	if err := s.db.Where("following_id = ?", userID).
-		Preload("Follower").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&follows).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch followers: %w", err)
+// GetFollowers returns the users following the user, with the viewer's relationship to each of them
+func (s *FollowService) GetFollowers(userID, viewerID uuid.UUID, page PageRequest) ([]UserWithRelationship, PageInfo, error) {
+	query := s.db.Where("following_id = ?", userID).
+		Preload("Follower")
+
+	follows, pageInfo, err := paginate(query, page, "follows", false, followCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
 	var users []models.UserPublic
@@ -106,23 +220,27 @@ func (s *FollowService) GetFollowers(userID uuid.UUID, limit, offset int) ([]mod
 			Location:        follow.Follower.Location,
 			Website:         follow.Follower.Website,
 			IsVerified:      follow.Follower.IsVerified,
+			IsPrivate:       follow.Follower.IsPrivate,
 			CreatedAt:       follow.Follower.CreatedAt,
 		}
 		users = append(users, userPublic)
 	}
 
-	return users, nil
+	withRelationships, err := s.withRelationships(viewerID, users)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+	return withRelationships, pageInfo, nil
 }
 
-func (s *FollowService) GetFollowing(userID uuid.UUID, limit, offset int) ([]models.UserPublic, error) {
-	var follows []models.Follow
-	if err := s.db.Where("follower_id = ?", userID).
-		Preload("Following").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&follows).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch following: %w", err)
+// GetFollowing returns the users the user follows, with the viewer's relationship to each of them
+func (s *FollowService) GetFollowing(userID, viewerID uuid.UUID, page PageRequest) ([]UserWithRelationship, PageInfo, error) {
+	query := s.db.Where("follower_id = ?", userID).
+		Preload("Following")
+
+	follows, pageInfo, err := paginate(query, page, "follows", false, followCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
 	var users []models.UserPublic
@@ -137,12 +255,17 @@ func (s *FollowService) GetFollowing(userID uuid.UUID, limit, offset int) ([]mod
 			Location:        follow.Following.Location,
 			Website:         follow.Following.Website,
 			IsVerified:      follow.Following.IsVerified,
+			IsPrivate:       follow.Following.IsPrivate,
 			CreatedAt:       follow.Following.CreatedAt,
 		}
 		users = append(users, userPublic)
 	}
 
-	return users, nil
+	withRelationships, err := s.withRelationships(viewerID, users)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+	return withRelationships, pageInfo, nil
 }
 
 func (s *FollowService) IsFollowing(followerID, followingID uuid.UUID) (bool, error) {
@@ -156,54 +279,14 @@ func (s *FollowService) IsFollowing(followerID, followingID uuid.UUID) (bool, er
 	return true, nil
 }
 
+// GetFollowCounts returns the user's follow counters. Unknown users have no
+// follows.
 func (s *FollowService) GetFollowCounts(userID uuid.UUID) (followersCount, followingCount int64, err error) {
-	// Get followers count
-	if err := s.db.Model(&models.Follow{}).Where("following_id = ?", userID).Count(&followersCount).Error; err != nil {
-		return 0, 0, fmt.Errorf("failed to count followers: %w", err)
-	}
-
-	// Get following count
-	if err := s.db.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&followingCount).Error; err != nil {
-		return 0, 0, fmt.Errorf("failed to count following: %w", err)
-	}
-
-	return followersCount, followingCount, nil
-}
-
-func (s *FollowService) GetSuggestedUsers(userID uuid.UUID, limit int) ([]models.UserPublic, error) {
-	// Get users that the current user is not following
-	// and exclude the current user
-	var users []models.User
-	if err := s.db.Where(`
-		id != ? AND
-		id NOT IN (
-			SELECT following_id FROM follows 
-			WHERE follower_id = ? AND deleted_at IS NULL
-		)
-	`, userID, userID).
-		Where("is_active = true").
-		Order("created_at DESC").
-		Limit(limit).
-		Find(&users).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch suggested users: %w", err)
-	}
-
-	var userPublics []models.UserPublic
-	for _, user := range users {
-		userPublic := models.UserPublic{
-			ID:              user.ID,
-			Username:        user.Username,
-			DisplayName:     user.DisplayName,
-			Bio:             user.Bio,
-			ProfileImageURL: user.ProfileImageURL,
-			CoverImageURL:   user.CoverImageURL,
-			Location:        user.Location,
-			Website:         user.Website,
-			IsVerified:      user.IsVerified,
-			CreatedAt:       user.CreatedAt,
-		}
-		userPublics = append(userPublics, userPublic)
+	var user models.User
+	if err := s.db.Select("followers_count", "following_count").Where("id = ?", userID).
+		Limit(1).Find(&user).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch follow counts: %w", err)
 	}
 
-	return userPublics, nil
+	return user.FollowersCount, user.FollowingCount, nil
 }
\ No newline at end of file
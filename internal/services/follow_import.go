@@ -0,0 +1,306 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxFollowImportUsernames = 5000
+	// Follows made per second by an import, so that a large import doesn't
+	// flood notifications and timeline backfills
+	followImportRate = 5
+	// An import saves its progress after this many usernames
+	followImportBatchSize = 25
+	// Rows written between flushes of a CSV export
+	followExportFlushSize = 500
+)
+
+// ImportFollows starts a background import following every username in the
+// CSV, one per row in the first column. A leading "username" header row and
+// @ prefixes are ignored, so an export can be imported as is.
+func (s *FollowService) ImportFollows(userID uuid.UUID, r io.Reader) (*models.FollowImport, error) {
+	usernames, err := parseFollowImportCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var activeCount int64
+	if err := s.db.Model(&models.FollowImport{}).
+		Where("user_id = ? AND status IN ?", userID, []models.FollowImportStatus{models.FollowImportStatusPending, models.FollowImportStatusRunning}).
+		Count(&activeCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to check follow imports: %w", err)
+	}
+	if activeCount > 0 {
+		return nil, errors.New("a follow import is already running")
+	}
+
+	followImport := models.FollowImport{
+		UserID:              userID,
+		Status:              models.FollowImportStatusPending,
+		Usernames:           usernames,
+		Total:               len(usernames),
+		UnresolvedUsernames: []string{},
+	}
+	if err := s.db.Create(&followImport).Error; err != nil {
+		return nil, fmt.Errorf("failed to create follow import: %w", err)
+	}
+
+	s.runFollowImportAsync(followImport.ID)
+	return &followImport, nil
+}
+
+// GetFollowImport returns the progress of one of the user's imports
+func (s *FollowService) GetFollowImport(userID, importID uuid.UUID) (*models.FollowImport, error) {
+	var followImport models.FollowImport
+	if err := s.db.Where("id = ? AND user_id = ?", importID, userID).First(&followImport).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("follow import not found")
+		}
+		return nil, fmt.Errorf("failed to find follow import: %w", err)
+	}
+	return &followImport, nil
+}
+
+// ResumeFollowImports restarts imports that were interrupted, e.g. by a
+// server restart. Each continues from its saved progress.
+func (s *FollowService) ResumeFollowImports() {
+	var importIDs []uuid.UUID
+	if err := s.db.Model(&models.FollowImport{}).
+		Where("status IN ?", []models.FollowImportStatus{models.FollowImportStatusPending, models.FollowImportStatusRunning}).
+		Pluck("id", &importIDs).Error; err != nil {
+		log.Printf("Failed to find follow imports to resume: %v", err)
+		return
+	}
+	for _, importID := range importIDs {
+		s.runFollowImportAsync(importID)
+	}
+}
+
+// runFollowImportAsync runs the import in the background unless it's
+// already running
+func (s *FollowService) runFollowImportAsync(importID uuid.UUID) {
+	if _, running := s.runningImports.LoadOrStore(importID, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer s.runningImports.Delete(importID)
+		if err := s.runFollowImport(importID); err != nil {
+			log.Printf("Follow import %s failed: %v", importID, err)
+			if err := s.db.Model(&models.FollowImport{}).Where("id = ?", importID).Updates(map[string]interface{}{
+				"status": models.FollowImportStatusFailed,
+				"error":  "import failed",
+			}).Error; err != nil {
+				log.Printf("Failed to mark follow import %s as failed: %v", importID, err)
+			}
+		}
+	}()
+}
+
+func (s *FollowService) runFollowImport(importID uuid.UUID) error {
+	var followImport models.FollowImport
+	if err := s.db.First(&followImport, importID).Error; err != nil {
+		return fmt.Errorf("failed to find follow import: %w", err)
+	}
+	followImport.Status = models.FollowImportStatusRunning
+	if err := s.saveFollowImportProgress(&followImport); err != nil {
+		return err
+	}
+
+	limiter := time.NewTicker(time.Second / followImportRate)
+	defer limiter.Stop()
+
+	for followImport.Processed < len(followImport.Usernames) {
+		end := min(followImport.Processed+followImportBatchSize, len(followImport.Usernames))
+		batch := followImport.Usernames[followImport.Processed:end]
+
+		usersByName, err := s.resolveUsernames(batch)
+		if err != nil {
+			return err
+		}
+
+		for _, username := range batch {
+			user, ok := usersByName[username]
+			if !ok {
+				followImport.UnresolvedUsernames = append(followImport.UnresolvedUsernames, username)
+				continue
+			}
+			if user.ID == followImport.UserID {
+				followImport.Skipped++
+				continue
+			}
+
+			<-limiter.C
+			requested, err := s.FollowUser(followImport.UserID, user.ID)
+			switch {
+			case err == nil && requested:
+				followImport.Requested++
+			case err == nil:
+				followImport.Followed++
+			case err.Error() == "already following user" || err.Error() == "follow request already sent":
+				followImport.Skipped++
+			case err.Error() == "user not found":
+				// Blocked either way; reported like a missing account
+				followImport.UnresolvedUsernames = append(followImport.UnresolvedUsernames, username)
+			default:
+				log.Printf("Follow import %s could not follow @%s: %v", importID, username, err)
+				followImport.Failed++
+			}
+		}
+
+		followImport.Processed = end
+		if err := s.saveFollowImportProgress(&followImport); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	followImport.Status = models.FollowImportStatusCompleted
+	followImport.CompletedAt = &now
+	if err := s.saveFollowImportProgress(&followImport); err != nil {
+		return err
+	}
+	return nil
+}
+
+// resolveUsernames maps each lowercased username to its active account
+func (s *FollowService) resolveUsernames(usernames []string) (map[string]models.User, error) {
+	var users []models.User
+	if err := s.db.Where("LOWER(username) IN ? AND is_active = true", usernames).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve usernames: %w", err)
+	}
+	usersByName := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByName[strings.ToLower(user.Username)] = user
+	}
+	return usersByName, nil
+}
+
+func (s *FollowService) saveFollowImportProgress(followImport *models.FollowImport) error {
+	if err := s.db.Model(followImport).Select(
+		"status", "processed", "followed", "requested", "skipped", "failed", "unresolved_usernames", "completed_at",
+	).Updates(followImport).Error; err != nil {
+		return fmt.Errorf("failed to save follow import progress: %w", err)
+	}
+	return nil
+}
+
+// parseFollowImportCSV returns the distinct lowercased usernames in the
+// first column of the CSV, in file order
+func parseFollowImportCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var usernames []string
+	seen := make(map[string]bool)
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid CSV file")
+		}
+
+		username := strings.TrimSpace(record[0])
+		if row == 0 {
+			// Spreadsheet exports often start with a byte order mark
+			username = strings.TrimPrefix(username, "\ufeff")
+			if strings.EqualFold(username, "username") {
+				continue
+			}
+		}
+		username = strings.ToLower(strings.TrimPrefix(username, "@"))
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+
+		if len(usernames) > maxFollowImportUsernames {
+			return nil, errors.New("at most 5000 usernames are allowed")
+		}
+	}
+
+	if len(usernames) == 0 {
+		return nil, errors.New("no usernames found")
+	}
+	return usernames, nil
+}
+
+// ExportFollowing writes the accounts the user follows to w as CSV, oldest
+// follow first. Rows are streamed, so the graph is never held in memory.
+func (s *FollowService) ExportFollowing(userID uuid.UUID, w io.Writer) error {
+	return s.exportFollows(w, userID, "follower_id", "following_id")
+}
+
+// ExportFollowers writes the user's followers to w as CSV, oldest follow
+// first
+func (s *FollowService) ExportFollowers(userID uuid.UUID, w io.Writer) error {
+	return s.exportFollows(w, userID, "following_id", "follower_id")
+}
+
+// exportFollows writes the users in otherColumn of the user's follows,
+// matched on userColumn. Both columns must be trusted SQL.
+func (s *FollowService) exportFollows(w io.Writer, userID uuid.UUID, userColumn, otherColumn string) error {
+	rows, err := s.db.Table("follows").
+		Select("users.username, users.display_name, follows.created_at").
+		Joins("JOIN users ON users.id = follows."+otherColumn+" AND users.deleted_at IS NULL").
+		Where("follows."+userColumn+" = ? AND follows.deleted_at IS NULL", userID).
+		Order("follows.created_at").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("failed to export follows: %w", err)
+	}
+	defer rows.Close()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"username", "display_name", "followed_at"}); err != nil {
+		return err
+	}
+
+	written := 0
+	for rows.Next() {
+		var username, displayName string
+		var followedAt time.Time
+		if err := rows.Scan(&username, &displayName, &followedAt); err != nil {
+			return fmt.Errorf("failed to export follows: %w", err)
+		}
+		if err := writer.Write([]string{username, csvSafe(displayName), followedAt.UTC().Format(time.RFC3339)}); err != nil {
+			return err
+		}
+
+		written++
+		if written%followExportFlushSize == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export follows: %w", err)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafe keeps spreadsheets from evaluating user-controlled text as a
+// formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
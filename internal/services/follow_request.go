@@ -0,0 +1,174 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// requestFollow records a request to follow a private account and notifies
+// its owner
+func (s *FollowService) requestFollow(requesterID, targetID uuid.UUID) error {
+	var existingRequest models.FollowRequest
+	if err := s.db.Where("requester_id = ? AND target_id = ?", requesterID, targetID).First(&existingRequest).Error; err == nil {
+		return errors.New("follow request already sent")
+	}
+
+	request := models.FollowRequest{
+		RequesterID: requesterID,
+		TargetID:    targetID,
+	}
+	if err := s.db.Create(&request).Error; err != nil {
+		return fmt.Errorf("failed to create follow request: %w", err)
+	}
+
+	if s.notificationService != nil {
+		s.notificationService.CreateFollowRequestNotification(requesterID, targetID)
+	}
+
+	return nil
+}
+
+// GetFollowRequests returns the users waiting for the user to approve their
+// follow requests, most recent first
+func (s *FollowService) GetFollowRequests(userID uuid.UUID, page PageRequest) ([]models.UserPublic, PageInfo, error) {
+	query := s.db.Where("target_id = ?", userID).
+		Preload("Requester")
+
+	requests, pageInfo, err := paginate(query, page, "follow_requests", false, followRequestCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+
+	users := make([]models.UserPublic, 0, len(requests))
+	for _, request := range requests {
+		users = append(users, toUserPublic(request.Requester))
+	}
+
+	return users, pageInfo, nil
+}
+
+// ApproveFollowRequest turns a pending request to follow the user into a
+// follow
+func (s *FollowService) ApproveFollowRequest(userID, requesterID uuid.UUID) error {
+	request, err := s.findFollowRequest(requesterID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(request).Error; err != nil {
+			return fmt.Errorf("failed to delete follow request: %w", err)
+		}
+
+		var existingFollow models.Follow
+		if err := tx.Where("follower_id = ? AND following_id = ?", requesterID, userID).First(&existingFollow).Error; err == nil {
+			return nil
+		}
+
+		follow := models.Follow{
+			FollowerID:  requesterID,
+			FollowingID: userID,
+		}
+		if err := tx.Create(&follow).Error; err != nil {
+			return fmt.Errorf("failed to create follow relationship: %w", err)
+		}
+		return adjustFollowCounts(tx, requesterID, userID, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.followCreated(requesterID, userID)
+	return nil
+}
+
+// RejectFollowRequest declines a pending request to follow the user. The
+// requester isn't notified.
+func (s *FollowService) RejectFollowRequest(userID, requesterID uuid.UUID) error {
+	request, err := s.findFollowRequest(requesterID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(request).Error; err != nil {
+		return fmt.Errorf("failed to delete follow request: %w", err)
+	}
+	return nil
+}
+
+// CancelFollowRequest withdraws the requester's pending request
+func (s *FollowService) CancelFollowRequest(requesterID, targetID uuid.UUID) error {
+	return s.RejectFollowRequest(targetID, requesterID)
+}
+
+func (s *FollowService) findFollowRequest(requesterID, targetID uuid.UUID) (*models.FollowRequest, error) {
+	var request models.FollowRequest
+	if err := s.db.Where("requester_id = ? AND target_id = ?", requesterID, targetID).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("follow request not found")
+		}
+		return nil, fmt.Errorf("failed to find follow request: %w", err)
+	}
+	return &request, nil
+}
+
+// canViewPosts reports whether the viewer may see the author's posts: the
+// author's account is public, or the viewer is the author or one of their
+// approved followers
+func canViewPosts(db *gorm.DB, authorID, viewerID uuid.UUID) (bool, error) {
+	var count int64
+	if err := excludePrivate(db.Model(&models.User{}).Where("users.id = ?", authorID), viewerID, "users.id").
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check account privacy: %w", err)
+	}
+	return count > 0, nil
+}
+
+// findVisiblePost loads a post, treating it as missing when the viewer is
+// in a block with its author or may not see the author's posts
+func findVisiblePost(db *gorm.DB, postID, viewerID uuid.UUID) (*models.Post, error) {
+	var post models.Post
+	if err := db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("post not found")
+		}
+		return nil, fmt.Errorf("failed to find post: %w", err)
+	}
+	if blocked, err := isBlockedBetween(db, viewerID, post.AuthorID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, errors.New("post not found")
+	}
+	if visible, err := canViewPosts(db, post.AuthorID, viewerID); err != nil {
+		return nil, err
+	} else if !visible {
+		return nil, errors.New("post not found")
+	}
+	return &post, nil
+}
+
+// excludePrivate drops rows where column holds a private account the viewer
+// doesn't follow. Anonymous viewers never see private accounts' posts.
+// column must be trusted SQL.
+func excludePrivate(query *gorm.DB, viewerID uuid.UUID, column string) *gorm.DB {
+	return query.Where(`NOT EXISTS (
+		SELECT 1 FROM users private_author
+		WHERE private_author.id = `+column+` AND private_author.is_private AND private_author.id <> ?
+			AND NOT EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = ? AND follows.following_id = private_author.id
+					AND follows.deleted_at IS NULL
+			)
+	)`, viewerID, viewerID)
+}
+
+// filterPrivatePosts drops posts by private accounts the viewer doesn't
+// follow, along with reposts and quotes of their posts
+func filterPrivatePosts(query *gorm.DB, viewerID uuid.UUID) *gorm.DB {
+	query = excludePrivate(query, viewerID, "posts.author_id")
+	return excludePrivate(query, viewerID, "(SELECT original.author_id FROM posts original WHERE original.id = posts.original_post_id)")
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// How far back each candidate source looks for posts
+	forYouCandidateWindow = 72 * time.Hour
+	// Upper bound on posts pulled from each source per request
+	forYouCandidatesPerSource = 300
+	// Number of hashtags treated as trending
+	forYouTrendingHashtags = 10
+	// How far back viewer-author interactions count towards affinity
+	forYouAffinityWindow = 30 * 24 * time.Hour
+)
+
+// GetForYouTimeline returns posts ranked for the viewer. Candidates come from
+// followed authors, posts liked or reposted by followed users, and posts
+// with trending hashtags; see rankCandidates for the ordering.
+func (s *TimelineService) GetForYouTimeline(userID uuid.UUID, limit, offset int) (*TimelineResponse, error) {
+	preference := sensitiveContentPreference(s.db, userID)
+	now := time.Now()
+	since := now.Add(-forYouCandidateWindow)
+
+	candidates := make(map[uuid.UUID]*rankingCandidate)
+	addCandidates := func(source candidateSource, query *gorm.DB) error {
+		var found []rankingCandidate
+		if err := query.
+			Select("posts.id, posts.author_id, posts.created_at, posts.likes_count, posts.reposts_count, posts.comments_count").
+			Order("posts.created_at DESC").
+			Limit(forYouCandidatesPerSource).
+			Scan(&found).Error; err != nil {
+			return fmt.Errorf("failed to fetch candidates: %w", err)
+		}
+		for _, c := range found {
+			if existing, ok := candidates[c.PostID]; ok {
+				existing.Sources |= source
+				continue
+			}
+			c.Sources = source
+			candidates[c.PostID] = &c
+		}
+		return nil
+	}
+
+	following := s.db.Model(&models.Follow{}).Select("following_id").Where("follower_id = ?", userID)
+
+	// Posts by followed authors
+	if err := addCandidates(sourceFollowing, s.candidatePosts(userID, preference, since).
+		Where("posts.author_id IN (?)", following)); err != nil {
+		return nil, err
+	}
+
+	// Posts that followed users liked or reposted, by authors the viewer
+	// doesn't follow yet
+	engaged := s.db.Model(&models.Like{}).Select("post_id").
+		Where("user_id IN (?) AND created_at > ?", following, since)
+	reposted := s.db.Model(&models.Post{}).Select("original_post_id").
+		Where("author_id IN (?) AND type = ? AND created_at > ?", following, models.PostTypeRepost, since)
+	if err := addCandidates(sourceFriendsOfFriends, s.candidatePosts(userID, preference, since).
+		Where("posts.id IN (?) OR posts.id IN (?)", engaged, reposted).
+		Where("posts.author_id NOT IN (?)", following)); err != nil {
+		return nil, err
+	}
+
+	// Posts with the hashtags used most over the last day
+	trending := s.db.Table("post_hashtags").
+		Select("post_hashtags.hashtag_id").
+		Joins("JOIN posts ON posts.id = post_hashtags.post_id").
+		Where("posts.created_at > ? AND posts.deleted_at IS NULL", now.Add(-24*time.Hour)).
+		Group("post_hashtags.hashtag_id").
+		Order("COUNT(*) DESC, post_hashtags.hashtag_id").
+		Limit(forYouTrendingHashtags)
+	if err := addCandidates(sourceTrendingHashtag, s.candidatePosts(userID, preference, since).
+		Where("posts.id IN (?)", s.db.Table("post_hashtags").Select("post_id").Where("hashtag_id IN (?)", trending))); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadInteractions(userID, candidates, now.Add(-forYouAffinityWindow)); err != nil {
+		return nil, err
+	}
+
+	pool := make([]rankingCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		pool = append(pool, *c)
+	}
+	ranked := rankCandidates(pool, now, s.rankingWeights)
+	total := int64(len(ranked))
+
+	var postIDs []uuid.UUID
+	if offset < len(ranked) {
+		for _, c := range ranked[offset:min(offset+limit, len(ranked))] {
+			postIDs = append(postIDs, c.PostID)
+		}
+	}
+
+	posts := []models.Post{}
+	if len(postIDs) > 0 {
+		var err error
+		if posts, err = loadPostsInOrder(s.visiblePosts(userID, preference), postIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Convert to PostWithDetails
+	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID, preference)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TimelineResponse{
+		Posts:    postsWithDetails,
+		Limit:    limit,
+		Offset:   offset,
+		PageInfo: PageInfo{Total: &total},
+	}, nil
+}
+
+// candidatePosts is the base query for every For You source: recent
+// published top-level posts the viewer may see, excluding their own
+func (s *TimelineService) candidatePosts(userID uuid.UUID, preference models.SensitiveContentPreference, since time.Time) *gorm.DB {
+	return s.publishedPosts(userID, preference).
+		Where("posts.type <> ? AND posts.parent_post_id IS NULL", models.PostTypeRepost).
+		Where("posts.author_id <> ? AND posts.created_at > ?", userID, since)
+}
+
+// loadInteractions counts the viewer's recent likes and replies on each
+// candidate author's posts
+func (s *TimelineService) loadInteractions(userID uuid.UUID, candidates map[uuid.UUID]*rankingCandidate, since time.Time) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	authorIDs := make([]uuid.UUID, 0, len(candidates))
+	seen := make(map[uuid.UUID]bool)
+	for _, c := range candidates {
+		if !seen[c.AuthorID] {
+			seen[c.AuthorID] = true
+			authorIDs = append(authorIDs, c.AuthorID)
+		}
+	}
+
+	var counts []struct {
+		AuthorID uuid.UUID
+		Count    int
+	}
+	if err := s.db.Raw(`
+		SELECT author_id, COUNT(*) AS count FROM (
+			SELECT posts.author_id FROM likes
+			JOIN posts ON posts.id = likes.post_id
+			WHERE likes.user_id = ? AND likes.created_at > ? AND likes.deleted_at IS NULL
+				AND posts.author_id IN ?
+			UNION ALL
+			SELECT parent.author_id FROM posts reply
+			JOIN posts parent ON parent.id = reply.parent_post_id
+			WHERE reply.author_id = ? AND reply.created_at > ? AND reply.deleted_at IS NULL
+				AND parent.author_id IN ?
+		) interactions
+		GROUP BY author_id
+	`, userID, since, authorIDs, userID, since, authorIDs).Scan(&counts).Error; err != nil {
+		return fmt.Errorf("failed to count interactions: %w", err)
+	}
+
+	interactions := make(map[uuid.UUID]int, len(counts))
+	for _, count := range counts {
+		interactions[count.AuthorID] = count.Count
+	}
+	for _, c := range candidates {
+		c.Interactions = interactions[c.AuthorID]
+	}
+
+	return nil
+}
@@ -12,12 +12,16 @@ import (
 type LikeService struct {
 	db                  *gorm.DB
 	notificationService *NotificationService
+	analyticsService    *AnalyticsService
+	broker              *EventBroker
 }
 
-func NewLikeService(db *gorm.DB, notificationService *NotificationService) *LikeService {
+func NewLikeService(db *gorm.DB, notificationService *NotificationService, analyticsService *AnalyticsService, broker *EventBroker) *LikeService {
 	return &LikeService{
 		db:                  db,
 		notificationService: notificationService,
+		analyticsService:    analyticsService,
+		broker:              broker,
 	}
 }
 
@@ -30,6 +34,16 @@ func (s *LikeService) LikePost(userID, postID uuid.UUID) error {
 		}
 		return fmt.Errorf("failed to find post: %w", err)
 	}
+	if blocked, err := isBlockedBetween(s.db, userID, post.AuthorID); err != nil {
+		return err
+	} else if blocked {
+		return errors.New("post not found")
+	}
+	if visible, err := canViewPosts(s.db, post.AuthorID, userID); err != nil {
+		return err
+	} else if !visible {
+		return errors.New("post not found")
+	}
 
 	// Check if already liked
 	var existingLike models.Like
@@ -51,12 +65,18 @@ func (s *LikeService) LikePost(userID, postID uuid.UUID) error {
 	if err := s.db.Model(&post).Update("likes_count", gorm.Expr("likes_count + 1")).Error; err != nil {
 		return fmt.Errorf("failed to update likes count: %w", err)
 	}
+	publishPostCounts(s.db, s.broker, postID)
 
 	// Create notification
 	if s.notificationService != nil {
 		s.notificationService.CreateLikeNotification(userID, postID)
 	}
 
+	// Record analytics event
+	if s.analyticsService != nil {
+		s.analyticsService.RecordEvent(models.AnalyticsEventLike, &userID, post.AuthorID, &postID)
+	}
+
 	return nil
 }
 
@@ -88,19 +108,24 @@ func (s *LikeService) UnlikePost(userID, postID uuid.UUID) error {
 	if err := s.db.Model(&post).Update("likes_count", gorm.Expr("likes_count - 1")).Error; err != nil {
 		return fmt.Errorf("failed to update likes count: %w", err)
 	}
+	publishPostCounts(s.db, s.broker, postID)
+
+	// Record analytics event
+	if s.analyticsService != nil {
+		s.analyticsService.RecordEvent(models.AnalyticsEventUnlike, &userID, post.AuthorID, &postID)
+	}
 
 	return nil
 }
 
-func (s *LikeService) GetPostLikes(postID uuid.UUID, limit, offset int) ([]models.UserPublic, error) {
-	var likes []models.Like
-	if err := s.db.Where("post_id = ?", postID).
-		Preload("User").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&likes).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch likes: %w", err)
+// GetPostLikes returns the users who liked the post, most recent like first
+func (s *LikeService) GetPostLikes(postID uuid.UUID, page PageRequest) ([]models.UserPublic, PageInfo, error) {
+	query := s.db.Where("post_id = ?", postID).
+		Preload("User")
+
+	likes, pageInfo, err := paginate(query, page, "likes", false, likeCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
 	var users []models.UserPublic
@@ -115,48 +140,45 @@ func (s *LikeService) GetPostLikes(postID uuid.UUID, limit, offset int) ([]model
 			Location:        like.User.Location,
 			Website:         like.User.Website,
 			IsVerified:      like.User.IsVerified,
+			IsPrivate:       like.User.IsPrivate,
 			CreatedAt:       like.User.CreatedAt,
 		}
 		users = append(users, userPublic)
 	}
 
-	return users, nil
+	return users, pageInfo, nil
 }
 
-func (s *LikeService) GetUserLikes(userID uuid.UUID, limit, offset int) ([]models.PostWithDetails, error) {
-	var likes []models.Like
-	if err := s.db.Where("user_id = ?", userID).
+// GetUserLikes returns the posts the user liked as seen by the viewer
+func (s *LikeService) GetUserLikes(userID, viewerID uuid.UUID, page PageRequest) ([]models.PostWithDetails, PageInfo, error) {
+	visible := filterBlockedPosts(s.db.Model(&models.Post{}).Select("posts.id"), viewerID)
+	visible = filterPrivatePosts(visible, viewerID)
+	query := s.db.Where("likes.user_id = ? AND likes.post_id IN (?)", userID, visible).
 		Preload("Post").
 		Preload("Post.Author").
 		Preload("Post.Media").
+		Preload("Post.Mentions").
+		Preload("Post.LinkCard").
 		Preload("Post.OriginalPost").
 		Preload("Post.OriginalPost.Author").
 		Preload("Post.ParentPost").
-		Preload("Post.ParentPost.Author").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&likes).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch user likes: %w", err)
+		Preload("Post.ParentPost.Author")
+
+	likes, pageInfo, err := paginate(query, page, "likes", false, likeCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
-	var posts []models.PostWithDetails
+	posts := make([]models.Post, 0, len(likes))
 	for _, like := range likes {
-		postWithDetails := models.PostWithDetails{
-			Post:     like.Post,
-			IsLiked:  true, // Obviously true since we're getting user's liked posts
-		}
-
-		// Check if user reposted this post
-		var repost models.Post
-		if err := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, like.Post.ID, models.PostTypeRepost).First(&repost).Error; err == nil {
-			postWithDetails.IsReposted = true
-		}
-
-		posts = append(posts, postWithDetails)
+		posts = append(posts, like.Post)
 	}
 
-	return posts, nil
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, viewerID)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+	return postsWithDetails, pageInfo, nil
 }
 
 func (s *LikeService) IsPostLikedByUser(userID, postID uuid.UUID) (bool, error) {
@@ -0,0 +1,723 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"digeon-backend/internal/models"
+	"digeon-backend/internal/text"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const linkPreviewUserAgent = "DigeonBot/1.0 (+link preview)"
+
+var errBlockedAddress = errors.New("destination address is not allowed")
+
+type LinkPreviewService struct {
+	db           *gorm.DB
+	mediaService *MediaService
+	client       *http.Client
+	timeout      time.Duration
+	cacheTTL     time.Duration
+	errorTTL     time.Duration
+	maxPageSize  int64
+	maxImageSize int64
+}
+
+// LinkPreviewOptions configures a LinkPreviewService. Zero values take the
+// defaults.
+type LinkPreviewOptions struct {
+	Timeout     time.Duration
+	CacheTTL    time.Duration
+	MaxPageSize int64
+	// Networks exempt from the private address check, such as an internal
+	// site that should get cards
+	AllowedNetworks []*net.IPNet
+	// Client used for every fetch instead of the address-checking one.
+	// Timeout still limits each request made with it.
+	Client *http.Client
+}
+
+func NewLinkPreviewService(db *gorm.DB, mediaService *MediaService) *LinkPreviewService {
+	options := LinkPreviewOptions{}
+	options.Timeout, _ = time.ParseDuration(os.Getenv("LINK_PREVIEW_TIMEOUT"))
+	options.CacheTTL, _ = time.ParseDuration(os.Getenv("LINK_PREVIEW_TTL"))
+	options.MaxPageSize, _ = strconv.ParseInt(os.Getenv("LINK_PREVIEW_MAX_BYTES"), 10, 64)
+	for _, cidr := range strings.Split(os.Getenv("LINK_PREVIEW_ALLOWED_NETWORKS"), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("Ignoring invalid LINK_PREVIEW_ALLOWED_NETWORKS entry %q: %v", cidr, err)
+			continue
+		}
+		options.AllowedNetworks = append(options.AllowedNetworks, network)
+	}
+
+	return NewLinkPreviewServiceWithOptions(db, mediaService, options)
+}
+
+func NewLinkPreviewServiceWithOptions(db *gorm.DB, mediaService *MediaService, options LinkPreviewOptions) *LinkPreviewService {
+	if options.Timeout <= 0 {
+		options.Timeout = 5 * time.Second
+	}
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = 24 * time.Hour
+	}
+	if options.MaxPageSize <= 0 {
+		options.MaxPageSize = 1024 * 1024 // 1MB
+	}
+
+	client := options.Client
+	if client == nil {
+		client = newSafeHTTPClient(options.Timeout, options.AllowedNetworks)
+	}
+
+	return &LinkPreviewService{
+		db:           db,
+		mediaService: mediaService,
+		client:       client,
+		timeout:      options.Timeout,
+		cacheTTL:     options.CacheTTL,
+		errorTTL:     time.Hour,
+		maxPageSize:  options.MaxPageSize,
+		maxImageSize: 5 * 1024 * 1024,
+	}
+}
+
+// newSafeHTTPClient returns a client that refuses to connect to private,
+// loopback and link-local addresses outside allowed. The check runs on the
+// resolved address at connect time, so it also covers redirects and DNS
+// rebinding.
+func newSafeHTTPClient(timeout time.Duration, allowed []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || (isBlockedIP(ip) && !containsIP(allowed, ip)) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("unsupported redirect scheme")
+			}
+			return nil
+		},
+	}
+}
+
+var blockedNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "this" network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved
+		"64:ff9b::/96",  // NAT64
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	return containsIP(blockedNetworks, ip)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnfurlPostAsync fetches the link card for a post in the background
+func (s *LinkPreviewService) UnfurlPostAsync(postID uuid.UUID, content string) {
+	go func() {
+		if err := s.UnfurlPost(postID, content); err != nil {
+			log.Printf("Link preview for post %s failed: %v", postID, err)
+		}
+	}()
+}
+
+// UnfurlPost attaches a card for the first URL in content to the post, or
+// detaches the current card if the content no longer contains a URL
+func (s *LinkPreviewService) UnfurlPost(postID uuid.UUID, content string) error {
+	urls := text.ExtractURLs(content)
+	if len(urls) == 0 {
+		return s.db.Model(&models.Post{}).Where("id = ?", postID).Update("link_card_id", nil).Error
+	}
+
+	card, err := s.GetCard(urls[0].Value)
+	if err != nil {
+		return err
+	}
+
+	var cardID *uuid.UUID
+	if card.FetchError == "" {
+		cardID = &card.ID
+	}
+	return s.db.Model(&models.Post{}).Where("id = ?", postID).Update("link_card_id", cardID).Error
+}
+
+// GetCard returns the cached card for rawURL, fetching it if the cache is
+// missing or expired. Failed fetches are cached for a shorter time so a
+// broken site is not hammered by every post that links to it.
+func (s *LinkPreviewService) GetCard(rawURL string) (*models.LinkCard, error) {
+	normalized, err := normalizeURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var card models.LinkCard
+	err = s.db.Where("url = ?", normalized).First(&card).Error
+	if err == nil && card.ExpiresAt.After(time.Now()) {
+		return &card, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find link card: %w", err)
+	}
+
+	card.URL = normalized
+	card.FetchError = ""
+	card.ExpiresAt = time.Now().Add(s.cacheTTL)
+	if fetchErr := s.fetchCard(&card); fetchErr != nil {
+		card.FetchError = truncate(fetchErr.Error(), 500)
+		card.ExpiresAt = time.Now().Add(s.errorTTL)
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "description", "site_name", "image_url", "media_id", "fetch_error", "expires_at", "updated_at"}),
+	}).Create(&card).Error; err != nil {
+		return nil, fmt.Errorf("failed to save link card: %w", err)
+	}
+
+	// Re-read so the ID is correct when another request created the row first
+	if err := s.db.Where("url = ?", normalized).First(&card).Error; err != nil {
+		return nil, fmt.Errorf("failed to find link card: %w", err)
+	}
+
+	return &card, nil
+}
+
+func (s *LinkPreviewService) fetchCard(card *models.LinkCard) error {
+	pageURL, err := url.Parse(card.URL)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := s.robotsAllowed(pageURL)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("disallowed by robots.txt")
+	}
+
+	resp, err := s.get(pageURL.String(), "text/html,application/xhtml+xml")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	meta := parseLinkMetadata(io.LimitReader(resp.Body, s.maxPageSize))
+
+	// Fall back to oEmbed when the page has no usable OpenGraph data
+	if meta.title == "" && meta.oembedURL != "" {
+		if oembedURL, err := resp.Request.URL.Parse(meta.oembedURL); err == nil {
+			s.applyOEmbed(&meta, oembedURL.String())
+		}
+	}
+
+	if meta.title == "" {
+		return errors.New("no preview metadata found")
+	}
+
+	card.Title = truncate(meta.title, 300)
+	card.Description = truncate(meta.description, 1000)
+	card.SiteName = truncate(meta.siteName, 200)
+	if card.SiteName == "" {
+		card.SiteName = resp.Request.URL.Hostname()
+	}
+
+	card.ImageURL = ""
+	card.MediaID = nil
+	if meta.image != "" {
+		if imageURL, err := resp.Request.URL.Parse(meta.image); err == nil {
+			if media, err := s.storeImage(imageURL); err == nil {
+				card.ImageURL = media.URL
+				card.MediaID = &media.ID
+			} else {
+				log.Printf("Link preview image %s failed: %v", imageURL, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *LinkPreviewService) applyOEmbed(meta *linkMetadata, oembedURL string) {
+	resp, err := s.get(oembedURL, "application/json")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var oembed struct {
+		Title        string `json:"title"`
+		AuthorName   string `json:"author_name"`
+		ProviderName string `json:"provider_name"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, s.maxPageSize)).Decode(&oembed); err != nil {
+		return
+	}
+
+	meta.title = oembed.Title
+	if meta.description == "" {
+		meta.description = oembed.AuthorName
+	}
+	if meta.siteName == "" {
+		meta.siteName = oembed.ProviderName
+	}
+	if meta.image == "" {
+		meta.image = oembed.ThumbnailURL
+	}
+}
+
+// storeImage downloads the preview image and stores it through MediaService
+// so cards never hotlink third-party images
+func (s *LinkPreviewService) storeImage(imageURL *url.URL) (*models.Media, error) {
+	if s.mediaService == nil {
+		return nil, errors.New("media service unavailable")
+	}
+	if imageURL.Scheme != "http" && imageURL.Scheme != "https" {
+		return nil, errors.New("unsupported image scheme")
+	}
+
+	resp, err := s.get(imageURL.String(), "image/*")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > s.maxImageSize {
+		return nil, errors.New("image too large")
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var ext string
+	switch contentType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/png":
+		ext = ".png"
+	case "image/gif":
+		ext = ".gif"
+	case "image/webp":
+		ext = ".webp"
+	default:
+		return nil, fmt.Errorf("unsupported image content type %q", contentType)
+	}
+
+	// Read one byte past the limit to detect oversized bodies without a Content-Length
+	body, err := io.ReadAll(io.LimitReader(resp.Body, s.maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > s.maxImageSize {
+		return nil, errors.New("image too large")
+	}
+
+	return s.mediaService.StoreImage(bytes.NewReader(body), "link-preview"+ext)
+}
+
+// robotsAllowed checks the site's robots.txt for our user agent. A missing
+// robots.txt allows everything.
+func (s *LinkPreviewService) robotsAllowed(pageURL *url.URL) (bool, error) {
+	robotsURL := &url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: "/robots.txt"}
+
+	resp, err := s.get(robotsURL.String(), "text/plain")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return false, fmt.Errorf("robots.txt unavailable (status %d)", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return true, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return false, err
+	}
+
+	requestPath := pageURL.EscapedPath()
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	if pageURL.RawQuery != "" {
+		requestPath += "?" + pageURL.RawQuery
+	}
+
+	return robotsAllows(string(body), "DigeonBot", requestPath), nil
+}
+
+func (s *LinkPreviewService) get(rawURL, accept string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("User-Agent", linkPreviewUserAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// robotsAllows evaluates robots.txt rules for agent using the longest
+// matching Allow/Disallow rule, falling back to the "*" group
+func robotsAllows(robots, agent, requestPath string) bool {
+	type rule struct {
+		allow   bool
+		pattern string
+	}
+
+	groups := make(map[string][]rule)
+	var current []string
+	inRules := false
+
+	for _, line := range strings.Split(robots, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				current = nil
+				inRules = false
+			}
+			current = append(current, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			for _, ua := range current {
+				groups[ua] = append(groups[ua], rule{allow: key == "allow", pattern: value})
+			}
+		}
+	}
+
+	rules, ok := groups[strings.ToLower(agent)]
+	if !ok {
+		rules = groups["*"]
+	}
+
+	matched := -1
+	allowed := true
+	for _, r := range rules {
+		if robotsPatternMatches(r.pattern, requestPath) && len(r.pattern) >= matched {
+			if len(r.pattern) > matched || r.allow {
+				allowed = r.allow
+			}
+			matched = len(r.pattern)
+		}
+	}
+	return allowed
+}
+
+// robotsPatternMatches supports the * wildcard and $ end anchor
+func robotsPatternMatches(pattern, requestPath string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(requestPath, parts[0]) {
+		return false
+	}
+	rest := requestPath[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	return !anchored || rest == "" || strings.HasSuffix(pattern, "*")
+}
+
+type linkMetadata struct {
+	title       string
+	description string
+	siteName    string
+	image       string
+	oembedURL   string
+}
+
+// parseLinkMetadata reads OpenGraph, Twitter card and basic HTML metadata
+// from the document head
+func parseLinkMetadata(r io.Reader) linkMetadata {
+	var meta, twitter, fallback linkMetadata
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return mergeLinkMetadata(meta, twitter, fallback)
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "head" {
+				return mergeLinkMetadata(meta, twitter, fallback)
+			}
+			if string(name) == "title" {
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle && fallback.title == "" {
+				fallback.title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				return mergeLinkMetadata(meta, twitter, fallback)
+			case "title":
+				inTitle = true
+			case "meta", "link":
+				attrs := make(map[string]string)
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+
+				if string(name) == "link" {
+					if strings.EqualFold(attrs["rel"], "alternate") && attrs["type"] == "application/json+oembed" {
+						fallback.oembedURL = attrs["href"]
+					}
+					continue
+				}
+
+				property := strings.ToLower(attrs["property"])
+				if property == "" {
+					property = strings.ToLower(attrs["name"])
+				}
+				content := strings.TrimSpace(html.UnescapeString(attrs["content"]))
+
+				switch property {
+				case "og:title":
+					meta.title = content
+				case "og:description":
+					meta.description = content
+				case "og:site_name":
+					meta.siteName = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if meta.image == "" {
+						meta.image = content
+					}
+				case "twitter:title":
+					twitter.title = content
+				case "twitter:description":
+					twitter.description = content
+				case "twitter:image", "twitter:image:src":
+					twitter.image = content
+				case "description":
+					fallback.description = content
+				}
+			}
+		}
+	}
+}
+
+// mergeLinkMetadata prefers OpenGraph, then Twitter card, then plain HTML values
+func mergeLinkMetadata(sources ...linkMetadata) linkMetadata {
+	var merged linkMetadata
+	for _, source := range sources {
+		if merged.title == "" {
+			merged.title = source.title
+		}
+		if merged.description == "" {
+			merged.description = source.description
+		}
+		if merged.siteName == "" {
+			merged.siteName = source.siteName
+		}
+		if merged.image == "" {
+			merged.image = source.image
+		}
+		if merged.oembedURL == "" {
+			merged.oembedURL = source.oembedURL
+		}
+	}
+	return merged
+}
+
+// normalizeURL canonicalizes a URL so equivalent links share one card:
+// lower-cased scheme and host, no default port, no fragment, no tracking
+// parameters and sorted query parameters
+func normalizeURL(rawURL string) (string, error) {
+	if strings.HasPrefix(strings.ToLower(rawURL), "www.") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("unsupported URL scheme")
+	}
+	if u.User != nil {
+		return "", errors.New("URLs with credentials are not supported")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", errors.New("invalid URL host")
+	}
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || lower == "fbclid" || lower == "gclid" {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	u.RawQuery = strings.Join(parts, "&")
+
+	return u.String(), nil
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
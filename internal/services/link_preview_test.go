@@ -0,0 +1,320 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestLinkPreviewService returns a service allowed to fetch from the
+// loopback test servers
+func newTestLinkPreviewService(options LinkPreviewOptions) *LinkPreviewService {
+	_, loopback4, _ := net.ParseCIDR("127.0.0.0/8")
+	_, loopback6, _ := net.ParseCIDR("::1/128")
+	options.AllowedNetworks = append(options.AllowedNetworks, loopback4, loopback6)
+	if options.Timeout == 0 {
+		options.Timeout = 2 * time.Second
+	}
+	return NewLinkPreviewServiceWithOptions(nil, nil, options)
+}
+
+// newTestSite serves robots.txt and HTML pages from paths
+func newTestSite(t *testing.T, robots string, pages map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(robots))
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseLinkMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want linkMetadata
+	}{
+		{
+			name: "OpenGraph",
+			html: `<html><head>
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content="OG description">
+				<meta property="og:site_name" content="Example">
+				<meta property="og:image" content="/image.png">
+				<meta property="og:image" content="/second.png">
+			</head></html>`,
+			want: linkMetadata{title: "OG title", description: "OG description", siteName: "Example", image: "/image.png"},
+		},
+		{
+			name: "Twitter card",
+			html: `<head>
+				<meta name="twitter:title" content="Card title">
+				<meta name="twitter:description" content="Card description">
+				<meta name="twitter:image:src" content="https://cdn.example.com/card.jpg">
+			</head>`,
+			want: linkMetadata{title: "Card title", description: "Card description", image: "https://cdn.example.com/card.jpg"},
+		},
+		{
+			name: "OpenGraph wins over Twitter card and HTML",
+			html: `<head>
+				<title>HTML title</title>
+				<meta name="description" content="HTML description">
+				<meta name="twitter:title" content="Card title">
+				<meta name="twitter:image" content="/card.jpg">
+				<meta property="og:title" content="OG title">
+			</head>`,
+			want: linkMetadata{title: "OG title", description: "HTML description", image: "/card.jpg"},
+		},
+		{
+			name: "plain HTML",
+			html: `<head><title>  Page title  </title><meta name="description" content="About &amp; more"></head>`,
+			want: linkMetadata{title: "Page title", description: "About & more"},
+		},
+		{
+			name: "oEmbed link",
+			html: `<head><link rel="alternate" type="application/json+oembed" href="/oembed?url=x"></head>`,
+			want: linkMetadata{oembedURL: "/oembed?url=x"},
+		},
+		{
+			name: "metadata in the body is ignored",
+			html: `<head><title>Head</title></head><body><meta property="og:title" content="Body"></body>`,
+			want: linkMetadata{title: "Head"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinkMetadata(strings.NewReader(tt.html)); got != tt.want {
+				t.Errorf("parseLinkMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotsAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		path   string
+		want   bool
+	}{
+		{"empty robots.txt", "", "/page", true},
+		{"disallow all", "User-agent: *\nDisallow: /", "/page", false},
+		{"empty disallow", "User-agent: *\nDisallow:", "/page", true},
+		{"other path", "User-agent: *\nDisallow: /private", "/public", true},
+		{"own group overrides *", "User-agent: *\nDisallow: /\n\nUser-agent: DigeonBot\nAllow: /", "/page", true},
+		{"own group disallows", "User-agent: *\nAllow: /\n\nUser-agent: digeonbot\nDisallow: /page", "/page", false},
+		{"longest match wins", "User-agent: *\nDisallow: /docs\nAllow: /docs/public", "/docs/public/a", true},
+		{"allow wins a tie", "User-agent: *\nDisallow: /page\nAllow: /page", "/page", true},
+		{"wildcard", "User-agent: *\nDisallow: /*.pdf", "/files/report.pdf", false},
+		{"end anchor", "User-agent: *\nDisallow: /*.pdf$", "/files/report.pdf?download=1", true},
+		{"comments", "User-agent: * # everyone\nDisallow: /page # not this", "/page", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := robotsAllows(tt.robots, "DigeonBot", tt.path); got != tt.want {
+				t.Errorf("robotsAllows(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchCard(t *testing.T) {
+	site := newTestSite(t, "User-agent: *\nDisallow: /private", map[string]string{
+		"/page": `<html><head>
+			<meta property="og:title" content="Hello">
+			<meta name="twitter:description" content="From the card">
+		</head><body></body></html>`,
+		"/private": `<head><meta property="og:title" content="Secret"></head>`,
+		"/empty":   `<head></head>`,
+	})
+	service := newTestLinkPreviewService(LinkPreviewOptions{})
+
+	card := &models.LinkCard{URL: site.URL + "/page"}
+	if err := service.fetchCard(card); err != nil {
+		t.Fatalf("fetchCard() error = %v", err)
+	}
+	if card.Title != "Hello" || card.Description != "From the card" || card.SiteName != "127.0.0.1" {
+		t.Errorf("card = %+v", card)
+	}
+
+	if err := service.fetchCard(&models.LinkCard{URL: site.URL + "/private"}); err == nil || err.Error() != "disallowed by robots.txt" {
+		t.Errorf("fetchCard() on a disallowed path error = %v", err)
+	}
+	if err := service.fetchCard(&models.LinkCard{URL: site.URL + "/empty"}); err == nil || err.Error() != "no preview metadata found" {
+		t.Errorf("fetchCard() without metadata error = %v", err)
+	}
+	if err := service.fetchCard(&models.LinkCard{URL: site.URL + "/missing"}); err == nil || err.Error() != "unexpected status 404" {
+		t.Errorf("fetchCard() on a missing page error = %v", err)
+	}
+}
+
+func TestFetchCardRobotsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<head><meta property="og:title" content="Hello"></head>`))
+	}))
+	defer server.Close()
+
+	err := newTestLinkPreviewService(LinkPreviewOptions{}).fetchCard(&models.LinkCard{URL: server.URL + "/page"})
+	if err == nil || !strings.Contains(err.Error(), "robots.txt unavailable") {
+		t.Errorf("fetchCard() error = %v, want robots.txt unavailable", err)
+	}
+}
+
+func TestFetchCardBodySizeCap(t *testing.T) {
+	padding := "<!--" + strings.Repeat("x", 2048) + "-->"
+	site := newTestSite(t, "", map[string]string{
+		"/page": `<html><head>` + padding + `<meta property="og:title" content="Too far"></head></html>`,
+	})
+
+	err := newTestLinkPreviewService(LinkPreviewOptions{MaxPageSize: 1024}).fetchCard(&models.LinkCard{URL: site.URL + "/page"})
+	if err == nil || err.Error() != "no preview metadata found" {
+		t.Errorf("fetchCard() past the size cap error = %v, want no preview metadata found", err)
+	}
+
+	card := &models.LinkCard{URL: site.URL + "/page"}
+	if err := newTestLinkPreviewService(LinkPreviewOptions{MaxPageSize: 4096}).fetchCard(card); err != nil || card.Title != "Too far" {
+		t.Errorf("fetchCard() within the size cap = %+v, %v", card, err)
+	}
+}
+
+func TestFetchCardTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	service := newTestLinkPreviewService(LinkPreviewOptions{Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	err := service.fetchCard(&models.LinkCard{URL: server.URL + "/slow"})
+	if err == nil {
+		t.Fatal("fetchCard() on a slow page succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("fetchCard() took %v, want about the 100ms timeout", elapsed)
+	}
+}
+
+func TestFetchCardWithClient(t *testing.T) {
+	site := newTestSite(t, "", map[string]string{
+		"/page": `<head><meta property="og:title" content="Hello"></head>`,
+	})
+
+	// A client without its own timeout still gets the service's
+	service := NewLinkPreviewServiceWithOptions(nil, nil, LinkPreviewOptions{Client: &http.Client{}})
+	card := &models.LinkCard{URL: site.URL + "/page"}
+	if err := service.fetchCard(card); err != nil || card.Title != "Hello" {
+		t.Errorf("fetchCard() = %+v, %v", card, err)
+	}
+}
+
+func TestFetchCardBlocksPrivateAddresses(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// No allowed networks, like production
+	service := NewLinkPreviewServiceWithOptions(nil, nil, LinkPreviewOptions{Timeout: time.Second})
+	err := service.fetchCard(&models.LinkCard{URL: server.URL + "/page"})
+	if !errors.Is(err, errBlockedAddress) {
+		t.Errorf("fetchCard() error = %v, want %v", err, errBlockedAddress)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("server received %d requests, want none", n)
+	}
+}
+
+func TestFetchCardBlocksRedirectToPrivateAddress(t *testing.T) {
+	var requests atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer internal.Close()
+
+	// Only the public-facing server is allowed; it redirects inwards
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, strings.Replace(internal.URL, "127.0.0.1", "[::1]", 1)+"/admin", http.StatusFound)
+	}))
+	defer public.Close()
+
+	_, loopback4, _ := net.ParseCIDR("127.0.0.0/8")
+	service := NewLinkPreviewServiceWithOptions(nil, nil, LinkPreviewOptions{
+		Timeout:         time.Second,
+		AllowedNetworks: []*net.IPNet{loopback4},
+	})
+	err := service.fetchCard(&models.LinkCard{URL: public.URL + "/page"})
+	if !errors.Is(err, errBlockedAddress) {
+		t.Errorf("fetchCard() error = %v, want %v", err, errBlockedAddress)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("internal server received %d requests, want none", n)
+	}
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fc00::1", true},
+		{"fe80::1", true},
+		{"64:ff9b::7f00:1", true},
+		{"93.184.216.34", false},
+		{"2606:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlockedIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isBlockedIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
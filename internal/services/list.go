@@ -0,0 +1,365 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxListNameLength        = 25
+	maxListDescriptionLength = 100
+)
+
+type ListService struct {
+	db *gorm.DB
+}
+
+func NewListService(db *gorm.DB) *ListService {
+	return &ListService{db: db}
+}
+
+type CreateListRequest struct {
+	Name        string `json:"name" validate:"required,max=25"`
+	Description string `json:"description" validate:"max=100"`
+	IsPrivate   bool   `json:"is_private"`
+}
+
+type UpdateListRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	IsPrivate   *bool   `json:"is_private,omitempty"`
+}
+
+type ListsResponse struct {
+	Lists []models.List `json:"lists"`
+	Limit int           `json:"limit"`
+	PageInfo
+}
+
+func (s *ListService) CreateList(ownerID uuid.UUID, req CreateListRequest) (*models.List, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateListFields(req.Name, req.Description); err != nil {
+		return nil, err
+	}
+
+	list := models.List{
+		OwnerID:     ownerID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsPrivate:   req.IsPrivate,
+	}
+
+	if err := s.db.Create(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+
+	return s.GetList(list.ID, ownerID)
+}
+
+// GetList returns a list if the viewer may see it. Private lists look
+// missing to everyone but their owner.
+func (s *ListService) GetList(listID, viewerID uuid.UUID) (*models.List, error) {
+	return findVisibleList(s.db, listID, viewerID)
+}
+
+func (s *ListService) UpdateList(listID, ownerID uuid.UUID, req UpdateListRequest) (*models.List, error) {
+	list, err := s.findOwnedList(listID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	name, description := list.Name, list.Description
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		description = *req.Description
+		updates["description"] = description
+	}
+	if req.IsPrivate != nil {
+		updates["is_private"] = *req.IsPrivate
+	}
+
+	if len(updates) == 0 {
+		return nil, errors.New("no valid fields to update")
+	}
+	if err := validateListFields(name, description); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(list).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update list: %w", err)
+	}
+
+	return s.GetList(listID, ownerID)
+}
+
+func (s *ListService) DeleteList(listID, ownerID uuid.UUID) error {
+	list, err := s.findOwnedList(listID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", listID).Delete(&models.ListMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete list members: %w", err)
+		}
+		if err := tx.Where("list_id = ?", listID).Delete(&models.ListSubscription{}).Error; err != nil {
+			return fmt.Errorf("failed to delete list subscriptions: %w", err)
+		}
+		if err := tx.Delete(list).Error; err != nil {
+			return fmt.Errorf("failed to delete list: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetUserLists returns the lists a user owns that the viewer may see
+func (s *ListService) GetUserLists(ownerID, viewerID uuid.UUID, page PageRequest) (*ListsResponse, error) {
+	query := s.db.Where("owner_id = ?", ownerID).Preload("Owner")
+	if ownerID != viewerID {
+		query = query.Where("is_private = false")
+	}
+
+	lists, pageInfo, err := paginate(query, page, "lists", false, listCursorKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListsResponse{
+		Lists:    lists,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
+	}, nil
+}
+
+// GetSubscribedLists returns the lists the user subscribes to, most recently
+// subscribed first. Lists that have since been made private are left out.
+func (s *ListService) GetSubscribedLists(userID uuid.UUID, page PageRequest) (*ListsResponse, error) {
+	query := s.db.Where("user_id = ?", userID).
+		Preload("List").
+		Preload("List.Owner")
+
+	subscriptions, pageInfo, err := paginate(query, page, "list_subscriptions", false, listSubscriptionCursorKey)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]models.List, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if subscription.List.ID == uuid.Nil || subscription.List.IsPrivate {
+			continue
+		}
+		lists = append(lists, subscription.List)
+	}
+
+	return &ListsResponse{
+		Lists:    lists,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
+	}, nil
+}
+
+func (s *ListService) AddMember(listID, ownerID, userID uuid.UUID) error {
+	if _, err := s.findOwnedList(listID, ownerID); err != nil {
+		return err
+	}
+
+	// Check if user exists
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// The unique index decides between concurrent requests
+		member := models.ListMember{
+			ListID: listID,
+			UserID: userID,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+		if result.Error != nil {
+			return fmt.Errorf("failed to add list member: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user already in list")
+		}
+
+		if err := tx.Model(&models.List{}).Where("id = ?", listID).
+			Update("members_count", gorm.Expr("members_count + 1")).Error; err != nil {
+			return fmt.Errorf("failed to update members count: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *ListService) RemoveMember(listID, ownerID, userID uuid.UUID) error {
+	if _, err := s.findOwnedList(listID, ownerID); err != nil {
+		return err
+	}
+
+	var member models.ListMember
+	if err := s.db.Where("list_id = ? AND user_id = ?", listID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("member not found")
+		}
+		return fmt.Errorf("failed to find list member: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&member).Error; err != nil {
+			return fmt.Errorf("failed to remove list member: %w", err)
+		}
+
+		if err := tx.Model(&models.List{}).Where("id = ?", listID).
+			Update("members_count", gorm.Expr("GREATEST(members_count - 1, 0)")).Error; err != nil {
+			return fmt.Errorf("failed to update members count: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *ListService) GetMembers(listID, viewerID uuid.UUID, page PageRequest) ([]models.UserPublic, PageInfo, error) {
+	if _, err := findVisibleList(s.db, listID, viewerID); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	query := s.db.Where("list_id = ?", listID).Preload("User")
+
+	members, pageInfo, err := paginate(query, page, "list_members", false, listMemberCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+
+	users := make([]models.UserPublic, 0, len(members))
+	for _, member := range members {
+		users = append(users, toUserPublic(member.User))
+	}
+
+	return users, pageInfo, nil
+}
+
+func (s *ListService) Subscribe(listID, userID uuid.UUID) error {
+	list, err := findVisibleList(s.db, listID, userID)
+	if err != nil {
+		return err
+	}
+	if list.OwnerID == userID {
+		return errors.New("cannot subscribe to your own list")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// The unique index decides between concurrent requests
+		subscription := models.ListSubscription{
+			ListID: listID,
+			UserID: userID,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&subscription)
+		if result.Error != nil {
+			return fmt.Errorf("failed to subscribe to list: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("already subscribed to list")
+		}
+
+		if err := tx.Model(&models.List{}).Where("id = ?", listID).
+			Update("subscribers_count", gorm.Expr("subscribers_count + 1")).Error; err != nil {
+			return fmt.Errorf("failed to update subscribers count: %w", err)
+		}
+		return nil
+	})
+}
+
+// Unsubscribe works even after the list was made private
+func (s *ListService) Unsubscribe(listID, userID uuid.UUID) error {
+	var subscription models.ListSubscription
+	if err := s.db.Where("list_id = ? AND user_id = ?", listID, userID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("subscription not found")
+		}
+		return fmt.Errorf("failed to find subscription: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&subscription).Error; err != nil {
+			return fmt.Errorf("failed to unsubscribe from list: %w", err)
+		}
+
+		if err := tx.Model(&models.List{}).Where("id = ?", listID).
+			Update("subscribers_count", gorm.Expr("GREATEST(subscribers_count - 1, 0)")).Error; err != nil {
+			return fmt.Errorf("failed to update subscribers count: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *ListService) findOwnedList(listID, ownerID uuid.UUID) (*models.List, error) {
+	var list models.List
+	if err := s.db.Where("id = ? AND owner_id = ?", listID, ownerID).First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("list not found or unauthorized")
+		}
+		return nil, fmt.Errorf("failed to find list: %w", err)
+	}
+	return &list, nil
+}
+
+// findVisibleList loads a list with its owner, treating other people's
+// private lists as missing
+func findVisibleList(db *gorm.DB, listID, viewerID uuid.UUID) (*models.List, error) {
+	var list models.List
+	if err := db.Preload("Owner").First(&list, listID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("list not found")
+		}
+		return nil, fmt.Errorf("failed to find list: %w", err)
+	}
+	if list.IsPrivate && list.OwnerID != viewerID {
+		return nil, errors.New("list not found")
+	}
+	return &list, nil
+}
+
+func validateListFields(name, description string) error {
+	if name == "" {
+		return errors.New("list name is required")
+	}
+	if utf8.RuneCountInString(name) > maxListNameLength {
+		return errors.New("list name exceeds 25 characters")
+	}
+	if utf8.RuneCountInString(description) > maxListDescriptionLength {
+		return errors.New("list description exceeds 100 characters")
+	}
+	return nil
+}
+
+func toUserPublic(user models.User) models.UserPublic {
+	return models.UserPublic{
+		ID:              user.ID,
+		Username:        user.Username,
+		DisplayName:     user.DisplayName,
+		Bio:             user.Bio,
+		ProfileImageURL: user.ProfileImageURL,
+		CoverImageURL:   user.CoverImageURL,
+		Location:        user.Location,
+		Website:         user.Website,
+		IsVerified:      user.IsVerified,
+		IsPrivate:       user.IsPrivate,
+		CreatedAt:       user.CreatedAt,
+		FollowersCount:  user.FollowersCount,
+		FollowingCount:  user.FollowingCount,
+		PostsCount:      user.PostsCount,
+	}
+}
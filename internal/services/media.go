@@ -165,6 +165,76 @@ func (s *MediaService) AttachMediaToPost(mediaIDs []string, postID uuid.UUID) er
 	return nil
 }
 
+// MarkMediaSensitive flags media already attached to the post as sensitive
+func (s *MediaService) MarkMediaSensitive(mediaIDs []string, postID uuid.UUID) error {
+	var ids []uuid.UUID
+	for _, mediaIDStr := range mediaIDs {
+		if mediaID, err := uuid.Parse(mediaIDStr); err == nil {
+			ids = append(ids, mediaID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.db.Model(&models.Media{}).
+		Where("id IN ? AND post_id = ?", ids, postID).
+		Update("is_sensitive", true).Error
+}
+
+// hasSensitiveMedia reports whether any media attached to the post is
+// flagged as sensitive
+func hasSensitiveMedia(db *gorm.DB, postID uuid.UUID) (bool, error) {
+	var count int64
+	if err := db.Model(&models.Media{}).
+		Where("post_id = ? AND is_sensitive = true", postID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check media: %w", err)
+	}
+	return count > 0, nil
+}
+
+// StoreImage saves an image fetched from elsewhere (e.g. a link preview) and
+// creates a media record for it. The caller is responsible for size limits.
+func (s *MediaService) StoreImage(src io.Reader, fileName string) (*models.Media, error) {
+	mediaType, err := s.getMediaType(fileName)
+	if err != nil || mediaType == models.MediaTypeVideo {
+		return nil, fmt.Errorf("unsupported image type: %s", filepath.Ext(fileName))
+	}
+
+	fileID := uuid.New()
+	storedName := fmt.Sprintf("%s%s", fileID.String(), strings.ToLower(filepath.Ext(fileName)))
+	filePath := filepath.Join(s.uploadDir, "images", storedName)
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, src)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	media := models.Media{
+		ID:       fileID,
+		Type:     mediaType,
+		URL:      fmt.Sprintf("%s/uploads/images/%s", s.baseURL, storedName),
+		FileName: fileName,
+		FileSize: size,
+	}
+
+	if err := s.db.Create(&media).Error; err != nil {
+		// Clean up file if database save fails
+		os.Remove(filePath)
+		return nil, fmt.Errorf("failed to save media record: %w", err)
+	}
+
+	return &media, nil
+}
+
 func (s *MediaService) GetMediaByID(mediaID uuid.UUID) (*models.Media, error) {
 	var media models.Media
 	if err := s.db.First(&media, mediaID).Error; err != nil {
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"digeon-backend/internal/text"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxMutedWordLength = 100
+
+type MuteService struct {
+	db *gorm.DB
+}
+
+func NewMuteService(db *gorm.DB) *MuteService {
+	return &MuteService{db: db}
+}
+
+type MuteUserRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type MuteWordRequest struct {
+	Word      string           `json:"word" validate:"required,max=100"`
+	Scope     models.MuteScope `json:"scope,omitempty"`
+	ExpiresAt *time.Time       `json:"expires_at,omitempty"`
+}
+
+type MutedUserResponse struct {
+	User      models.UserPublic `json:"user"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// MuteUser mutes a user until req.ExpiresAt, or indefinitely. Muting an
+// already muted user replaces the expiry.
+func (s *MuteService) MuteUser(userID, mutedUserID uuid.UUID, req MuteUserRequest) error {
+	if userID == mutedUserID {
+		return errors.New("cannot mute yourself")
+	}
+	if err := validateMuteExpiry(req.ExpiresAt); err != nil {
+		return err
+	}
+
+	// Check if user exists
+	var mutedUser models.User
+	if err := s.db.First(&mutedUser, mutedUserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	var mute models.MutedUser
+	err := s.db.Where("user_id = ? AND muted_user_id = ?", userID, mutedUserID).First(&mute).Error
+	if err == nil {
+		if err := s.db.Model(&mute).Update("expires_at", req.ExpiresAt).Error; err != nil {
+			return fmt.Errorf("failed to update mute: %w", err)
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to find mute: %w", err)
+	}
+
+	mute = models.MutedUser{
+		UserID:      userID,
+		MutedUserID: mutedUserID,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := s.db.Create(&mute).Error; err != nil {
+		return fmt.Errorf("failed to create mute: %w", err)
+	}
+
+	return nil
+}
+
+func (s *MuteService) UnmuteUser(userID, mutedUserID uuid.UUID) error {
+	var mute models.MutedUser
+	if err := s.db.Where("user_id = ? AND muted_user_id = ?", userID, mutedUserID).First(&mute).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("mute not found")
+		}
+		return fmt.Errorf("failed to find mute: %w", err)
+	}
+
+	if err := s.db.Delete(&mute).Error; err != nil {
+		return fmt.Errorf("failed to delete mute: %w", err)
+	}
+
+	return nil
+}
+
+// GetMutedUsers returns the users the user currently mutes, most recent first
+func (s *MuteService) GetMutedUsers(userID uuid.UUID, page PageRequest) ([]MutedUserResponse, PageInfo, error) {
+	query := s.db.Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Preload("MutedUser")
+
+	mutes, pageInfo, err := paginate(query, page, "muted_users", false, mutedUserCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+
+	responses := make([]MutedUserResponse, 0, len(mutes))
+	for _, mute := range mutes {
+		responses = append(responses, MutedUserResponse{
+			User:      toUserPublic(mute.MutedUser),
+			ExpiresAt: mute.ExpiresAt,
+			CreatedAt: mute.CreatedAt,
+		})
+	}
+
+	return responses, pageInfo, nil
+}
+
+// MuteWord mutes a word, or a hashtag when the word starts with #, within
+// req.Scope. Muting the same word again replaces its scope and expiry.
+func (s *MuteService) MuteWord(userID uuid.UUID, req MuteWordRequest) (*models.MutedWord, error) {
+	if req.Scope == "" {
+		req.Scope = models.MuteScopeAll
+	}
+	if !req.Scope.IsValid() {
+		return nil, errors.New("invalid scope")
+	}
+	if err := validateMuteExpiry(req.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	word := strings.TrimSpace(req.Word)
+	isHashtag := strings.HasPrefix(word, "#") || strings.HasPrefix(word, "＃")
+	pattern := ""
+	if isHashtag {
+		word = text.NormalizeHashtag(word)
+	} else {
+		word = strings.Join(strings.Fields(text.NormalizeMuteText(word)), " ")
+		pattern = text.MutePattern(word)
+	}
+	if word == "" {
+		return nil, errors.New("word is required")
+	}
+	if utf8.RuneCountInString(word) > maxMutedWordLength {
+		return nil, errors.New("word must be 100 characters or less")
+	}
+
+	var mutedWord models.MutedWord
+	err := s.db.Where("user_id = ? AND word = ? AND is_hashtag = ?", userID, word, isHashtag).First(&mutedWord).Error
+	if err == nil {
+		if err := s.db.Model(&mutedWord).Updates(map[string]interface{}{
+			"scope":      req.Scope,
+			"expires_at": req.ExpiresAt,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update muted word: %w", err)
+		}
+		mutedWord.Scope = req.Scope
+		mutedWord.ExpiresAt = req.ExpiresAt
+		return &mutedWord, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find muted word: %w", err)
+	}
+
+	mutedWord = models.MutedWord{
+		UserID:    userID,
+		Word:      word,
+		IsHashtag: isHashtag,
+		Pattern:   pattern,
+		Scope:     req.Scope,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.db.Create(&mutedWord).Error; err != nil {
+		return nil, fmt.Errorf("failed to create muted word: %w", err)
+	}
+
+	return &mutedWord, nil
+}
+
+// GetMutedWords returns the user's muted words that haven't expired
+func (s *MuteService) GetMutedWords(userID uuid.UUID) ([]models.MutedWord, error) {
+	mutedWords := []models.MutedWord{}
+	if err := s.db.Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&mutedWords).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch muted words: %w", err)
+	}
+	return mutedWords, nil
+}
+
+func (s *MuteService) UnmuteWord(userID, mutedWordID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", mutedWordID, userID).Delete(&models.MutedWord{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete muted word: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("muted word not found")
+	}
+	return nil
+}
+
+func validateMuteExpiry(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+	return nil
+}
+
+// excludeMutedUsers drops rows where column holds a user the viewer mutes.
+// column must be trusted SQL.
+func excludeMutedUsers(query *gorm.DB, viewerID uuid.UUID, column string) *gorm.DB {
+	if viewerID == uuid.Nil {
+		return query
+	}
+	return query.Where(`NOT EXISTS (
+		SELECT 1 FROM muted_users
+		WHERE muted_users.deleted_at IS NULL AND muted_users.user_id = ?
+			AND muted_users.muted_user_id = `+column+`
+			AND (muted_users.expires_at IS NULL OR muted_users.expires_at > NOW())
+	)`, viewerID)
+}
+
+// excludeMutedWords drops rows where postColumn holds a post matching one of
+// the viewer's muted words in scopes. Reposts and quotes are matched on the
+// original post too, and the viewer's own posts are never hidden. Content is
+// normalized the same way as text.NormalizeMuteText before matching.
+func excludeMutedWords(query *gorm.DB, viewerID uuid.UUID, postColumn string, scopes ...models.MuteScope) *gorm.DB {
+	if viewerID == uuid.Nil {
+		return query
+	}
+	return query.Where(`NOT EXISTS (
+		SELECT 1 FROM posts muted_post
+		JOIN muted_words ON muted_words.user_id = ? AND muted_words.deleted_at IS NULL
+			AND muted_words.scope IN ?
+			AND (muted_words.expires_at IS NULL OR muted_words.expires_at > NOW())
+		WHERE muted_post.id = `+postColumn+` AND muted_post.author_id <> ?
+			AND CASE WHEN muted_words.is_hashtag THEN EXISTS (
+				SELECT 1 FROM post_hashtags
+				JOIN hashtags ON hashtags.id = post_hashtags.hashtag_id
+				WHERE post_hashtags.post_id IN (muted_post.id, muted_post.original_post_id)
+					AND hashtags.name = muted_words.word
+			) ELSE LOWER(NORMALIZE(muted_post.content || ' ' || COALESCE(
+				(SELECT original.content FROM posts original WHERE original.id = muted_post.original_post_id), ''
+			), NFKC)) ~ muted_words.pattern END
+	)`, viewerID, scopes, viewerID)
+}
+
+// filterMutedPosts drops posts by users the viewer mutes, reposts and quotes
+// of their posts, and posts matching the viewer's muted words in scopes
+func filterMutedPosts(query *gorm.DB, viewerID uuid.UUID, scopes ...models.MuteScope) *gorm.DB {
+	query = excludeMutedUsers(query, viewerID, "posts.author_id")
+	query = excludeMutedUsers(query, viewerID, "(SELECT original.author_id FROM posts original WHERE original.id = posts.original_post_id)")
+	return excludeMutedWords(query, viewerID, "posts.id", scopes...)
+}
+
+// filterMutedNotifications drops notifications from users the viewer mutes
+// and about posts matching their muted words
+func filterMutedNotifications(query *gorm.DB, viewerID uuid.UUID) *gorm.DB {
+	query = excludeMutedUsers(query, viewerID, "notifications.actor_id")
+	return excludeMutedWords(query, viewerID, "notifications.post_id", models.MuteScopeNotifications, models.MuteScopeAll)
+}
+
+// isPostMuted reports whether filterMutedPosts would drop the post
+func isPostMuted(db *gorm.DB, viewerID, postID uuid.UUID, scopes ...models.MuteScope) (bool, error) {
+	var count int64
+	query := db.Model(&models.Post{}).Where("posts.id = ?", postID)
+	if err := filterMutedPosts(query, viewerID, scopes...).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check mutes: %w", err)
+	}
+	return count == 0, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"digeon-backend/internal/models"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -9,11 +10,12 @@ import (
 )
 
 type NotificationService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	broker *EventBroker
 }
 
-func NewNotificationService(db *gorm.DB) *NotificationService {
-	return &NotificationService{db: db}
+func NewNotificationService(db *gorm.DB, broker *EventBroker) *NotificationService {
+	return &NotificationService{db: db, broker: broker}
 }
 
 type NotificationResponse struct {
@@ -48,7 +50,30 @@ func (s *NotificationService) CreateFollowNotification(actorID, userID uuid.UUID
 		IsRead:  false,
 	}
 
-	return s.db.Create(&notification).Error
+	return s.create(&notification)
+}
+
+// CreateFollowRequestNotification creates a notification when someone asks
+// to follow a private account. Asking again, such as after cancelling,
+// doesn't add another while the first is still unread.
+func (s *NotificationService) CreateFollowRequestNotification(actorID, userID uuid.UUID) error {
+	var existingNotification models.Notification
+	if err := s.db.Where("user_id = ? AND actor_id = ? AND type = ? AND is_read = false",
+		userID, actorID, models.NotificationTypeFollowRequest).First(&existingNotification).Error; err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	notification := models.Notification{
+		UserID:  userID,
+		ActorID: actorID,
+		Type:    models.NotificationTypeFollowRequest,
+		Message: "requested to follow you",
+		IsRead:  false,
+	}
+
+	return s.create(&notification)
 }
 
 // CreateLikeNotification creates a notification when someone likes a post
@@ -80,7 +105,41 @@ func (s *NotificationService) CreateLikeNotification(actorID, postID uuid.UUID)
 		IsRead:  false,
 	}
 
-	return s.db.Create(&notification).Error
+	return s.create(&notification)
+}
+
+// CreateReactionNotification creates a notification when someone reacts to a post
+func (s *NotificationService) CreateReactionNotification(actorID, postID uuid.UUID, emoji string) error {
+	// Get post owner
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		return err
+	}
+
+	// Don't create notification if user reacts to their own post
+	if actorID == post.AuthorID {
+		return nil
+	}
+
+	message := fmt.Sprintf("reacted %s to your post", emoji)
+
+	// Check if notification already exists
+	var existingNotification models.Notification
+	if err := s.db.Where("user_id = ? AND actor_id = ? AND post_id = ? AND type = ? AND message = ?",
+		post.AuthorID, actorID, postID, models.NotificationTypeReaction, message).First(&existingNotification).Error; err == nil {
+		return nil // Notification already exists
+	}
+
+	notification := models.Notification{
+		UserID:  post.AuthorID,
+		ActorID: actorID,
+		Type:    models.NotificationTypeReaction,
+		PostID:  &postID,
+		Message: message,
+		IsRead:  false,
+	}
+
+	return s.create(&notification)
 }
 
 // CreateCommentNotification creates a notification when someone comments on a post
@@ -105,7 +164,7 @@ func (s *NotificationService) CreateCommentNotification(actorID, postID uuid.UUI
 		IsRead:  false,
 	}
 
-	return s.db.Create(&notification).Error
+	return s.create(&notification)
 }
 
 // CreateRepostNotification creates a notification when someone reposts a post
@@ -130,7 +189,7 @@ func (s *NotificationService) CreateRepostNotification(actorID, postID uuid.UUID
 		IsRead:  false,
 	}
 
-	return s.db.Create(&notification).Error
+	return s.create(&notification)
 }
 
 // CreateQuoteNotification creates a notification when someone quotes a post
@@ -155,58 +214,143 @@ func (s *NotificationService) CreateQuoteNotification(actorID, postID uuid.UUID)
 		IsRead:  false,
 	}
 
-	return s.db.Create(&notification).Error
+	return s.create(&notification)
+}
+
+// CreateMentionNotification creates a notification when someone mentions a user in a post
+func (s *NotificationService) CreateMentionNotification(actorID, userID, postID uuid.UUID) error {
+	// Don't create notification if user mentions themselves
+	if actorID == userID {
+		return nil
+	}
+
+	// Check if notification already exists
+	var existingNotification models.Notification
+	if err := s.db.Where("user_id = ? AND actor_id = ? AND post_id = ? AND type = ?",
+		userID, actorID, postID, models.NotificationTypeMention).First(&existingNotification).Error; err == nil {
+		return nil // Notification already exists
+	}
+
+	notification := models.Notification{
+		UserID:  userID,
+		ActorID: actorID,
+		Type:    models.NotificationTypeMention,
+		PostID:  &postID,
+		Message: "mentioned you in a post",
+		IsRead:  false,
+	}
+
+	return s.create(&notification)
+}
+
+// CreateSavedSearchNotification creates a notification when a new post
+// matches a saved search. The post's author is the actor.
+func (s *NotificationService) CreateSavedSearchNotification(savedSearch *models.SavedSearch, post *models.Post) error {
+	notification := models.Notification{
+		UserID:  savedSearch.UserID,
+		ActorID: post.AuthorID,
+		Type:    models.NotificationTypeSavedSearch,
+		PostID:  &post.ID,
+		Message: fmt.Sprintf("posted something matching your saved search %q", savedSearch.Name),
+		IsRead:  false,
+	}
+
+	return s.create(&notification)
+}
+
+// create stores a notification and pushes it to the recipient's streams.
+// Nothing is sent between users in a block.
+func (s *NotificationService) create(notification *models.Notification) error {
+	if blocked, err := isBlockedBetween(s.db, notification.ActorID, notification.UserID); err != nil || blocked {
+		return err
+	}
+
+	if err := s.db.Create(notification).Error; err != nil {
+		return err
+	}
+
+	s.broker.Publish(Event{
+		Type:           EventTypeNotification,
+		UserID:         notification.UserID,
+		NotificationID: notification.ID,
+	})
+	return nil
 }
 
 // GetNotifications gets notifications for a user
-func (s *NotificationService) GetNotifications(userID uuid.UUID, limit, offset int) ([]NotificationResponse, error) {
-	var notifications []models.Notification
-	if err := s.db.Where("user_id = ?", userID).
+func (s *NotificationService) GetNotifications(userID uuid.UUID, page PageRequest) ([]NotificationResponse, PageInfo, error) {
+	query := s.db.Where("user_id = ?", userID).
 		Preload("Actor").
-		Preload("Post").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&notifications).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+		Preload("Post")
+	query = excludeBlocked(query, userID, "notifications.actor_id")
+	query = filterMutedNotifications(query, userID)
+
+	notifications, pageInfo, err := paginate(query, page, "notifications", false, notificationCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
 	var responses []NotificationResponse
 	for _, notification := range notifications {
-		response := NotificationResponse{
-			ID:      notification.ID,
-			Type:    notification.Type,
-			Message: notification.Message,
-			IsRead:  notification.IsRead,
-			Actor: models.UserPublic{
-				ID:              notification.Actor.ID,
-				Username:        notification.Actor.Username,
-				DisplayName:     notification.Actor.DisplayName,
-				Bio:             notification.Actor.Bio,
-				ProfileImageURL: notification.Actor.ProfileImageURL,
-				CoverImageURL:   notification.Actor.CoverImageURL,
-				Location:        notification.Actor.Location,
-				Website:         notification.Actor.Website,
-				IsVerified:      notification.Actor.IsVerified,
-				CreatedAt:       notification.Actor.CreatedAt,
-			},
-			CreatedAt: notification.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
+		responses = append(responses, toNotificationResponse(notification))
+	}
+
+	return responses, pageInfo, nil
+}
 
-		if notification.Post != nil {
-			response.Post = notification.Post
+// GetNotification gets a single notification belonging to the user
+func (s *NotificationService) GetNotification(notificationID, userID uuid.UUID) (*NotificationResponse, error) {
+	var notification models.Notification
+	query := filterMutedNotifications(s.db.Where("id = ? AND user_id = ?", notificationID, userID), userID)
+	if err := query.
+		Preload("Actor").
+		Preload("Post").
+		First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("notification not found")
 		}
+		return nil, fmt.Errorf("failed to find notification: %w", err)
+	}
 
-		responses = append(responses, response)
+	response := toNotificationResponse(notification)
+	return &response, nil
+}
+
+func toNotificationResponse(notification models.Notification) NotificationResponse {
+	response := NotificationResponse{
+		ID:      notification.ID,
+		Type:    notification.Type,
+		Message: notification.Message,
+		IsRead:  notification.IsRead,
+		Actor: models.UserPublic{
+			ID:              notification.Actor.ID,
+			Username:        notification.Actor.Username,
+			DisplayName:     notification.Actor.DisplayName,
+			Bio:             notification.Actor.Bio,
+			ProfileImageURL: notification.Actor.ProfileImageURL,
+			CoverImageURL:   notification.Actor.CoverImageURL,
+			Location:        notification.Actor.Location,
+			Website:         notification.Actor.Website,
+			IsVerified:      notification.Actor.IsVerified,
+			IsPrivate:       notification.Actor.IsPrivate,
+			CreatedAt:       notification.Actor.CreatedAt,
+		},
+		CreatedAt: notification.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 
-	return responses, nil
+	if notification.Post != nil {
+		response.Post = notification.Post
+	}
+
+	return response
 }
 
 // GetUnreadNotificationsCount gets the count of unread notifications for a user
 func (s *NotificationService) GetUnreadNotificationsCount(userID uuid.UUID) (int64, error) {
 	var count int64
-	if err := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = false", userID).Count(&count).Error; err != nil {
+	query := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = false", userID)
+	query = excludeBlocked(query, userID, "notifications.actor_id")
+	if err := filterMutedNotifications(query, userID).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
 	}
 	return count, nil
@@ -226,6 +370,7 @@ func (s *NotificationService) MarkNotificationAsRead(notificationID, userID uuid
 		return fmt.Errorf("notification not found or unauthorized")
 	}
 
+	s.publishRead(userID)
 	return nil
 }
 
@@ -236,6 +381,8 @@ func (s *NotificationService) MarkAllNotificationsAsRead(userID uuid.UUID) error
 		Update("is_read", true).Error; err != nil {
 		return fmt.Errorf("failed to mark all notifications as read: %w", err)
 	}
+
+	s.publishRead(userID)
 	return nil
 }
 
@@ -251,6 +398,7 @@ func (s *NotificationService) DeleteNotification(notificationID, userID uuid.UUI
 		return fmt.Errorf("notification not found or unauthorized")
 	}
 
+	s.publishRead(userID)
 	return nil
 }
 
@@ -259,5 +407,12 @@ func (s *NotificationService) DeleteAllNotifications(userID uuid.UUID) error {
 	if err := s.db.Where("user_id = ?", userID).Delete(&models.Notification{}).Error; err != nil {
 		return fmt.Errorf("failed to delete all notifications: %w", err)
 	}
+
+	s.publishRead(userID)
 	return nil
+}
+
+// publishRead tells the user's streams that their unread count changed
+func (s *NotificationService) publishRead(userID uuid.UUID) {
+	s.broker.Publish(Event{Type: EventTypeNotificationsRead, UserID: userID})
 }
\ No newline at end of file
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PageRequest describes one page of a cursor-paginated listing. An empty
+// Cursor starts from the newest item (or the oldest, for listings that are
+// sorted oldest first).
+type PageRequest struct {
+	Limit        int
+	Cursor       string
+	IncludeTotal bool
+}
+
+// PageInfo holds the cursors for the pages either side of the current one.
+// NextCursor continues in listing order and is empty on the last page;
+// PrevCursor goes back towards the start and can be used to poll for items
+// added since the page was fetched. Total is only set when requested.
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+type cursorDirection string
+
+const (
+	cursorNext cursorDirection = "next"
+	cursorPrev cursorDirection = "prev"
+)
+
+// cursor is the decoded form of the opaque cursor strings handed to clients
+type cursor struct {
+	CreatedAt time.Time       `json:"t"`
+	ID        uuid.UUID       `json:"id"`
+	Direction cursorDirection `json:"d"`
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID, direction cursorDirection) string {
+	data, _ := json.Marshal(cursor{CreatedAt: createdAt, ID: id, Direction: direction})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+	if c.Direction != cursorNext && c.Direction != cursorPrev {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &c, nil
+}
+
+// rankCursor is the decoded form of cursors for listings ordered by a
+// relevance rank, such as post search
+type rankCursor struct {
+	Rank      float32   `json:"r"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func encodeRankCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	data, _ := json.Marshal(rankCursor{Rank: rank, CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeRankCursor(value string) (*rankCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c rankCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &c, nil
+}
+
+// paginate fetches one page of query using (created_at, id) keyset
+// pagination on table. Listings are newest first unless ascending is set.
+// key returns the created_at and id of an item so cursors can be built
+// from the page's first and last rows.
+func paginate[T any](query *gorm.DB, page PageRequest, table string, ascending bool, key func(*T) (time.Time, uuid.UUID)) ([]T, PageInfo, error) {
+	var info PageInfo
+
+	c, err := decodePageCursor(page)
+	if err != nil {
+		return nil, info, err
+	}
+
+	if page.IncludeTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&total).Error; err != nil {
+			return nil, info, fmt.Errorf("failed to count items: %w", err)
+		}
+		info.Total = &total
+	}
+
+	// Going back towards the start scans in the opposite order; the page
+	// is reversed afterwards so items always come back in listing order
+	scanAscending := ascending == isForward(c)
+
+	comparison, order := "<", "DESC"
+	if scanAscending {
+		comparison, order = ">", "ASC"
+	}
+
+	if c != nil {
+		query = query.Where(fmt.Sprintf("(%s.created_at, %s.id) %s (?, ?)", table, table, comparison), c.CreatedAt, c.ID)
+	}
+
+	var items []T
+	if err := query.
+		Order(fmt.Sprintf("%s.created_at %s, %s.id %s", table, order, table, order)).
+		Limit(page.Limit + 1).
+		Find(&items).Error; err != nil {
+		return nil, info, fmt.Errorf("failed to fetch items: %w", err)
+	}
+
+	hasMore := len(items) > page.Limit
+	if hasMore {
+		items = items[:page.Limit]
+	}
+
+	items, cursors := finishPage(items, page, c, hasMore, key)
+	cursors.Total = info.Total
+	return items, cursors, nil
+}
+
+// decodePageCursor returns the page's decoded cursor, or nil on the first page
+func decodePageCursor(page PageRequest) (*cursor, error) {
+	if page.Cursor == "" {
+		return nil, nil
+	}
+	return decodeCursor(page.Cursor)
+}
+
+// isForward reports whether c continues in listing order
+func isForward(c *cursor) bool {
+	return c == nil || c.Direction == cursorNext
+}
+
+// finishPage takes at most page.Limit items in scan order, puts them back
+// in listing order and builds the cursors either side of them. hasMore
+// reports whether the scan stopped before running out of items.
+func finishPage[T any](items []T, page PageRequest, c *cursor, hasMore bool, key func(*T) (time.Time, uuid.UUID)) ([]T, PageInfo) {
+	var info PageInfo
+
+	forward := isForward(c)
+	if !forward {
+		slices.Reverse(items)
+	}
+
+	if len(items) == 0 {
+		// Nothing newer yet; keep polling from the same position
+		if c != nil && !forward {
+			info.PrevCursor = page.Cursor
+		}
+		return items, info
+	}
+
+	firstCreatedAt, firstID := key(&items[0])
+	lastCreatedAt, lastID := key(&items[len(items)-1])
+
+	info.PrevCursor = encodeCursor(firstCreatedAt, firstID, cursorPrev)
+	if !forward || hasMore {
+		info.NextCursor = encodeCursor(lastCreatedAt, lastID, cursorNext)
+	}
+
+	return items, info
+}
+
+func postCursorKey(post *models.Post) (time.Time, uuid.UUID) {
+	return post.CreatedAt, post.ID
+}
+
+func followCursorKey(follow *models.Follow) (time.Time, uuid.UUID) {
+	return follow.CreatedAt, follow.ID
+}
+
+func notificationCursorKey(notification *models.Notification) (time.Time, uuid.UUID) {
+	return notification.CreatedAt, notification.ID
+}
+
+func bookmarkCursorKey(bookmark *models.Bookmark) (time.Time, uuid.UUID) {
+	return bookmark.CreatedAt, bookmark.ID
+}
+
+func listCursorKey(list *models.List) (time.Time, uuid.UUID) {
+	return list.CreatedAt, list.ID
+}
+
+func listMemberCursorKey(member *models.ListMember) (time.Time, uuid.UUID) {
+	return member.CreatedAt, member.ID
+}
+
+func listSubscriptionCursorKey(subscription *models.ListSubscription) (time.Time, uuid.UUID) {
+	return subscription.CreatedAt, subscription.ID
+}
+
+func blockCursorKey(block *models.Block) (time.Time, uuid.UUID) {
+	return block.CreatedAt, block.ID
+}
+
+func mutedUserCursorKey(mute *models.MutedUser) (time.Time, uuid.UUID) {
+	return mute.CreatedAt, mute.ID
+}
+
+func followRequestCursorKey(request *models.FollowRequest) (time.Time, uuid.UUID) {
+	return request.CreatedAt, request.ID
+}
+
+func likeCursorKey(like *models.Like) (time.Time, uuid.UUID) {
+	return like.CreatedAt, like.ID
+}
+
+func reactionCursorKey(reaction *models.Reaction) (time.Time, uuid.UUID) {
+	return reaction.CreatedAt, reaction.ID
+}
@@ -2,24 +2,37 @@ package services
 
 import (
 	"digeon-backend/internal/models"
+	"digeon-backend/internal/text"
 	"errors"
 	"fmt"
-	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const maxContentWarningLength = 200
+
 type PostService struct {
-	db           *gorm.DB
-	mediaService *MediaService
+	db                  *gorm.DB
+	mediaService        *MediaService
+	notificationService *NotificationService
+	analyticsService    *AnalyticsService
+	linkPreviewService  *LinkPreviewService
+	timelineService     *TimelineService
+	broker              *EventBroker
 }
 
-func NewPostService(db *gorm.DB, mediaService *MediaService) *PostService {
+func NewPostService(db *gorm.DB, mediaService *MediaService, notificationService *NotificationService, analyticsService *AnalyticsService, linkPreviewService *LinkPreviewService, timelineService *TimelineService, broker *EventBroker) *PostService {
 	return &PostService{
-		db:           db,
-		mediaService: mediaService,
+		db:                  db,
+		mediaService:        mediaService,
+		notificationService: notificationService,
+		analyticsService:    analyticsService,
+		linkPreviewService:  linkPreviewService,
+		timelineService:     timelineService,
+		broker:              broker,
 	}
 }
 
@@ -30,25 +43,55 @@ type CreatePostRequest struct {
 	ParentPostID   *string   `json:"parent_post_id,omitempty"`
 	MediaURLs      []string  `json:"media_urls,omitempty"`
 	IsDraft        bool      `json:"is_draft"`
+	IsSensitive    bool      `json:"is_sensitive"`
+	ContentWarning string    `json:"content_warning,omitempty"`
+	// Subset of MediaURLs to mark as sensitive
+	SensitiveMediaURLs []string `json:"sensitive_media_urls,omitempty"`
+	// Language of the content as a BCP 47 tag; guessed when omitted
+	Lang string `json:"lang,omitempty"`
 }
 
 type UpdatePostRequest struct {
-	Content string `json:"content" validate:"max=280"`
+	Content        string  `json:"content" validate:"max=280"`
+	IsSensitive    *bool   `json:"is_sensitive,omitempty"`
+	ContentWarning *string `json:"content_warning,omitempty"`
 }
 
 func (s *PostService) CreatePost(userID uuid.UUID, req CreatePostRequest) (*models.PostWithDetails, error) {
+	// Normalize so stored entity offsets match the content clients receive
+	req.Content = text.Normalize(req.Content)
+
 	// Validate input
 	if err := s.validateCreatePostRequest(req); err != nil {
 		return nil, err
 	}
 
+	// Reposts go through the same idempotent path as the repost endpoint
+	if req.Type == string(models.PostTypeRepost) {
+		originalID, err := uuid.Parse(*req.OriginalPostID)
+		if err != nil {
+			return nil, errors.New("invalid original post ID")
+		}
+		repost, err := s.createRepost(userID, originalID)
+		if err != nil {
+			return nil, err
+		}
+		return s.GetPostWithDetails(repost.ID, userID)
+	}
+
 	// Create post
+	req.ContentWarning = strings.TrimSpace(req.ContentWarning)
 	post := models.Post{
-		AuthorID: userID,
-		Content:  req.Content,
-		Type:     models.PostType(req.Type),
-		IsDraft:  req.IsDraft,
-		IsPublic: true,
+		AuthorID:       userID,
+		Content:        req.Content,
+		Type:           models.PostType(req.Type),
+		IsDraft:        req.IsDraft,
+		IsPublic:       true,
+		IsSensitive:    req.IsSensitive || req.ContentWarning != "",
+		ContentWarning: req.ContentWarning,
+	}
+	if req.Lang != "" {
+		post.Lang, _ = text.NormalizeLanguage(req.Lang)
 	}
 
 	// Handle original post reference (for reposts and quotes)
@@ -63,6 +106,16 @@ func (s *PostService) CreatePost(userID uuid.UUID, req CreatePostRequest) (*mode
 		if err := s.db.First(&originalPost, originalID).Error; err != nil {
 			return nil, errors.New("original post not found")
 		}
+		if blocked, err := isBlockedBetween(s.db, userID, originalPost.AuthorID); err != nil {
+			return nil, err
+		} else if blocked {
+			return nil, errors.New("original post not found")
+		}
+		if visible, err := canViewPosts(s.db, originalPost.AuthorID, userID); err != nil {
+			return nil, err
+		} else if !visible {
+			return nil, errors.New("original post not found")
+		}
 		
 		post.OriginalPostID = &originalID
 	}
@@ -79,13 +132,35 @@ func (s *PostService) CreatePost(userID uuid.UUID, req CreatePostRequest) (*mode
 		if err := s.db.First(&parentPost, parentID).Error; err != nil {
 			return nil, errors.New("parent post not found")
 		}
+		if blocked, err := isBlockedBetween(s.db, userID, parentPost.AuthorID); err != nil {
+			return nil, err
+		} else if blocked {
+			return nil, errors.New("parent post not found")
+		}
+		if visible, err := canViewPosts(s.db, parentPost.AuthorID, userID); err != nil {
+			return nil, err
+		} else if !visible {
+			return nil, errors.New("parent post not found")
+		}
 		
 		post.ParentPostID = &parentID
 	}
 
-	// Save post
-	if err := s.db.Create(&post).Error; err != nil {
-		return nil, fmt.Errorf("failed to create post: %w", err)
+	// Save post, updating the author's and parent's counters with it
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return fmt.Errorf("failed to create post: %w", err)
+		}
+		if post.ParentPostID != nil {
+			if err := tx.Model(&models.Post{}).Where("id = ?", *post.ParentPostID).
+				Update("comments_count", gorm.Expr("comments_count + 1")).Error; err != nil {
+				return fmt.Errorf("failed to update comments count: %w", err)
+			}
+		}
+		return adjustPostsCount(tx, userID, 1)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Process hashtags
@@ -93,32 +168,222 @@ func (s *PostService) CreatePost(userID uuid.UUID, req CreatePostRequest) (*mode
 		return nil, fmt.Errorf("failed to process hashtags: %w", err)
 	}
 
+	// Process mentions
+	if err := s.processMentions(&post, req.Content); err != nil {
+		return nil, fmt.Errorf("failed to process mentions: %w", err)
+	}
+
 	// Attach media if provided
 	if len(req.MediaURLs) > 0 && s.mediaService != nil {
 		if err := s.mediaService.AttachMediaToPost(req.MediaURLs, post.ID); err != nil {
 			return nil, fmt.Errorf("failed to attach media: %w", err)
 		}
+		if err := s.mediaService.MarkMediaSensitive(req.SensitiveMediaURLs, post.ID); err != nil {
+			return nil, fmt.Errorf("failed to mark media as sensitive: %w", err)
+		}
+
+		// Only media that were actually attached flag the post
+		if !post.IsSensitive {
+			hasSensitive, err := hasSensitiveMedia(s.db, post.ID)
+			if err != nil {
+				return nil, err
+			}
+			if hasSensitive {
+				if err := s.db.Model(&post).UpdateColumn("is_sensitive", true).Error; err != nil {
+					return nil, fmt.Errorf("failed to mark post as sensitive: %w", err)
+				}
+			}
+		}
 	}
 
-	// Update parent post comment count if this is a reply
-	if req.ParentPostID != nil {
-		parentID, _ := uuid.Parse(*req.ParentPostID)
-		s.db.Model(&models.Post{}).Where("id = ?", parentID).Update("comments_count", gorm.Expr("comments_count + 1"))
+	// Notify the quoted post's author
+	if post.Type == models.PostTypeQuote && !post.IsDraft && s.notificationService != nil {
+		s.notificationService.CreateQuoteNotification(userID, *post.OriginalPostID)
+	}
+
+	// Push the post onto followers' home timelines and open streams
+	if !post.IsDraft {
+		if s.timelineService != nil {
+			s.timelineService.DistributePostAsync(post.ID)
+		}
+		s.broker.Publish(Event{Type: EventTypeTimelinePost, PostID: post.ID, AuthorID: post.AuthorID})
+	}
+
+	// Fetch the link preview in the background
+	if s.linkPreviewService != nil {
+		s.linkPreviewService.UnfurlPostAsync(post.ID, post.Content)
 	}
 
-	// Update original post repost count if this is a repost
-	if req.OriginalPostID != nil && req.Type == string(models.PostTypeRepost) {
-		originalID, _ := uuid.Parse(*req.OriginalPostID)
-		s.db.Model(&models.Post{}).Where("id = ?", originalID).Update("reposts_count", gorm.Expr("reposts_count + 1"))
+	// Record analytics events against the referenced post
+	if s.analyticsService != nil && !post.IsDraft {
+		if post.ParentPostID != nil {
+			s.analyticsService.RecordPostEvent(models.AnalyticsEventReply, userID, *post.ParentPostID)
+		}
+		if post.OriginalPostID != nil && post.Type == models.PostTypeQuote {
+			s.analyticsService.RecordPostEvent(models.AnalyticsEventQuote, userID, *post.OriginalPostID)
+		}
 	}
 
 	// Return post with details
 	return s.GetPostWithDetails(post.ID, userID)
 }
 
+// Repost reposts a post for the user. Reposting the same post twice is a
+// no-op, and reposting a repost reposts the original instead.
+func (s *PostService) Repost(userID, postID uuid.UUID) (*models.PostWithDetails, error) {
+	repost, err := s.createRepost(userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPostWithDetails(*repost.OriginalPostID, userID)
+}
+
+// UndoRepost removes the user's repost of a post. Undoing a repost that
+// doesn't exist is a no-op.
+func (s *PostService) UndoRepost(userID, postID uuid.UUID) error {
+	var original models.Post
+	if err := s.db.First(&original, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("post not found")
+		}
+		return fmt.Errorf("failed to find post: %w", err)
+	}
+	if original.Type == models.PostTypeRepost && original.OriginalPostID != nil {
+		postID = *original.OriginalPostID
+	}
+
+	removed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var repost models.Post
+		if err := tx.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, postID, models.PostTypeRepost).
+			First(&repost).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to find repost: %w", err)
+		}
+
+		if err := tx.Delete(&repost).Error; err != nil {
+			return fmt.Errorf("failed to delete repost: %w", err)
+		}
+		if err := adjustPostsCount(tx, userID, -1); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			Update("reposts_count", gorm.Expr("GREATEST(reposts_count - 1, 0)")).Error; err != nil {
+			return fmt.Errorf("failed to update reposts count: %w", err)
+		}
+
+		removed = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if removed {
+		publishPostCounts(s.db, s.broker, postID)
+	}
+	return nil
+}
+
+// createRepost creates the repost row and bumps the original's repost count
+// in one transaction, returning the existing repost if there already is one
+func (s *PostService) createRepost(userID, postID uuid.UUID) (*models.Post, error) {
+	var original models.Post
+	if err := s.db.First(&original, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("original post not found")
+		}
+		return nil, fmt.Errorf("failed to find post: %w", err)
+	}
+	// Reposting a repost reposts the post it points to, so that is the one
+	// checked below
+	if original.Type == models.PostTypeRepost && original.OriginalPostID != nil {
+		postID = *original.OriginalPostID
+		original = models.Post{}
+		if err := s.db.First(&original, postID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("original post not found")
+			}
+			return nil, fmt.Errorf("failed to find post: %w", err)
+		}
+	}
+	if original.IsDraft {
+		return nil, errors.New("original post not found")
+	}
+	if blocked, err := isBlockedBetween(s.db, userID, original.AuthorID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, errors.New("original post not found")
+	}
+	if visible, err := canViewPosts(s.db, original.AuthorID, userID); err != nil {
+		return nil, err
+	} else if !visible {
+		return nil, errors.New("original post not found")
+	}
+
+	var repost models.Post
+	created := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, postID, models.PostTypeRepost).
+			First(&repost).Error; err == nil {
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to find repost: %w", err)
+		}
+
+		repost = models.Post{
+			AuthorID:       userID,
+			Type:           models.PostTypeRepost,
+			OriginalPostID: &postID,
+			IsPublic:       true,
+		}
+		if err := tx.Create(&repost).Error; err != nil {
+			return err
+		}
+		if err := adjustPostsCount(tx, userID, 1); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			Update("reposts_count", gorm.Expr("reposts_count + 1")).Error; err != nil {
+			return fmt.Errorf("failed to update reposts count: %w", err)
+		}
+
+		created = true
+		return nil
+	})
+	if err != nil {
+		// A concurrent request may have won the race on the unique index
+		if findErr := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, postID, models.PostTypeRepost).
+			First(&repost).Error; findErr == nil {
+			return &repost, nil
+		}
+		return nil, fmt.Errorf("failed to create repost: %w", err)
+	}
+
+	if created {
+		if s.timelineService != nil {
+			s.timelineService.DistributePostAsync(repost.ID)
+		}
+		s.broker.Publish(Event{Type: EventTypeTimelinePost, PostID: repost.ID, AuthorID: userID})
+		publishPostCounts(s.db, s.broker, postID)
+		if s.notificationService != nil {
+			s.notificationService.CreateRepostNotification(userID, postID)
+		}
+		if s.analyticsService != nil {
+			s.analyticsService.RecordPostEvent(models.AnalyticsEventRepost, userID, postID)
+		}
+	}
+
+	return &repost, nil
+}
+
 func (s *PostService) GetPostByID(postID uuid.UUID) (*models.Post, error) {
 	var post models.Post
-	if err := s.db.Preload("Author").Preload("Media").Preload("OriginalPost").Preload("ParentPost").First(&post, postID).Error; err != nil {
+	if err := s.db.Preload("Author").Preload("Media").Preload("Mentions").Preload("LinkCard").Preload("OriginalPost").Preload("ParentPost").First(&post, postID).Error; err != nil {
 		return nil, err
 	}
 	return &post, nil
@@ -130,61 +395,72 @@ func (s *PostService) GetPostWithDetails(postID, userID uuid.UUID) (*models.Post
 		return nil, err
 	}
 
-	postWithDetails := &models.PostWithDetails{
-		Post: *post,
+	// Posts look missing to users in a block with their author, and so do
+	// reposts and quotes of them. Posts by private accounts, and reposts and
+	// quotes of them, are only visible to approved followers.
+	authorIDs := []uuid.UUID{post.AuthorID}
+	if post.OriginalPost != nil {
+		authorIDs = append(authorIDs, post.OriginalPost.AuthorID)
+	}
+	for _, authorID := range authorIDs {
+		if blocked, err := isBlockedBetween(s.db, userID, authorID); err != nil {
+			return nil, err
+		} else if blocked {
+			return nil, errors.New("post not found")
+		}
+	}
+	for _, authorID := range authorIDs {
+		if visible, err := canViewPosts(s.db, authorID, userID); err != nil {
+			return nil, err
+		} else if !visible {
+			return nil, errors.New("post not found")
+		}
 	}
 
-	// Check if user liked this post
-	var like models.Like
-	if err := s.db.Where("user_id = ? AND post_id = ?", userID, postID).First(&like).Error; err == nil {
-		postWithDetails.IsLiked = true
+	postsWithDetails, err := toPostsWithDetails(s.db, []models.Post{*post}, userID)
+	if err != nil {
+		return nil, err
 	}
+	postWithDetails := &postsWithDetails[0]
 
-	// Check if user reposted this post
-	var repost models.Post
-	if err := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, postID, models.PostTypeRepost).First(&repost).Error; err == nil {
-		postWithDetails.IsReposted = true
+	// Blur sensitive content unless the viewer opted to see it. A post the
+	// viewer asked for directly is never hidden outright.
+	if post.AuthorID != userID && post.HasSensitiveContent() {
+		postWithDetails.IsBlurred = sensitiveContentPreference(s.db, userID) != models.SensitiveContentShow
 	}
 
 	return postWithDetails, nil
 }
 
-func (s *PostService) GetPostsByUserID(userID uuid.UUID, limit, offset int) ([]models.PostWithDetails, error) {
-	var posts []models.Post
-	if err := s.db.Where("author_id = ? AND is_draft = false", userID).
+// GetPostsByUserID returns the author's posts as seen by the viewer
+func (s *PostService) GetPostsByUserID(authorID, viewerID uuid.UUID, page PageRequest) ([]models.PostWithDetails, PageInfo, error) {
+	if blocked, err := isBlockedBetween(s.db, authorID, viewerID); err != nil {
+		return nil, PageInfo{}, err
+	} else if blocked {
+		return nil, PageInfo{}, errors.New("user not found")
+	}
+
+	// Their reposts and quotes of someone in a block with the viewer are
+	// hidden as well
+	query := filterBlockedPosts(s.db.Where("posts.author_id = ? AND posts.is_draft = false", authorID), viewerID)
+	query = filterPrivatePosts(query, viewerID).
 		Preload("Author").
 		Preload("Media").
+		Preload("Mentions").
+		Preload("LinkCard").
 		Preload("OriginalPost").
-		Preload("ParentPost").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&posts).Error; err != nil {
-		return nil, err
-	}
-
-	var result []models.PostWithDetails
-	for _, post := range posts {
-		postWithDetails := models.PostWithDetails{
-			Post: post,
-		}
-
-		// Check if user liked this post
-		var like models.Like
-		if err := s.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error; err == nil {
-			postWithDetails.IsLiked = true
-		}
+		Preload("ParentPost")
 
-		// Check if user reposted this post
-		var repost models.Post
-		if err := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, post.ID, models.PostTypeRepost).First(&repost).Error; err == nil {
-			postWithDetails.IsReposted = true
-		}
-
-		result = append(result, postWithDetails)
+	posts, pageInfo, err := paginate(query, page, "posts", false, postCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
 	}
 
-	return result, nil
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, viewerID)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+	return postsWithDetails, pageInfo, nil
 }
 
 func (s *PostService) UpdatePost(postID, userID uuid.UUID, req UpdatePostRequest) error {
@@ -195,12 +471,41 @@ func (s *PostService) UpdatePost(postID, userID uuid.UUID, req UpdatePostRequest
 	}
 
 	// Validate content
-	if len(req.Content) > 280 {
+	req.Content = text.Normalize(req.Content)
+	if !text.IsValidLength(req.Content) {
 		return errors.New("content exceeds 280 characters")
 	}
 
+	updates := map[string]interface{}{
+		"content":       req.Content,
+		"search_vector": models.TSVector(text.SearchVector(req.Content)),
+	}
+	contentWarning := post.ContentWarning
+	if req.ContentWarning != nil {
+		contentWarning = strings.TrimSpace(*req.ContentWarning)
+		if utf8.RuneCountInString(contentWarning) > maxContentWarningLength {
+			return errors.New("content warning exceeds 200 characters")
+		}
+		updates["content_warning"] = contentWarning
+	}
+	if req.IsSensitive != nil || req.ContentWarning != nil {
+		isSensitive := post.IsSensitive
+		if req.IsSensitive != nil {
+			isSensitive = *req.IsSensitive
+		}
+		// A content warning or sensitive media keep the post flagged
+		if !isSensitive && contentWarning == "" {
+			hasSensitive, err := hasSensitiveMedia(s.db, post.ID)
+			if err != nil {
+				return err
+			}
+			isSensitive = hasSensitive
+		}
+		updates["is_sensitive"] = isSensitive || contentWarning != ""
+	}
+
 	// Update post
-	if err := s.db.Model(&post).Update("content", req.Content).Error; err != nil {
+	if err := s.db.Model(&post).Updates(updates).Error; err != nil {
 		return fmt.Errorf("failed to update post: %w", err)
 	}
 
@@ -209,6 +514,16 @@ func (s *PostService) UpdatePost(postID, userID uuid.UUID, req UpdatePostRequest
 		return fmt.Errorf("failed to process hashtags: %w", err)
 	}
 
+	// Reprocess mentions
+	if err := s.processMentions(&post, req.Content); err != nil {
+		return fmt.Errorf("failed to process mentions: %w", err)
+	}
+
+	// Refresh the link preview in the background
+	if s.linkPreviewService != nil {
+		s.linkPreviewService.UnfurlPostAsync(postID, req.Content)
+	}
+
 	return nil
 }
 
@@ -219,19 +534,36 @@ func (s *PostService) DeletePost(postID, userID uuid.UUID) error {
 		return errors.New("post not found or unauthorized")
 	}
 
-	// Delete post (soft delete)
-	if err := s.db.Delete(&post).Error; err != nil {
-		return fmt.Errorf("failed to delete post: %w", err)
-	}
+	// Delete post (soft delete) along with the counters it contributes to
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&post).Error; err != nil {
+			return fmt.Errorf("failed to delete post: %w", err)
+		}
+
+		// Update parent post comment count if this was a reply
+		if post.ParentPostID != nil {
+			if err := tx.Model(&models.Post{}).Where("id = ?", *post.ParentPostID).
+				Update("comments_count", gorm.Expr("GREATEST(comments_count - 1, 0)")).Error; err != nil {
+				return fmt.Errorf("failed to update comments count: %w", err)
+			}
+		}
+
+		// Update original post repost count if this was a repost
+		if post.OriginalPostID != nil && post.Type == models.PostTypeRepost {
+			if err := tx.Model(&models.Post{}).Where("id = ?", *post.OriginalPostID).
+				Update("reposts_count", gorm.Expr("GREATEST(reposts_count - 1, 0)")).Error; err != nil {
+				return fmt.Errorf("failed to update reposts count: %w", err)
+			}
+		}
 
-	// Update parent post comment count if this was a reply
-	if post.ParentPostID != nil {
-		s.db.Model(&models.Post{}).Where("id = ?", *post.ParentPostID).Update("comments_count", gorm.Expr("comments_count - 1"))
+		return adjustPostsCount(tx, userID, -1)
+	})
+	if err != nil {
+		return err
 	}
 
-	// Update original post repost count if this was a repost
 	if post.OriginalPostID != nil && post.Type == models.PostTypeRepost {
-		s.db.Model(&models.Post{}).Where("id = ?", *post.OriginalPostID).Update("reposts_count", gorm.Expr("reposts_count - 1"))
+		publishPostCounts(s.db, s.broker, *post.OriginalPostID)
 	}
 
 	return nil
@@ -239,10 +571,21 @@ func (s *PostService) DeletePost(postID, userID uuid.UUID) error {
 
 func (s *PostService) validateCreatePostRequest(req CreatePostRequest) error {
 	// Validate content length
-	if len(req.Content) > 280 {
+	if !text.IsValidLength(req.Content) {
 		return errors.New("content exceeds 280 characters")
 	}
 
+	// Validate content warning length
+	if utf8.RuneCountInString(strings.TrimSpace(req.ContentWarning)) > maxContentWarningLength {
+		return errors.New("content warning exceeds 200 characters")
+	}
+
+	if req.Lang != "" {
+		if _, ok := text.NormalizeLanguage(req.Lang); !ok {
+			return errors.New("invalid lang")
+		}
+	}
+
 	// Validate post type
 	validTypes := []string{
 		string(models.PostTypeOriginal),
@@ -309,16 +652,96 @@ func (s *PostService) processHashtags(postID uuid.UUID, content string) error {
 	return nil
 }
 
+// processMentions resolves @username references in content and replaces the
+// post's stored mentions. Users who were not already mentioned are notified.
+func (s *PostService) processMentions(post *models.Post, content string) error {
+	// Remember who was already mentioned so edits don't re-notify them
+	var previous []models.PostMention
+	if err := s.db.Where("post_id = ?", post.ID).Find(&previous).Error; err != nil {
+		return err
+	}
+	alreadyMentioned := make(map[uuid.UUID]bool, len(previous))
+	for _, mention := range previous {
+		alreadyMentioned[mention.UserID] = true
+	}
+
+	if err := s.db.Where("post_id = ?", post.ID).Delete(&models.PostMention{}).Error; err != nil {
+		return err
+	}
+
+	matches := text.ExtractMentions(content)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	usernames := make([]string, 0, len(matches))
+	for _, match := range matches {
+		usernames = append(usernames, strings.ToLower(match.Value))
+	}
+
+	// Users in a block with the author can't be mentioned
+	var users []models.User
+	query := s.db.Where("LOWER(username) IN ? AND is_active = true", usernames)
+	if err := excludeBlocked(query, post.AuthorID, "users.id").Find(&users).Error; err != nil {
+		return err
+	}
+	usersByName := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByName[strings.ToLower(user.Username)] = user
+	}
+
+	var mentions []models.PostMention
+	for _, match := range matches {
+		user, ok := usersByName[strings.ToLower(match.Value)]
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, models.PostMention{
+			PostID:   post.ID,
+			UserID:   user.ID,
+			Username: user.Username,
+			Start:    match.Start,
+			End:      match.End,
+		})
+	}
+
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	if err := s.db.Create(&mentions).Error; err != nil {
+		return err
+	}
+
+	// Drafts and non-public posts are not visible to the mentioned users yet
+	if s.notificationService == nil || post.IsDraft || !post.IsPublic {
+		return nil
+	}
+
+	notified := make(map[uuid.UUID]bool, len(mentions))
+	for _, mention := range mentions {
+		if alreadyMentioned[mention.UserID] || notified[mention.UserID] {
+			continue
+		}
+		notified[mention.UserID] = true
+		s.notificationService.CreateMentionNotification(post.AuthorID, mention.UserID, post.ID)
+	}
+
+	return nil
+}
+
 func extractHashtags(content string) []string {
-	re := regexp.MustCompile(`#(\w+)`)
-	matches := re.FindAllStringSubmatch(content, -1)
-	
+	seen := make(map[string]bool)
+
 	var hashtags []string
-	for _, match := range matches {
-		if len(match) > 1 {
-			hashtags = append(hashtags, strings.ToLower(match[1]))
+	for _, entity := range text.ExtractHashtags(content) {
+		tag := text.NormalizeHashtag(entity.Value)
+		if seen[tag] {
+			continue
 		}
+		seen[tag] = true
+		hashtags = append(hashtags, tag)
 	}
-	
+
 	return hashtags
-}
\ No newline at end of file
+}
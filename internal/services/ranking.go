@@ -0,0 +1,177 @@
+package services
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// candidateSource records why a post was picked for the For You timeline.
+// A post can come from several sources at once.
+type candidateSource uint8
+
+const (
+	sourceFollowing candidateSource = 1 << iota
+	sourceFriendsOfFriends
+	sourceTrendingHashtag
+)
+
+// rankingCandidate is a post considered for the For You timeline together
+// with the signals it is scored on
+type rankingCandidate struct {
+	PostID        uuid.UUID `gorm:"column:id"`
+	AuthorID      uuid.UUID
+	CreatedAt     time.Time
+	LikesCount    int
+	RepostsCount  int
+	CommentsCount int
+
+	Sources candidateSource `gorm:"-"`
+	// Number of recent interactions between the viewer and the author
+	Interactions int `gorm:"-"`
+}
+
+// RankingWeights tunes the For You scoring. Every weight multiplies a
+// signal that is roughly in [0, 5], so they can be compared directly.
+type RankingWeights struct {
+	Following        float64
+	FriendsOfFriends float64
+	TrendingHashtag  float64
+	Affinity         float64
+	Engagement       float64
+
+	// A post's score halves every HalfLife
+	HalfLife time.Duration
+	// Each further post by an author already ranked above is multiplied by
+	// AuthorPenalty, and no author gets more than MaxPerAuthor posts
+	AuthorPenalty float64
+	MaxPerAuthor  int
+}
+
+// NewRankingWeights reads the weights from FOR_YOU_* environment variables,
+// falling back to the defaults for unset or invalid values
+func NewRankingWeights() RankingWeights {
+	halfLife, err := time.ParseDuration(os.Getenv("FOR_YOU_HALF_LIFE"))
+	if err != nil || halfLife <= 0 {
+		halfLife = 6 * time.Hour
+	}
+
+	maxPerAuthor, err := strconv.Atoi(os.Getenv("FOR_YOU_MAX_PER_AUTHOR"))
+	if err != nil || maxPerAuthor <= 0 {
+		maxPerAuthor = 3
+	}
+
+	return RankingWeights{
+		Following:        envWeight("FOR_YOU_WEIGHT_FOLLOWING", 1.0),
+		FriendsOfFriends: envWeight("FOR_YOU_WEIGHT_FRIENDS_OF_FRIENDS", 0.6),
+		TrendingHashtag:  envWeight("FOR_YOU_WEIGHT_TRENDING", 0.4),
+		Affinity:         envWeight("FOR_YOU_WEIGHT_AFFINITY", 0.5),
+		Engagement:       envWeight("FOR_YOU_WEIGHT_ENGAGEMENT", 0.3),
+		HalfLife:         halfLife,
+		AuthorPenalty:    envWeight("FOR_YOU_AUTHOR_PENALTY", 0.5),
+		MaxPerAuthor:     maxPerAuthor,
+	}
+}
+
+func envWeight(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return defaultValue
+	}
+	return value
+}
+
+// scoreCandidate scores a post as of now. The decay is exponential, so the
+// relative order of two posts doesn't change as time passes.
+func scoreCandidate(c rankingCandidate, now time.Time, w RankingWeights) float64 {
+	var relevance float64
+	if c.Sources&sourceFollowing != 0 {
+		relevance += w.Following
+	}
+	if c.Sources&sourceFriendsOfFriends != 0 {
+		relevance += w.FriendsOfFriends
+	}
+	if c.Sources&sourceTrendingHashtag != 0 {
+		relevance += w.TrendingHashtag
+	}
+
+	relevance += w.Affinity * math.Log1p(float64(max(c.Interactions, 0)))
+
+	engagement := max(c.LikesCount, 0) + 2*max(c.RepostsCount, 0) + 2*max(c.CommentsCount, 0)
+	relevance += w.Engagement * math.Log1p(float64(engagement))
+
+	age := max(now.Sub(c.CreatedAt), 0)
+	decay := math.Exp2(-float64(age) / float64(w.HalfLife))
+
+	return relevance * decay
+}
+
+// rankCandidates orders candidates by score, spreading out posts by the same
+// author. Ties are broken by recency and then post ID so the result depends
+// only on its inputs.
+func rankCandidates(candidates []rankingCandidate, now time.Time, w RankingWeights) []rankingCandidate {
+	type scored struct {
+		candidate rankingCandidate
+		score     float64
+	}
+
+	pool := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		pool = append(pool, scored{candidate: c, score: scoreCandidate(c, now, w)})
+	}
+	sort.Slice(pool, func(i, j int) bool {
+		return rankedBefore(pool[i].candidate, pool[i].score, pool[j].candidate, pool[j].score)
+	})
+
+	// Greedily pick the best post after applying the penalty for authors
+	// already picked. Penalties only ever lower scores, so a post whose
+	// author has no picks yet can't be beaten by anything further down.
+	ranked := make([]rankingCandidate, 0, len(pool))
+	picked := make(map[uuid.UUID]int)
+	used := make([]bool, len(pool))
+	for len(ranked) < len(pool) {
+		best := -1
+		var bestScore float64
+		for i, s := range pool {
+			if used[i] {
+				continue
+			}
+			count := picked[s.candidate.AuthorID]
+			if count >= w.MaxPerAuthor {
+				used[i] = true
+				continue
+			}
+
+			score := s.score * math.Pow(w.AuthorPenalty, float64(count))
+			if best == -1 || rankedBefore(s.candidate, score, pool[best].candidate, bestScore) {
+				best, bestScore = i, score
+			}
+			if count == 0 {
+				break
+			}
+		}
+		if best == -1 {
+			break
+		}
+
+		used[best] = true
+		picked[pool[best].candidate.AuthorID]++
+		ranked = append(ranked, pool[best].candidate)
+	}
+
+	return ranked
+}
+
+func rankedBefore(a rankingCandidate, aScore float64, b rankingCandidate, bScore float64) bool {
+	if aScore != bScore {
+		return aScore > bScore
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.PostID.String() < b.PostID.String()
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testRankingWeights = RankingWeights{
+	Following:        1.0,
+	FriendsOfFriends: 0.6,
+	TrendingHashtag:  0.4,
+	Affinity:         0.5,
+	Engagement:       0.3,
+	HalfLife:         6 * time.Hour,
+	AuthorPenalty:    0.5,
+	MaxPerAuthor:     3,
+}
+
+var (
+	testAuthorA = uuid.MustParse("aaaaaaaa-0000-0000-0000-000000000000")
+	testAuthorB = uuid.MustParse("bbbbbbbb-0000-0000-0000-000000000000")
+	testAuthorC = uuid.MustParse("cccccccc-0000-0000-0000-000000000000")
+)
+
+// testCandidate returns a post by author from following, age before now
+func testCandidate(id int, author uuid.UUID, now time.Time, age time.Duration) rankingCandidate {
+	return rankingCandidate{
+		PostID:    testPostID(id),
+		AuthorID:  author,
+		CreatedAt: now.Add(-age),
+		Sources:   sourceFollowing,
+	}
+}
+
+// testPostID returns a post ID that sorts by n
+func testPostID(n int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", n))
+}
+
+func TestScoreCandidate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		candidate rankingCandidate
+		want      float64
+	}{
+		{
+			name:      "no signals",
+			candidate: rankingCandidate{CreatedAt: now},
+			want:      0,
+		},
+		{
+			name:      "following",
+			candidate: rankingCandidate{CreatedAt: now, Sources: sourceFollowing},
+			want:      1.0,
+		},
+		{
+			name:      "every source",
+			candidate: rankingCandidate{CreatedAt: now, Sources: sourceFollowing | sourceFriendsOfFriends | sourceTrendingHashtag},
+			want:      2.0,
+		},
+		{
+			name:      "halved after one half-life",
+			candidate: rankingCandidate{CreatedAt: now.Add(-6 * time.Hour), Sources: sourceFollowing},
+			want:      0.5,
+		},
+		{
+			name:      "quartered after two half-lives",
+			candidate: rankingCandidate{CreatedAt: now.Add(-12 * time.Hour), Sources: sourceFollowing},
+			want:      0.25,
+		},
+		{
+			name:      "future posts get no boost",
+			candidate: rankingCandidate{CreatedAt: now.Add(time.Hour), Sources: sourceFollowing},
+			want:      1.0,
+		},
+		{
+			name:      "affinity",
+			candidate: rankingCandidate{CreatedAt: now, Sources: sourceFollowing, Interactions: 3},
+			want:      1.0 + 0.5*math.Log(4),
+		},
+		{
+			name:      "reposts and comments count double",
+			candidate: rankingCandidate{CreatedAt: now, LikesCount: 1, RepostsCount: 1, CommentsCount: 1},
+			want:      0.3 * math.Log(6),
+		},
+		{
+			name:      "negative counts are ignored",
+			candidate: rankingCandidate{CreatedAt: now, Sources: sourceFollowing, LikesCount: -5, Interactions: -2},
+			want:      1.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scoreCandidate(tt.candidate, now, testRankingWeights); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("scoreCandidate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreCandidateAffinity(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	previous := -1.0
+	for _, interactions := range []int{0, 1, 2, 5, 20} {
+		score := scoreCandidate(rankingCandidate{CreatedAt: now, Sources: sourceFollowing, Interactions: interactions}, now, testRankingWeights)
+		if score <= previous {
+			t.Errorf("score with %d interactions = %v, want more than %v", interactions, score, previous)
+		}
+		previous = score
+	}
+}
+
+func TestScoreCandidateDecayKeepsOrder(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	older := rankingCandidate{CreatedAt: now.Add(-3 * time.Hour), Sources: sourceFollowing, Interactions: 10}
+	newer := rankingCandidate{CreatedAt: now, Sources: sourceTrendingHashtag}
+
+	for _, later := range []time.Duration{0, time.Hour, 24 * time.Hour} {
+		at := now.Add(later)
+		if scoreCandidate(older, at, testRankingWeights) <= scoreCandidate(newer, at, testRankingWeights) {
+			t.Errorf("%v later the order of the two posts flipped", later)
+		}
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		weights    func(w *RankingWeights)
+		candidates []rankingCandidate
+		want       []int
+	}{
+		{
+			name: "by score",
+			candidates: []rankingCandidate{
+				testCandidate(1, testAuthorA, now, 10*time.Hour),
+				testCandidate(2, testAuthorB, now, 0),
+				testCandidate(3, testAuthorC, now, 5*time.Hour),
+			},
+			want: []int{2, 3, 1},
+		},
+		{
+			name: "newer first when scores tie",
+			candidates: []rankingCandidate{
+				{PostID: testPostID(1), AuthorID: testAuthorA, CreatedAt: now.Add(-2 * time.Hour)},
+				{PostID: testPostID(2), AuthorID: testAuthorB, CreatedAt: now.Add(-1 * time.Hour)},
+				{PostID: testPostID(3), AuthorID: testAuthorC, CreatedAt: now.Add(-3 * time.Hour)},
+			},
+			want: []int{2, 1, 3},
+		},
+		{
+			name: "post ID breaks a full tie",
+			candidates: []rankingCandidate{
+				testCandidate(3, testAuthorA, now, time.Hour),
+				testCandidate(1, testAuthorB, now, time.Hour),
+				testCandidate(2, testAuthorC, now, time.Hour),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name: "penalty lets another author in",
+			candidates: []rankingCandidate{
+				testCandidate(1, testAuthorA, now, 0),
+				testCandidate(2, testAuthorA, now, time.Hour),
+				testCandidate(3, testAuthorB, now, 4*time.Hour),
+			},
+			want: []int{1, 3, 2},
+		},
+		{
+			name:    "no penalty keeps score order",
+			weights: func(w *RankingWeights) { w.AuthorPenalty = 1 },
+			candidates: []rankingCandidate{
+				testCandidate(1, testAuthorA, now, 0),
+				testCandidate(2, testAuthorA, now, time.Hour),
+				testCandidate(3, testAuthorB, now, 4*time.Hour),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:    "per-author cap",
+			weights: func(w *RankingWeights) { w.AuthorPenalty = 1 },
+			candidates: []rankingCandidate{
+				testCandidate(1, testAuthorA, now, 0),
+				testCandidate(2, testAuthorA, now, time.Minute),
+				testCandidate(3, testAuthorA, now, 2*time.Minute),
+				testCandidate(4, testAuthorA, now, 3*time.Minute),
+				testCandidate(5, testAuthorA, now, 4*time.Minute),
+				testCandidate(6, testAuthorB, now, 24*time.Hour),
+			},
+			want: []int{1, 2, 3, 6},
+		},
+		{
+			name:    "cap of one",
+			weights: func(w *RankingWeights) { w.MaxPerAuthor = 1 },
+			candidates: []rankingCandidate{
+				testCandidate(1, testAuthorA, now, 0),
+				testCandidate(2, testAuthorA, now, time.Minute),
+				testCandidate(3, testAuthorB, now, time.Hour),
+				testCandidate(4, testAuthorB, now, 2*time.Hour),
+			},
+			want: []int{1, 3},
+		},
+		{
+			name: "empty",
+			want: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testRankingWeights
+			if tt.weights != nil {
+				tt.weights(&w)
+			}
+
+			assertRanking(t, rankCandidates(tt.candidates, now, w), tt.want)
+
+			// The result depends only on the candidates, not their order
+			reversed := make([]rankingCandidate, len(tt.candidates))
+			for i, c := range tt.candidates {
+				reversed[len(tt.candidates)-1-i] = c
+			}
+			assertRanking(t, rankCandidates(reversed, now, w), tt.want)
+		})
+	}
+}
+
+func assertRanking(t *testing.T, ranked []rankingCandidate, want []int) {
+	t.Helper()
+	got := make([]uuid.UUID, len(ranked))
+	for i, c := range ranked {
+		got[i] = c.PostID
+	}
+	wantIDs := make([]uuid.UUID, len(want))
+	for i, id := range want {
+		wantIDs[i] = testPostID(id)
+	}
+
+	if len(got) != len(wantIDs) {
+		t.Fatalf("rankCandidates() = %v, want %v", got, wantIDs)
+	}
+	for i := range got {
+		if got[i] != wantIDs[i] {
+			t.Fatalf("rankCandidates() = %v, want %v", got, wantIDs)
+		}
+	}
+}
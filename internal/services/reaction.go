@@ -0,0 +1,232 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"digeon-backend/internal/text"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultReactionEmojis is used when REACTION_EMOJIS is not set. ❤️ is not
+// included because hearts are handled by likes.
+var defaultReactionEmojis = []string{"👍", "😂", "😮", "😢", "🔥", "🎉"}
+
+const likeEmoji = "❤️"
+
+type ReactionService struct {
+	db                  *gorm.DB
+	notificationService *NotificationService
+	emojis              []string
+}
+
+type ReactionResponse struct {
+	Emoji     string            `json:"emoji"`
+	User      models.UserPublic `json:"user"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func NewReactionService(db *gorm.DB, notificationService *NotificationService) *ReactionService {
+	emojis := defaultReactionEmojis
+	if value := os.Getenv("REACTION_EMOJIS"); value != "" {
+		emojis = nil
+		for _, emoji := range strings.Split(value, ",") {
+			emoji = text.Normalize(strings.TrimSpace(emoji))
+			if emoji == "" || emoji == likeEmoji {
+				continue
+			}
+			emojis = append(emojis, emoji)
+		}
+	}
+
+	return &ReactionService{
+		db:                  db,
+		notificationService: notificationService,
+		emojis:              emojis,
+	}
+}
+
+// Emojis returns the emoji users can react with
+func (s *ReactionService) Emojis() []string {
+	return s.emojis
+}
+
+func (s *ReactionService) AddReaction(userID, postID uuid.UUID, emoji string) error {
+	emoji, err := s.validateEmoji(emoji)
+	if err != nil {
+		return err
+	}
+
+	// Check if post exists
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("post not found")
+		}
+		return fmt.Errorf("failed to find post: %w", err)
+	}
+	if blocked, err := isBlockedBetween(s.db, userID, post.AuthorID); err != nil {
+		return err
+	} else if blocked {
+		return errors.New("post not found")
+	}
+	if visible, err := canViewPosts(s.db, post.AuthorID, userID); err != nil {
+		return err
+	} else if !visible {
+		return errors.New("post not found")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// The unique index decides between concurrent requests
+		reaction := models.Reaction{
+			UserID: userID,
+			PostID: postID,
+			Emoji:  emoji,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create reaction: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("reaction already exists")
+		}
+
+		// Update post reaction counts
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			Update("reaction_counts", gorm.Expr(
+				"jsonb_set(reaction_counts, ARRAY[?::text], to_jsonb(COALESCE((reaction_counts->>?)::int, 0) + 1))",
+				emoji, emoji,
+			)).Error; err != nil {
+			return fmt.Errorf("failed to update reaction counts: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Create notification
+	if s.notificationService != nil {
+		s.notificationService.CreateReactionNotification(userID, postID, emoji)
+	}
+
+	return nil
+}
+
+func (s *ReactionService) RemoveReaction(userID, postID uuid.UUID, emoji string) error {
+	emoji, err := s.validateEmoji(emoji)
+	if err != nil {
+		return err
+	}
+
+	// Check if post exists
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("post not found")
+		}
+		return fmt.Errorf("failed to find post: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var reaction models.Reaction
+		if err := tx.Where("user_id = ? AND post_id = ? AND emoji = ?", userID, postID, emoji).First(&reaction).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("reaction not found")
+			}
+			return fmt.Errorf("failed to find reaction: %w", err)
+		}
+
+		// A concurrent removal may have deleted it since it was found; only
+		// the request that deletes it updates the counts
+		result := tx.Delete(&reaction)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete reaction: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("reaction not found")
+		}
+
+		// Update post reaction counts, dropping emoji that reach zero
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			Update("reaction_counts", gorm.Expr(
+				"CASE WHEN COALESCE((reaction_counts->>?)::int, 0) <= 1 THEN reaction_counts - ?::text "+
+					"ELSE jsonb_set(reaction_counts, ARRAY[?::text], to_jsonb((reaction_counts->>?)::int - 1)) END",
+				emoji, emoji, emoji, emoji,
+			)).Error; err != nil {
+			return fmt.Errorf("failed to update reaction counts: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetPostReactions lists who reacted to a post, optionally only with emoji,
+// newest first
+func (s *ReactionService) GetPostReactions(postID, viewerID uuid.UUID, emoji string, page PageRequest) ([]ReactionResponse, PageInfo, error) {
+	if _, err := findVisiblePost(s.db, postID, viewerID); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	query := excludeBlocked(s.db.Where("post_id = ?", postID), viewerID, "reactions.user_id")
+	if emoji != "" {
+		query = query.Where("emoji = ?", text.Normalize(emoji))
+	}
+
+	reactions, pageInfo, err := paginate(query.Preload("User"), page, "reactions", false, reactionCursorKey)
+	if err != nil {
+		return nil, pageInfo, err
+	}
+
+	var responses []ReactionResponse
+	for _, reaction := range reactions {
+		responses = append(responses, ReactionResponse{
+			Emoji: reaction.Emoji,
+			User: models.UserPublic{
+				ID:              reaction.User.ID,
+				Username:        reaction.User.Username,
+				DisplayName:     reaction.User.DisplayName,
+				Bio:             reaction.User.Bio,
+				ProfileImageURL: reaction.User.ProfileImageURL,
+				CoverImageURL:   reaction.User.CoverImageURL,
+				Location:        reaction.User.Location,
+				Website:         reaction.User.Website,
+				IsVerified:      reaction.User.IsVerified,
+				IsPrivate:       reaction.User.IsPrivate,
+				CreatedAt:       reaction.User.CreatedAt,
+			},
+			CreatedAt: reaction.CreatedAt,
+		})
+	}
+
+	return responses, pageInfo, nil
+}
+
+// GetUserReactions returns the emoji the user has reacted to a post with
+func (s *ReactionService) GetUserReactions(userID, postID uuid.UUID) ([]string, error) {
+	var emojis []string
+	if err := s.db.Model(&models.Reaction{}).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Order("created_at ASC").
+		Pluck("emoji", &emojis).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user reactions: %w", err)
+	}
+	return emojis, nil
+}
+
+func (s *ReactionService) validateEmoji(emoji string) (string, error) {
+	emoji = text.Normalize(strings.TrimSpace(emoji))
+	for _, allowed := range s.emojis {
+		if emoji == allowed {
+			return emoji, nil
+		}
+	}
+	return "", errors.New("unsupported reaction")
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Most users whose relationships can be fetched at once
+const maxRelationshipIDs = 100
+
+// Relationship describes how the viewer relates to another user
+type Relationship struct {
+	UserID               uuid.UUID `json:"user_id"`
+	Following            bool      `json:"following"`
+	FollowedBy           bool      `json:"followed_by"`
+	Blocking             bool      `json:"blocking"`
+	Muting               bool      `json:"muting"`
+	FollowRequestPending bool      `json:"follow_request_pending"`
+}
+
+// UserWithRelationship is a user together with the viewer's relationship to
+// them. Relationship is omitted for anonymous viewers.
+type UserWithRelationship struct {
+	models.UserPublic
+	Relationship *Relationship `json:"relationship,omitempty"`
+}
+
+// GetRelationships returns the viewer's relationship to each user, in the
+// order given
+func (s *FollowService) GetRelationships(viewerID uuid.UUID, userIDs []uuid.UUID) ([]Relationship, error) {
+	if len(userIDs) == 0 {
+		return nil, errors.New("ids is required")
+	}
+	if len(userIDs) > maxRelationshipIDs {
+		return nil, errors.New("at most 100 ids are allowed")
+	}
+
+	relationships, err := s.loadRelationships(viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Relationship, 0, len(userIDs))
+	for _, userID := range userIDs {
+		result = append(result, *relationships[userID])
+	}
+	return result, nil
+}
+
+// withRelationships pairs users with the viewer's relationship to them
+func (s *FollowService) withRelationships(viewerID uuid.UUID, users []models.UserPublic) ([]UserWithRelationship, error) {
+	result := make([]UserWithRelationship, 0, len(users))
+	if viewerID == uuid.Nil || len(users) == 0 {
+		for _, user := range users {
+			result = append(result, UserWithRelationship{UserPublic: user})
+		}
+		return result, nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	relationships, err := s.loadRelationships(viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		result = append(result, UserWithRelationship{UserPublic: user, Relationship: relationships[user.ID]})
+	}
+	return result, nil
+}
+
+// loadRelationships fetches the viewer's relationships to every user in a
+// single query, whatever the number of users
+func (s *FollowService) loadRelationships(viewerID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]*Relationship, error) {
+	relationships := make(map[uuid.UUID]*Relationship, len(userIDs))
+	for _, userID := range userIDs {
+		relationships[userID] = &Relationship{UserID: userID}
+	}
+
+	var rows []struct {
+		Kind   string
+		UserID uuid.UUID
+	}
+	if err := s.db.Raw(`
+		SELECT 'following' AS kind, following_id AS user_id FROM follows
+		WHERE deleted_at IS NULL AND follower_id = ? AND following_id IN ?
+		UNION ALL
+		SELECT 'followed_by', follower_id FROM follows
+		WHERE deleted_at IS NULL AND following_id = ? AND follower_id IN ?
+		UNION ALL
+		SELECT 'blocking', blocked_id FROM blocks
+		WHERE deleted_at IS NULL AND blocker_id = ? AND blocked_id IN ?
+		UNION ALL
+		SELECT 'muting', muted_user_id FROM muted_users
+		WHERE deleted_at IS NULL AND user_id = ? AND muted_user_id IN ?
+			AND (expires_at IS NULL OR expires_at > NOW())
+		UNION ALL
+		SELECT 'follow_request_pending', target_id FROM follow_requests
+		WHERE deleted_at IS NULL AND requester_id = ? AND target_id IN ?
+	`, viewerID, userIDs, viewerID, userIDs, viewerID, userIDs, viewerID, userIDs, viewerID, userIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch relationships: %w", err)
+	}
+
+	for _, row := range rows {
+		relationship, ok := relationships[row.UserID]
+		if !ok {
+			continue
+		}
+		switch row.Kind {
+		case "following":
+			relationship.Following = true
+		case "followed_by":
+			relationship.FollowedBy = true
+		case "blocking":
+			relationship.Blocking = true
+		case "muting":
+			relationship.Muting = true
+		case "follow_request_pending":
+			relationship.FollowRequestPending = true
+		}
+	}
+
+	return relationships, nil
+}
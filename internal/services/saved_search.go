@@ -0,0 +1,258 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxSavedSearches          = 25
+	maxSavedSearchNameLength  = 100
+	savedSearchCheckBatchSize = 100
+	// Most notifications one saved search sends per check, so that a
+	// popular query can't flood its owner
+	maxSavedSearchNotifications = 10
+	// Posts are matched from this long before the last check, since one
+	// saved late can carry an earlier created_at. Posts already notified
+	// about are skipped.
+	savedSearchCheckOverlap = time.Minute
+)
+
+type SavedSearchRequest struct {
+	Name   string `json:"name"`
+	Query  string `json:"query"`
+	Notify bool   `json:"notify"`
+}
+
+type UpdateSavedSearchRequest struct {
+	Name   *string `json:"name,omitempty"`
+	Query  *string `json:"query,omitempty"`
+	Notify *bool   `json:"notify,omitempty"`
+}
+
+func validateSavedSearch(name, query string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxSavedSearchNameLength {
+		return errors.New("name must be 100 characters or less")
+	}
+	if query == "" {
+		return errors.New("query is required")
+	}
+	if utf8.RuneCountInString(query) > maxStoredSearchQueryLength {
+		return errors.New("query must be 500 characters or less")
+	}
+
+	search, err := parseSearchQuery(query)
+	if err != nil {
+		return err
+	}
+	if search.empty() {
+		return errors.New("query has nothing to search for")
+	}
+	return nil
+}
+
+// CreateSavedSearch saves a post search query under a name
+func (s *SearchService) CreateSavedSearch(userID uuid.UUID, req SavedSearchRequest) (*models.SavedSearch, error) {
+	name := strings.TrimSpace(req.Name)
+	query := normalizeStoredSearchQuery(req.Query)
+	if err := validateSavedSearch(name, query); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+	if count >= maxSavedSearches {
+		return nil, errors.New("at most 25 saved searches are allowed")
+	}
+	if err := s.checkSavedSearchName(userID, uuid.Nil, name); err != nil {
+		return nil, err
+	}
+
+	savedSearch := models.SavedSearch{
+		UserID: userID,
+		Name:   name,
+		Query:  query,
+		Notify: req.Notify,
+	}
+	if err := s.db.Create(&savedSearch).Error; err != nil {
+		return nil, fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	return &savedSearch, nil
+}
+
+// GetSavedSearches returns the user's saved searches, newest first
+func (s *SearchService) GetSavedSearches(userID uuid.UUID) ([]models.SavedSearch, error) {
+	savedSearches := []models.SavedSearch{}
+	if err := s.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&savedSearches).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch saved searches: %w", err)
+	}
+	return savedSearches, nil
+}
+
+// UpdateSavedSearch changes a saved search. Changing the query or turning on
+// notifications starts matching from now, rather than notifying about
+// older posts.
+func (s *SearchService) UpdateSavedSearch(userID, savedSearchID uuid.UUID, req UpdateSavedSearchRequest) (*models.SavedSearch, error) {
+	var savedSearch models.SavedSearch
+	if err := s.db.Where("id = ? AND user_id = ?", savedSearchID, userID).First(&savedSearch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("saved search not found")
+		}
+		return nil, fmt.Errorf("failed to find saved search: %w", err)
+	}
+
+	updates := map[string]interface{}{}
+	name, query := savedSearch.Name, savedSearch.Query
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		updates["name"] = name
+	}
+	if req.Query != nil {
+		query = normalizeStoredSearchQuery(*req.Query)
+		updates["query"] = query
+		if query != savedSearch.Query {
+			updates["checked_at"] = time.Now()
+		}
+	}
+	if req.Notify != nil {
+		updates["notify"] = *req.Notify
+		if *req.Notify && !savedSearch.Notify {
+			updates["checked_at"] = time.Now()
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil, errors.New("no valid fields to update")
+	}
+	if err := validateSavedSearch(name, query); err != nil {
+		return nil, err
+	}
+	if name != savedSearch.Name {
+		if err := s.checkSavedSearchName(userID, savedSearch.ID, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.Model(&savedSearch).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	return &savedSearch, nil
+}
+
+// DeleteSavedSearch removes one of the user's saved searches
+func (s *SearchService) DeleteSavedSearch(userID, savedSearchID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", savedSearchID, userID).Delete(&models.SavedSearch{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete saved search: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("saved search not found")
+	}
+	return nil
+}
+
+// checkSavedSearchName fails when another of the user's saved searches has
+// the name
+func (s *SearchService) checkSavedSearchName(userID, savedSearchID uuid.UUID, name string) error {
+	var count int64
+	if err := s.db.Model(&models.SavedSearch{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, savedSearchID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check saved search name: %w", err)
+	}
+	if count > 0 {
+		return errors.New("a saved search with this name already exists")
+	}
+	return nil
+}
+
+// StartSavedSearchJob runs CheckSavedSearches every interval in the
+// background
+func (s *SearchService) StartSavedSearchJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.CheckSavedSearches(); err != nil {
+				log.Printf("Saved search check failed: %v", err)
+			}
+		}
+	}()
+}
+
+// CheckSavedSearches notifies the owners of saved searches with
+// notifications on about posts that matched since the last check
+func (s *SearchService) CheckSavedSearches() error {
+	var savedSearches []models.SavedSearch
+	result := s.db.Where("notify = true").
+		FindInBatches(&savedSearches, savedSearchCheckBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range savedSearches {
+				if err := s.checkSavedSearch(&savedSearches[i]); err != nil {
+					log.Printf("Saved search %s check failed: %v", savedSearches[i].ID, err)
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to find saved searches: %w", result.Error)
+	}
+	return nil
+}
+
+// checkSavedSearch notifies the owner about posts that matched one saved
+// search since it was last checked, searching as the owner so that only
+// posts they could find themselves are sent
+func (s *SearchService) checkSavedSearch(savedSearch *models.SavedSearch) error {
+	now := time.Now()
+	search, err := parseSearchQuery(savedSearch.Query)
+	if err != nil {
+		return err
+	}
+
+	if !search.empty() {
+		preference := sensitiveContentPreference(s.db, savedSearch.UserID)
+		var posts []models.Post
+		if err := s.searchablePosts(search, savedSearch.UserID, preference).
+			Select("posts.id", "posts.author_id").
+			Where("posts.created_at > ? AND posts.created_at <= ?", savedSearch.CheckedAt.Add(-savedSearchCheckOverlap), now).
+			Where("posts.author_id <> ?", savedSearch.UserID).
+			Where(`NOT EXISTS (
+				SELECT 1 FROM notifications
+				WHERE notifications.user_id = ? AND notifications.post_id = posts.id AND notifications.type = ?
+			)`, savedSearch.UserID, models.NotificationTypeSavedSearch).
+			Order("posts.created_at ASC").
+			Limit(maxSavedSearchNotifications).
+			Find(&posts).Error; err != nil {
+			return fmt.Errorf("failed to match posts: %w", err)
+		}
+
+		for i := range posts {
+			if err := s.notificationService.CreateSavedSearchNotification(savedSearch, &posts[i]); err != nil {
+				return fmt.Errorf("failed to notify: %w", err)
+			}
+		}
+	}
+
+	if err := s.db.Model(savedSearch).UpdateColumn("checked_at", now).Error; err != nil {
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return nil
+}
@@ -2,19 +2,27 @@ package services
 
 import (
 	"digeon-backend/internal/models"
+	"digeon-backend/internal/text"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Posts indexed per query when backfilling search vectors
+const searchBackfillBatchSize = 500
+
 type SearchService struct {
-	db *gorm.DB
+	db                  *gorm.DB
+	notificationService *NotificationService
 }
 
-func NewSearchService(db *gorm.DB) *SearchService {
-	return &SearchService{db: db}
+func NewSearchService(db *gorm.DB, notificationService *NotificationService) *SearchService {
+	return &SearchService{db: db, notificationService: notificationService}
 }
 
 type SearchResponse struct {
@@ -32,12 +40,21 @@ type SearchUsersResponse struct {
 }
 
 type SearchPostsResponse struct {
-	Posts  []models.PostWithDetails `json:"posts"`
-	Limit  int                      `json:"limit"`
-	Offset int                      `json:"offset"`
-	Total  int64                    `json:"total"`
+	Posts []models.PostWithDetails `json:"posts"`
+	Limit int                      `json:"limit"`
+	PageInfo
 }
 
+type SearchSort string
+
+const (
+	SearchSortRelevance SearchSort = "relevance"
+	SearchSortRecent    SearchSort = "recent"
+)
+
+// Most code points of a post shown in a search result snippet
+const searchSnippetLength = 120
+
 type SearchHashtagsResponse struct {
 	Hashtags []models.Hashtag `json:"hashtags"`
 	Limit    int              `json:"limit"`
@@ -61,13 +78,13 @@ func (s *SearchService) SearchAll(query string, userID uuid.UUID, limit int) (*S
 	response := &SearchResponse{}
 
 	// Search users
-	users, err := s.SearchUsers(query, categoryLimit, 0)
+	users, err := s.SearchUsers(query, userID, categoryLimit, 0)
 	if err == nil {
 		response.Users = users.Users
 	}
 
 	// Search posts
-	posts, err := s.SearchPosts(query, userID, categoryLimit, 0)
+	posts, err := s.SearchPosts(query, userID, SearchSortRelevance, PageRequest{Limit: categoryLimit})
 	if err == nil {
 		response.Posts = posts.Posts
 	}
@@ -84,7 +101,7 @@ func (s *SearchService) SearchAll(query string, userID uuid.UUID, limit int) (*S
 }
 
 // SearchUsers searches for users by username or display name
-func (s *SearchService) SearchUsers(query string, limit, offset int) (*SearchUsersResponse, error) {
+func (s *SearchService) SearchUsers(query string, viewerID uuid.UUID, limit, offset int) (*SearchUsersResponse, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return &SearchUsersResponse{Users: []models.UserPublic{}, Limit: limit, Offset: offset}, nil
@@ -104,6 +121,7 @@ func (s *SearchService) SearchUsers(query string, limit, offset int) (*SearchUse
 	`, searchPattern, searchPattern).
 		Order(fmt.Sprintf("CASE WHEN username = '%s' THEN 1 WHEN LOWER(username) LIKE '%s' THEN 2 ELSE 3 END, created_at DESC", 
 			exactMatch, prefixMatch))
+	dbQuery = excludeBlocked(dbQuery, viewerID, "users.id")
 
 	// Get total count
 	if err := dbQuery.Model(&models.User{}).Count(&total).Error; err != nil {
@@ -127,6 +145,7 @@ func (s *SearchService) SearchUsers(query string, limit, offset int) (*SearchUse
 			Location:        user.Location,
 			Website:         user.Website,
 			IsVerified:      user.IsVerified,
+			IsPrivate:       user.IsPrivate,
 			CreatedAt:       user.CreatedAt,
 		}
 		userPublics = append(userPublics, userPublic)
@@ -140,70 +159,139 @@ func (s *SearchService) SearchUsers(query string, limit, offset int) (*SearchUse
 	}, nil
 }
 
-// SearchPosts searches for posts by content
-func (s *SearchService) SearchPosts(query string, userID uuid.UUID, limit, offset int) (*SearchPostsResponse, error) {
-	query = strings.TrimSpace(query)
-	if query == "" {
-		return &SearchPostsResponse{Posts: []models.PostWithDetails{}, Limit: limit, Offset: offset}, nil
+// SearchPosts searches posts with a query in the syntax described by
+// parseSearchQuery. Results are ranked by relevance unless sort is
+// SearchSortRecent or the query has no words, and each carries a snippet
+// of its content with the matching terms highlighted.
+func (s *SearchService) SearchPosts(query string, userID uuid.UUID, sort SearchSort, page PageRequest) (*SearchPostsResponse, error) {
+	if sort == "" {
+		sort = SearchSortRelevance
+	}
+	if sort != SearchSortRelevance && sort != SearchSortRecent {
+		return nil, errors.New("invalid sort")
 	}
 
-	var posts []models.Post
-	var total int64
+	search, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if search.empty() {
+		return &SearchPostsResponse{Posts: []models.PostWithDetails{}, Limit: page.Limit}, nil
+	}
 
-	// Use PostgreSQL full-text search and ILIKE for partial matches
-	searchPattern := "%" + strings.ToLower(query) + "%"
-	
-	dbQuery := s.db.Where(`
-		LOWER(content) LIKE ? AND is_draft = false AND is_public = true
-	`, searchPattern).
+	preference := sensitiveContentPreference(s.db, userID)
+
+	dbQuery := s.searchablePosts(search, userID, preference).
 		Preload("Author").
 		Preload("Media").
+		Preload("Mentions").
+		Preload("LinkCard").
 		Preload("OriginalPost").
 		Preload("OriginalPost.Author").
 		Preload("ParentPost").
-		Preload("ParentPost.Author").
-		Order("created_at DESC")
+		Preload("ParentPost.Author")
 
-	// Get total count
-	if err := dbQuery.Model(&models.Post{}).Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count posts: %w", err)
+	// A query of operators alone has nothing to rank by
+	var posts []models.Post
+	var pageInfo PageInfo
+	if sort == SearchSortRecent || search.tsquery == "" {
+		posts, pageInfo, err = paginate(dbQuery, page, "posts", false, postCursorKey)
+	} else {
+		posts, pageInfo, err = s.paginateByRank(dbQuery, page, search.tsquery)
 	}
-
-	// Get posts with pagination
-	if err := dbQuery.Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to search posts: %w", err)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to PostWithDetails
-	var postsWithDetails []models.PostWithDetails
-	for _, post := range posts {
-		postWithDetails := models.PostWithDetails{
-			Post: post,
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	markBlurredPosts(postsWithDetails, userID, preference)
+	terms := search.highlightTerms()
+	for i := range postsWithDetails {
+		highlights := text.FindHighlights(postsWithDetails[i].Content, terms)
+		snippet, highlights := text.Snippet(postsWithDetails[i].Content, highlights, searchSnippetLength)
+		if highlights == nil {
+			highlights = []text.Highlight{}
 		}
+		postsWithDetails[i].SearchMatch = &models.SearchMatch{Snippet: snippet, Highlights: highlights}
+	}
 
-		// Check if user liked this post
-		if userID != uuid.Nil {
-			var like models.Like
-			if err := s.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error; err == nil {
-				postWithDetails.IsLiked = true
-			}
+	return &SearchPostsResponse{
+		Posts:    postsWithDetails,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
+	}, nil
+}
 
-			// Check if user reposted this post
-			var repost models.Post
-			if err := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, post.ID, models.PostTypeRepost).First(&repost).Error; err == nil {
-				postWithDetails.IsReposted = true
-			}
+// searchablePosts returns the published posts matching search that the
+// user may see in search results
+func (s *SearchService) searchablePosts(search *postSearchQuery, userID uuid.UUID, preference models.SensitiveContentPreference) *gorm.DB {
+	query := search.apply(s.db.Model(&models.Post{}).Where("posts.is_draft = false AND posts.is_public = true"))
+	query = filterBlockedPosts(query, userID)
+	query = filterPrivatePosts(query, userID)
+	query = filterMutedPosts(query, userID, models.MuteScopeAll)
+	return filterSensitivePosts(query, userID, preference)
+}
+
+// paginateByRank fetches one page of query ordered by how well posts match
+// tsquery, best first. Ranked pages only go forwards, so there is no
+// PrevCursor.
+func (s *SearchService) paginateByRank(query *gorm.DB, page PageRequest, tsquery string) ([]models.Post, PageInfo, error) {
+	var info PageInfo
+
+	var c *rankCursor
+	if page.Cursor != "" {
+		decoded, err := decodeRankCursor(page.Cursor)
+		if err != nil {
+			return nil, info, err
 		}
+		c = decoded
+	}
 
-		postsWithDetails = append(postsWithDetails, postWithDetails)
+	if page.IncludeTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Model(&models.Post{}).Count(&total).Error; err != nil {
+			return nil, info, fmt.Errorf("failed to count items: %w", err)
+		}
+		info.Total = &total
 	}
 
-	return &SearchPostsResponse{
-		Posts:  postsWithDetails,
-		Limit:  limit,
-		Offset: offset,
-		Total:  total,
-	}, nil
+	rank := "ts_rank_cd(posts.search_vector, ?::tsquery)"
+	if c != nil {
+		query = query.Where("("+rank+", posts.created_at, posts.id) < (?, ?, ?)", tsquery, c.Rank, c.CreatedAt, c.ID)
+	}
+
+	var posts []models.Post
+	if err := query.
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                rank + " DESC, posts.created_at DESC, posts.id DESC",
+			Vars:               []interface{}{tsquery},
+			WithoutParentheses: true,
+		}}).
+		Limit(page.Limit + 1).
+		Find(&posts).Error; err != nil {
+		return nil, info, fmt.Errorf("failed to fetch items: %w", err)
+	}
+
+	if len(posts) > page.Limit {
+		posts = posts[:page.Limit]
+
+		last := posts[len(posts)-1]
+		var lastRank float32
+		if err := s.db.Model(&models.Post{}).
+			Select(rank, tsquery).
+			Where("id = ?", last.ID).
+			Scan(&lastRank).Error; err != nil {
+			return nil, info, fmt.Errorf("failed to rank post: %w", err)
+		}
+		info.NextCursor = encodeRankCursor(lastRank, last.CreatedAt, last.ID)
+	}
+
+	return posts, info, nil
 }
 
 // SearchHashtags searches for hashtags
@@ -213,10 +301,8 @@ func (s *SearchService) SearchHashtags(query string, limit, offset int) (*Search
 		return &SearchHashtagsResponse{Hashtags: []models.Hashtag{}, Limit: limit, Offset: offset}, nil
 	}
 
-	// Remove # if present
-	if strings.HasPrefix(query, "#") {
-		query = query[1:]
-	}
+	// Remove # if present and fold to the stored hashtag form
+	query = text.NormalizeHashtag(query)
 
 	var hashtags []models.Hashtag
 	var total int64
@@ -249,75 +335,55 @@ func (s *SearchService) SearchHashtags(query string, limit, offset int) (*Search
 }
 
 // GetHashtagPosts gets posts for a specific hashtag
-func (s *SearchService) GetHashtagPosts(hashtag string, userID uuid.UUID, limit, offset int) (*SearchPostsResponse, error) {
+func (s *SearchService) GetHashtagPosts(hashtag string, userID uuid.UUID, page PageRequest) (*SearchPostsResponse, error) {
 	hashtag = strings.TrimSpace(hashtag)
 	if hashtag == "" {
-		return &SearchPostsResponse{Posts: []models.PostWithDetails{}, Limit: limit, Offset: offset}, nil
+		return &SearchPostsResponse{Posts: []models.PostWithDetails{}, Limit: page.Limit}, nil
 	}
 
-	// Remove # if present
-	if strings.HasPrefix(hashtag, "#") {
-		hashtag = hashtag[1:]
-	}
+	// Remove # if present and fold to the stored hashtag form
+	hashtag = text.NormalizeHashtag(hashtag)
 
-	var posts []models.Post
-	var total int64
+	preference := sensitiveContentPreference(s.db, userID)
 
 	// Find hashtag first
 	var hashtagRecord models.Hashtag
 	if err := s.db.Where("LOWER(name) = LOWER(?)", hashtag).First(&hashtagRecord).Error; err != nil {
-		return &SearchPostsResponse{Posts: []models.PostWithDetails{}, Limit: limit, Offset: offset}, nil
+		return &SearchPostsResponse{Posts: []models.PostWithDetails{}, Limit: page.Limit}, nil
 	}
 
 	dbQuery := s.db.Joins("JOIN post_hashtags ON posts.id = post_hashtags.post_id").
 		Where("post_hashtags.hashtag_id = ? AND posts.is_draft = false AND posts.is_public = true", hashtagRecord.ID).
 		Preload("Author").
 		Preload("Media").
+		Preload("Mentions").
+		Preload("LinkCard").
 		Preload("OriginalPost").
 		Preload("OriginalPost.Author").
 		Preload("ParentPost").
-		Preload("ParentPost.Author").
-		Order("posts.created_at DESC")
-
-	// Get total count
-	if err := dbQuery.Model(&models.Post{}).Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count hashtag posts: %w", err)
-	}
-
-	// Get posts with pagination
-	if err := dbQuery.Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to get hashtag posts: %w", err)
+		Preload("ParentPost.Author")
+	dbQuery = filterBlockedPosts(dbQuery, userID)
+	dbQuery = filterPrivatePosts(dbQuery, userID)
+	dbQuery = filterMutedPosts(dbQuery, userID, models.MuteScopeAll)
+	dbQuery = filterSensitivePosts(dbQuery, userID, preference)
+
+	posts, pageInfo, err := paginate(dbQuery, page, "posts", false, postCursorKey)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to PostWithDetails
-	var postsWithDetails []models.PostWithDetails
-	for _, post := range posts {
-		postWithDetails := models.PostWithDetails{
-			Post: post,
-		}
-
-		// Check if user liked this post
-		if userID != uuid.Nil {
-			var like models.Like
-			if err := s.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error; err == nil {
-				postWithDetails.IsLiked = true
-			}
-
-			// Check if user reposted this post
-			var repost models.Post
-			if err := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, post.ID, models.PostTypeRepost).First(&repost).Error; err == nil {
-				postWithDetails.IsReposted = true
-			}
-		}
-
-		postsWithDetails = append(postsWithDetails, postWithDetails)
+	postsWithDetails, err := toPostsWithDetails(s.db, posts, userID)
+	if err != nil {
+		return nil, err
 	}
 
+	markBlurredPosts(postsWithDetails, userID, preference)
+
 	return &SearchPostsResponse{
-		Posts:  postsWithDetails,
-		Limit:  limit,
-		Offset: offset,
-		Total:  total,
+		Posts:    postsWithDetails,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
 	}, nil
 }
 
@@ -338,4 +404,36 @@ func (s *SearchService) GetTrendingHashtags(limit int) ([]models.Hashtag, error)
 	}
 
 	return hashtags, nil
-}
\ No newline at end of file
+}
+// BackfillSearchVectorsAsync indexes posts saved before search vectors
+// existed, in the background
+func (s *SearchService) BackfillSearchVectorsAsync() {
+	go func() {
+		if err := s.BackfillSearchVectors(); err != nil {
+			log.Printf("Search vector backfill failed: %v", err)
+		}
+	}()
+}
+
+// BackfillSearchVectors indexes every post without a search vector
+func (s *SearchService) BackfillSearchVectors() error {
+	for {
+		var posts []models.Post
+		if err := s.db.Unscoped().Select("id", "content").
+			Where("search_vector IS NULL").
+			Limit(searchBackfillBatchSize).
+			Find(&posts).Error; err != nil {
+			return fmt.Errorf("failed to find posts to index: %w", err)
+		}
+		if len(posts) == 0 {
+			return nil
+		}
+
+		for _, post := range posts {
+			if err := s.db.Unscoped().Model(&models.Post{}).Where("id = ?", post.ID).
+				UpdateColumn("search_vector", models.TSVector(text.SearchVector(post.Content))).Error; err != nil {
+				return fmt.Errorf("failed to index post: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Most recent queries kept in each user's search history
+const maxSearchHistory = 50
+
+// Longest query kept in search history or a saved search
+const maxStoredSearchQueryLength = 500
+
+// normalizeStoredSearchQuery collapses the whitespace in a query, so that
+// queries differing only in spacing are stored once
+func normalizeStoredSearchQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// RecordSearch adds a query to the user's search history, moving it back to
+// the top if it is already there. Only the newest maxSearchHistory queries
+// are kept.
+func (s *SearchService) RecordSearch(userID uuid.UUID, query string) error {
+	query = normalizeStoredSearchQuery(query)
+	if userID == uuid.Nil || query == "" || utf8.RuneCountInString(query) > maxStoredSearchQueryLength {
+		return nil
+	}
+
+	entry := models.SearchHistory{UserID: userID, Query: query, SearchedAt: time.Now()}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "query"}},
+			DoUpdates: clause.AssignmentColumns([]string{"searched_at"}),
+		}).Create(&entry).Error; err != nil {
+			return fmt.Errorf("failed to record search: %w", err)
+		}
+
+		if err := tx.Exec(`
+			DELETE FROM search_histories WHERE user_id = ? AND id NOT IN (
+				SELECT id FROM search_histories WHERE user_id = ?
+				ORDER BY searched_at DESC LIMIT ?
+			)`, userID, userID, maxSearchHistory).Error; err != nil {
+			return fmt.Errorf("failed to trim search history: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetSearchHistory returns the user's recent queries, newest first
+func (s *SearchService) GetSearchHistory(userID uuid.UUID) ([]models.SearchHistory, error) {
+	history := []models.SearchHistory{}
+	if err := s.db.Where("user_id = ?", userID).
+		Order("searched_at DESC").
+		Limit(maxSearchHistory).
+		Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch search history: %w", err)
+	}
+	return history, nil
+}
+
+// DeleteSearchHistoryEntry removes one query from the user's search history
+func (s *SearchService) DeleteSearchHistoryEntry(userID, entryID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", entryID, userID).Delete(&models.SearchHistory{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete search history entry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("search history entry not found")
+	}
+	return nil
+}
+
+// ClearSearchHistory removes every query from the user's search history
+func (s *SearchService) ClearSearchHistory(userID uuid.UUID) error {
+	if err := s.db.Where("user_id = ?", userID).Delete(&models.SearchHistory{}).Error; err != nil {
+		return fmt.Errorf("failed to clear search history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"digeon-backend/internal/text"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Most words, phrases and operators one post search query may contain
+const maxSearchQueryParts = 20
+
+// Dates in since: and until: are days in UTC
+const searchDateLayout = "2006-01-02"
+
+// A post links somewhere when its content has a URL, even before its link
+// card has been fetched
+const searchLinkPattern = `(https?://|www\.)[^[:space:]]`
+
+// postSearchQuery is a post search query compiled to SQL conditions
+type postSearchQuery struct {
+	// Full-text terms every result must contain; results are ranked by
+	// these
+	terms []text.SearchTerm
+	// tsquery literal of terms, "" when there are none
+	tsquery string
+	// Mentions and hashtags asked for, highlighted along with terms
+	highlights []text.SearchTerm
+	filters    []searchFilter
+
+	since, until *time.Time
+}
+
+// searchFilter is one condition on posts as parameterized SQL
+type searchFilter struct {
+	sql  string
+	args []interface{}
+}
+
+// searchQueryPart is one space-separated part of a search query
+type searchQueryPart struct {
+	text    string
+	negated bool
+	// Quoted text, never an operator
+	phrase bool
+	prefix bool
+}
+
+// parseSearchQuery compiles a post search query. Words must all appear in
+// the post; "quoted text" is a phrase and a word ending in * matches as a
+// prefix. These operators narrow the results:
+//
+//	from:user        posted by user
+//	to:user          replying to a post by user
+//	@user            mentioning user
+//	#tag             tagged with tag
+//	since:YYYY-MM-DD posted on or after the day, in UTC
+//	until:YYYY-MM-DD posted before the day, in UTC
+//	has:media        with attached media
+//	has:links        with a URL in the content
+//	min_likes:N      liked at least N times
+//	min_reposts:N    reposted at least N times
+//	filter:replies   replies only
+//	lang:xx          written in the language
+//
+// A leading - excludes posts matching a word, phrase or operator. Words
+// with a colon that isn't one of these, such as URLs, are searched as
+// text. User input only ever reaches the SQL as bound parameters.
+func parseSearchQuery(query string) (*postSearchQuery, error) {
+	parts, err := splitSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) > maxSearchQueryParts {
+		return nil, errors.New("invalid search query: at most 20 terms are allowed")
+	}
+
+	q := &postSearchQuery{}
+	for _, part := range parts {
+		if part.phrase {
+			q.addTerm(text.SearchTerm{Text: part.text, Prefix: part.prefix}, part.negated)
+			continue
+		}
+
+		if name, value, ok := strings.Cut(part.text, ":"); ok {
+			name = strings.ToLower(name)
+			if isSearchOperator(name) {
+				if err := q.addOperator(name, value, part.negated); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		switch {
+		case isSearchSigil(part.text, '@', '＠'):
+			username := normalizeSearchUsername(part.text)
+			q.addFilter(searchFilter{
+				sql:  "EXISTS (SELECT 1 FROM post_mentions WHERE post_mentions.post_id = posts.id AND LOWER(post_mentions.username) = ?)",
+				args: []interface{}{username},
+			}, part.negated)
+			if !part.negated {
+				q.highlights = append(q.highlights, text.SearchTerm{Text: username})
+			}
+		case isSearchSigil(part.text, '#', '＃'):
+			tag := text.NormalizeHashtag(part.text)
+			q.addFilter(searchFilter{
+				sql: `EXISTS (SELECT 1 FROM post_hashtags JOIN hashtags ON hashtags.id = post_hashtags.hashtag_id
+					WHERE post_hashtags.post_id = posts.id AND hashtags.name = ?)`,
+				args: []interface{}{tag},
+			}, part.negated)
+			if !part.negated {
+				q.highlights = append(q.highlights, text.SearchTerm{Text: tag})
+			}
+		default:
+			prefix := len(part.text) > 1 && strings.HasSuffix(part.text, "*")
+			q.addTerm(text.SearchTerm{Text: strings.TrimSuffix(part.text, "*"), Prefix: prefix}, part.negated)
+		}
+	}
+
+	if q.since != nil && q.until != nil && !q.since.Before(*q.until) {
+		return nil, errors.New("invalid search query: since must be before until")
+	}
+
+	q.tsquery = text.SearchQuery(q.terms)
+	return q, nil
+}
+
+// splitSearchQuery splits a query at spaces outside quotes
+func splitSearchQuery(query string) ([]searchQueryPart, error) {
+	var parts []searchQueryPart
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var part searchQueryPart
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			part.negated = true
+			i++
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("invalid search query: unclosed quote")
+			}
+			part.text = string(runes[i+1 : end])
+			part.phrase = true
+			i = end + 1
+			if i < len(runes) && runes[i] == '*' {
+				part.prefix = true
+				i++
+			}
+			parts = append(parts, part)
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+			end++
+		}
+		part.text = string(runes[i:end])
+		i = end
+		if part.text != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts, nil
+}
+
+func isSearchOperator(name string) bool {
+	switch name {
+	case "from", "to", "since", "until", "has", "min_likes", "min_reposts", "filter", "lang":
+		return true
+	}
+	return false
+}
+
+// isSearchSigil reports whether s is a sigil, in either width, followed by
+// a name
+func isSearchSigil(s string, sigil, fullWidth rune) bool {
+	for _, prefix := range []rune{sigil, fullWidth} {
+		if rest, ok := strings.CutPrefix(s, string(prefix)); ok && rest != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSearchUsername strips a leading @ and folds case the way
+// usernames are compared
+func normalizeSearchUsername(s string) string {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "@"), "＠")
+	return strings.ToLower(s)
+}
+
+func (q *postSearchQuery) addTerm(term text.SearchTerm, negated bool) {
+	if !negated {
+		q.terms = append(q.terms, term)
+		return
+	}
+	if tsquery := text.SearchTermQuery(term); tsquery != "" {
+		q.addFilter(searchFilter{sql: "posts.search_vector @@ ?::tsquery", args: []interface{}{tsquery}}, true)
+	}
+}
+
+func (q *postSearchQuery) addFilter(filter searchFilter, negated bool) {
+	if negated {
+		// A post lacking what the filter compares, such as a reply
+		// target, doesn't match it
+		filter.sql = "NOT COALESCE((" + filter.sql + "), false)"
+	}
+	q.filters = append(q.filters, filter)
+}
+
+func (q *postSearchQuery) addOperator(name, value string, negated bool) error {
+	if value == "" {
+		return errors.New("invalid search query: " + name + ": needs a value")
+	}
+
+	switch name {
+	case "from":
+		q.addFilter(searchFilter{
+			sql:  "posts.author_id IN (SELECT id FROM users WHERE LOWER(username) = ?)",
+			args: []interface{}{normalizeSearchUsername(value)},
+		}, negated)
+	case "to":
+		q.addFilter(searchFilter{
+			sql: `posts.parent_post_id IN (SELECT parent.id FROM posts parent
+				JOIN users ON users.id = parent.author_id WHERE LOWER(users.username) = ?)`,
+			args: []interface{}{normalizeSearchUsername(value)},
+		}, negated)
+	case "since", "until":
+		day, err := time.ParseInLocation(searchDateLayout, value, time.UTC)
+		if err != nil {
+			return errors.New("invalid search query: " + name + ": must be a date like 2025-01-31")
+		}
+		if name == "since" {
+			q.addFilter(searchFilter{sql: "posts.created_at >= ?", args: []interface{}{day}}, negated)
+			if !negated {
+				q.since = &day
+			}
+		} else {
+			q.addFilter(searchFilter{sql: "posts.created_at < ?", args: []interface{}{day}}, negated)
+			if !negated {
+				q.until = &day
+			}
+		}
+	case "has":
+		switch strings.ToLower(value) {
+		case "media":
+			q.addFilter(searchFilter{
+				sql: "EXISTS (SELECT 1 FROM media WHERE media.post_id = posts.id AND media.deleted_at IS NULL)",
+			}, negated)
+		case "links":
+			q.addFilter(searchFilter{
+				sql:  "(posts.link_card_id IS NOT NULL OR posts.content ~* ?)",
+				args: []interface{}{searchLinkPattern},
+			}, negated)
+		default:
+			return errors.New("invalid search query: has: must be media or links")
+		}
+	case "min_likes", "min_reposts":
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return errors.New("invalid search query: " + name + ": must be a whole number")
+		}
+		column := "posts.likes_count"
+		if name == "min_reposts" {
+			column = "posts.reposts_count"
+		}
+		q.addFilter(searchFilter{sql: column + " >= ?", args: []interface{}{count}}, negated)
+	case "filter":
+		if strings.ToLower(value) != "replies" {
+			return errors.New("invalid search query: filter: must be replies")
+		}
+		q.addFilter(searchFilter{sql: "posts.parent_post_id IS NOT NULL"}, negated)
+	case "lang":
+		lang, ok := text.NormalizeLanguage(value)
+		if !ok {
+			return errors.New("invalid search query: lang: must be a language code like ja")
+		}
+		q.addFilter(searchFilter{sql: "posts.lang = ?", args: []interface{}{lang}}, negated)
+	}
+	return nil
+}
+
+// empty reports whether the query has nothing to match posts on
+func (q *postSearchQuery) empty() bool {
+	return q.tsquery == "" && len(q.filters) == 0
+}
+
+// apply adds the query's conditions to a query on posts
+func (q *postSearchQuery) apply(query *gorm.DB) *gorm.DB {
+	if q.tsquery != "" {
+		query = query.Where("posts.search_vector @@ ?::tsquery", q.tsquery)
+	}
+	for _, filter := range q.filters {
+		query = query.Where(filter.sql, filter.args...)
+	}
+	return query
+}
+
+// highlightTerms returns the terms to highlight in matching posts
+func (q *postSearchQuery) highlightTerms() []text.SearchTerm {
+	return append(append([]text.SearchTerm{}, q.terms...), q.highlights...)
+}
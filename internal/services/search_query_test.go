@@ -0,0 +1,255 @@
+package services
+
+import (
+	"digeon-backend/internal/text"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []searchQueryPart
+	}{
+		{"empty", "  ", nil},
+		{"words", " go  lang ", []searchQueryPart{{text: "go"}, {text: "lang"}}},
+		{"negated word", "-spoiler", []searchQueryPart{{text: "spoiler", negated: true}}},
+		{"lone dash is a word", "a - b", []searchQueryPart{{text: "a"}, {text: "-"}, {text: "b"}}},
+		{"phrase", `"hello world"`, []searchQueryPart{{text: "hello world", phrase: true}}},
+		{"negated phrase", `-"hello world"`, []searchQueryPart{{text: "hello world", phrase: true, negated: true}}},
+		{"phrase prefix", `"hello wor"*`, []searchQueryPart{{text: "hello wor", phrase: true, prefix: true}}},
+		{"quote ends a word", `go"lang"`, []searchQueryPart{{text: "go"}, {text: "lang", phrase: true}}},
+		{"empty phrase", `""`, []searchQueryPart{{text: "", phrase: true}}},
+		{"operator", "from:alice", []searchQueryPart{{text: "from:alice"}}},
+		{"full-width space separates", "東京　大阪", []searchQueryPart{{text: "東京"}, {text: "大阪"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitSearchQuery(tt.query)
+			if err != nil {
+				t.Fatalf("splitSearchQuery(%q) error = %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSearchQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSearchQueryUnclosedQuote(t *testing.T) {
+	for _, query := range []string{`"hello`, `go "hello world`, `-"`, `"a" "b`} {
+		if _, err := splitSearchQuery(query); err == nil || err.Error() != "invalid search query: unclosed quote" {
+			t.Errorf("splitSearchQuery(%q) error = %v, want unclosed quote", query, err)
+		}
+	}
+}
+
+func TestParseSearchQueryTerms(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		tsquery string
+		filters int
+	}{
+		{"word", "Go", "('go')", 0},
+		{"words", "go lang", "('go') & ('lang')", 0},
+		{"prefix", "gol*", "('gol':*)", 0},
+		{"lone star is not a prefix", "*", "", 0},
+		{"phrase", `"hello world"`, "('hello' <-> 'world')", 0},
+		{"phrase prefix", `"hello wor"*`, "('hello' <-> 'wor':*)", 0},
+		{"CJK", "東京タワー", "('東京' <-> '京タ' <-> 'タワ' <-> 'ワー')", 0},
+		{"quote in a word", "o'reilly", "('o' <-> 'reilly')", 0},
+		{"backslash in a word", `back\slash`, "('back' <-> 'slash')", 0},
+		{"quote and backslash only", `'\`, "", 0},
+		{"unknown operator is text", "https://example.com", "('https' <-> 'example' <-> 'com')", 0},
+		{"negated word is a filter", "go -java", "('go')", 1},
+		{"negated word without letters is dropped", "go -!!!", "('go')", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseSearchQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseSearchQuery(%q) error = %v", tt.query, err)
+			}
+			if q.tsquery != tt.tsquery {
+				t.Errorf("tsquery = %q, want %q", q.tsquery, tt.tsquery)
+			}
+			if len(q.filters) != tt.filters {
+				t.Errorf("got %d filters, want %d", len(q.filters), tt.filters)
+			}
+		})
+	}
+}
+
+func TestParseSearchQueryOperators(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation(searchDateLayout, s, time.UTC)
+		return d
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		sql   string
+		args  []interface{}
+	}{
+		{"from", "from:Alice", "FROM users WHERE LOWER(username) = ?", []interface{}{"alice"}},
+		{"from with @", "from:@alice", "FROM users WHERE LOWER(username) = ?", []interface{}{"alice"}},
+		{"to", "to:bob", "posts.parent_post_id IN (SELECT parent.id", []interface{}{"bob"}},
+		{"mention", "@Carol", "FROM post_mentions", []interface{}{"carol"}},
+		{"full-width mention", "＠carol", "FROM post_mentions", []interface{}{"carol"}},
+		{"hashtag", "#Golang", "hashtags.name = ?", []interface{}{"golang"}},
+		{"full-width hashtag", "＃ＧＷ", "hashtags.name = ?", []interface{}{"gw"}},
+		{"since", "since:2025-01-31", "posts.created_at >= ?", []interface{}{day("2025-01-31")}},
+		{"until", "until:2025-02-01", "posts.created_at < ?", []interface{}{day("2025-02-01")}},
+		{"has media", "has:media", "FROM media", nil},
+		{"has links", "has:Links", "posts.link_card_id IS NOT NULL", []interface{}{searchLinkPattern}},
+		{"min likes", "min_likes:10", "posts.likes_count >= ?", []interface{}{10}},
+		{"min reposts", "min_reposts:0", "posts.reposts_count >= ?", []interface{}{0}},
+		{"replies", "filter:replies", "posts.parent_post_id IS NOT NULL", nil},
+		{"lang", "lang:JA", "posts.lang = ?", []interface{}{"ja"}},
+		{"operator names ignore case", "FROM:alice", "FROM users WHERE LOWER(username) = ?", []interface{}{"alice"}},
+	}
+
+	for _, tt := range tests {
+		for _, negated := range []bool{false, true} {
+			query := tt.query
+			name := tt.name
+			if negated {
+				query = "-" + query
+				name = "not " + name
+			}
+
+			t.Run(name, func(t *testing.T) {
+				q, err := parseSearchQuery(query)
+				if err != nil {
+					t.Fatalf("parseSearchQuery(%q) error = %v", query, err)
+				}
+				if q.tsquery != "" {
+					t.Errorf("tsquery = %q, want none", q.tsquery)
+				}
+				if len(q.filters) != 1 {
+					t.Fatalf("got %d filters, want 1", len(q.filters))
+				}
+
+				filter := q.filters[0]
+				if !strings.Contains(filter.sql, tt.sql) {
+					t.Errorf("filter SQL = %q, want it to contain %q", filter.sql, tt.sql)
+				}
+				if wrapped := strings.HasPrefix(filter.sql, "NOT COALESCE(("); wrapped != negated {
+					t.Errorf("filter SQL = %q, negated = %v", filter.sql, negated)
+				}
+				if !reflect.DeepEqual(filter.args, tt.args) {
+					t.Errorf("filter args = %v, want %v", filter.args, tt.args)
+				}
+			})
+		}
+	}
+}
+
+func TestParseSearchQueryHighlights(t *testing.T) {
+	q, err := parseSearchQuery("go @alice #東京 -@bob -#spoiler -java")
+	if err != nil {
+		t.Fatalf("parseSearchQuery() error = %v", err)
+	}
+
+	want := []text.SearchTerm{{Text: "go"}, {Text: "alice"}, {Text: "東京"}}
+	if got := q.highlightTerms(); !reflect.DeepEqual(got, want) {
+		t.Errorf("highlightTerms() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseSearchQueryDates(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{"since:2025-01-01 until:2025-01-02", ""},
+		{"since:2025-01-02 until:2025-01-02", "invalid search query: since must be before until"},
+		{"since:2025-02-01 until:2025-01-01", "invalid search query: since must be before until"},
+		// Negated dates don't bound the range
+		{"-since:2025-02-01 until:2025-01-01", ""},
+		{"since:2025-02-01 -until:2025-01-01", ""},
+		{"since:2025-1-1", "invalid search query: since: must be a date like 2025-01-31"},
+		{"until:2025-02-30", "invalid search query: until: must be a date like 2025-01-31"},
+		{"since:yesterday", "invalid search query: since: must be a date like 2025-01-31"},
+		{"until:2025-01-01T00:00:00Z", "invalid search query: until: must be a date like 2025-01-31"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseSearchQuery(tt.query)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("parseSearchQuery(%q) error = %v", tt.query, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("parseSearchQuery(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{`"unclosed`, "invalid search query: unclosed quote"},
+		{"from:", "invalid search query: from: needs a value"},
+		{"-to:", "invalid search query: to: needs a value"},
+		{"has:video", "invalid search query: has: must be media or links"},
+		{"min_likes:-1", "invalid search query: min_likes: must be a whole number"},
+		{"min_reposts:many", "invalid search query: min_reposts: must be a whole number"},
+		{"filter:media", "invalid search query: filter: must be replies"},
+		{"lang:12", "invalid search query: lang: must be a language code like ja"},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseSearchQuery(tt.query); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("parseSearchQuery(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSearchQueryPartLimit(t *testing.T) {
+	parts := make([]string, maxSearchQueryParts+1)
+	for i := range parts {
+		parts[i] = "word"
+	}
+	parts[0] = `"a phrase counts once"`
+	parts[1] = "-from:alice"
+
+	if _, err := parseSearchQuery(strings.Join(parts[:maxSearchQueryParts], " ")); err != nil {
+		t.Errorf("parseSearchQuery() with %d parts error = %v", maxSearchQueryParts, err)
+	}
+	if _, err := parseSearchQuery(strings.Join(parts, " ")); err == nil || err.Error() != "invalid search query: at most 20 terms are allowed" {
+		t.Errorf("parseSearchQuery() with %d parts error = %v", len(parts), err)
+	}
+}
+
+func TestParseSearchQueryEmpty(t *testing.T) {
+	tests := []struct {
+		query string
+		empty bool
+	}{
+		{"", true},
+		{"!!! ...", true},
+		{"-!!!", true},
+		{"go", false},
+		{"from:alice", false},
+		{"-go", false},
+	}
+
+	for _, tt := range tests {
+		q, err := parseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatalf("parseSearchQuery(%q) error = %v", tt.query, err)
+		}
+		if q.empty() != tt.empty {
+			t.Errorf("parseSearchQuery(%q).empty() = %v, want %v", tt.query, q.empty(), tt.empty)
+		}
+	}
+}
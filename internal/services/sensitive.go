@@ -0,0 +1,50 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sensitiveContentPreference returns how the viewer wants sensitive posts
+// shown. Anonymous viewers get them blurred.
+func sensitiveContentPreference(db *gorm.DB, viewerID uuid.UUID) models.SensitiveContentPreference {
+	if viewerID == uuid.Nil {
+		return models.SensitiveContentBlur
+	}
+
+	var user models.User
+	if err := db.Select("sensitive_content").First(&user, viewerID).Error; err != nil || !user.SensitiveContent.IsValid() {
+		return models.SensitiveContentBlur
+	}
+	return user.SensitiveContent
+}
+
+// filterSensitivePosts drops sensitive posts, and reposts or quotes of them,
+// when the viewer hides sensitive content. The viewer's own posts are kept.
+func filterSensitivePosts(query *gorm.DB, viewerID uuid.UUID, preference models.SensitiveContentPreference) *gorm.DB {
+	if preference != models.SensitiveContentHide {
+		return query
+	}
+	return query.Where(`
+		posts.author_id = ? OR (
+			posts.is_sensitive = false AND NOT EXISTS (
+				SELECT 1 FROM posts original
+				WHERE original.id = posts.original_post_id AND original.is_sensitive = true
+			)
+		)
+	`, viewerID)
+}
+
+// markBlurredPosts sets IsBlurred on the posts the viewer should see blurred
+func markBlurredPosts(posts []models.PostWithDetails, viewerID uuid.UUID, preference models.SensitiveContentPreference) {
+	if preference == models.SensitiveContentShow {
+		return
+	}
+	for i := range posts {
+		if posts[i].AuthorID != viewerID && posts[i].HasSensitiveContent() {
+			posts[i].IsBlurred = true
+		}
+	}
+}
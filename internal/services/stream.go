@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"digeon-backend/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	streamHeartbeatInterval = 25 * time.Second
+	// Most posts a client can ask for counts on when connecting
+	maxWatchedPosts = 100
+)
+
+type StreamService struct {
+	db                  *gorm.DB
+	broker              *EventBroker
+	postService         *PostService
+	notificationService *NotificationService
+}
+
+func NewStreamService(db *gorm.DB, broker *EventBroker, postService *PostService, notificationService *NotificationService) *StreamService {
+	return &StreamService{
+		db:                  db,
+		broker:              broker,
+		postService:         postService,
+		notificationService: notificationService,
+	}
+}
+
+// StreamSender writes one message to the client. An empty event is a
+// heartbeat with no data.
+type StreamSender func(event string, data interface{}) error
+
+type UnreadCountMessage struct {
+	Count int64 `json:"count"`
+}
+
+type PostCountsMessage struct {
+	PostID uuid.UUID `json:"post_id"`
+	PostCounts
+}
+
+// watchablePosts returns the posts among the first maxWatchedPosts of
+// postIDs that the user may see, so that counts are never sent for private
+// or blocked posts
+func (s *StreamService) watchablePosts(userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	if len(postIDs) > maxWatchedPosts {
+		postIDs = postIDs[:maxWatchedPosts]
+	}
+	watching := make(map[uuid.UUID]bool, len(postIDs))
+	if len(postIDs) == 0 {
+		return watching, nil
+	}
+
+	query := s.db.Model(&models.Post{}).Where("posts.id IN ? AND posts.is_draft = false", postIDs)
+	query = filterBlockedPosts(query, userID)
+	query = filterPrivatePosts(query, userID)
+
+	var visible []uuid.UUID
+	if err := query.Pluck("posts.id", &visible).Error; err != nil {
+		return nil, fmt.Errorf("failed to check watched posts: %w", err)
+	}
+	for _, postID := range visible {
+		watching[postID] = true
+	}
+	return watching, nil
+}
+
+// Stream sends the user's real-time events until ctx is done or send fails:
+// new posts for their home timeline, notifications, unread counts, and
+// like/repost counts for watched posts. Posts delivered by the stream are
+// watched automatically.
+func (s *StreamService) Stream(ctx context.Context, userID uuid.UUID, watched []uuid.UUID, send StreamSender) error {
+	sub := s.broker.Subscribe(userID)
+	defer sub.Close()
+
+	following, err := s.followingSet(userID)
+	if err != nil {
+		return err
+	}
+	preference := sensitiveContentPreference(s.db, userID)
+
+	watching, err := s.watchablePosts(userID, watched)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sendUnreadCount(userID, send); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			if err := send("", nil); err != nil {
+				return err
+			}
+
+		case event := <-sub.Events:
+			switch event.Type {
+			case EventTypeTimelinePost:
+				if event.AuthorID != userID && !following[event.AuthorID] {
+					continue
+				}
+				post, err := s.postService.GetPostWithDetails(event.PostID, userID)
+				if err != nil {
+					continue
+				}
+				if preference == models.SensitiveContentHide && post.AuthorID != userID && post.HasSensitiveContent() {
+					continue
+				}
+				if muted, err := isPostMuted(s.db, userID, post.ID, models.MuteScopeHome, models.MuteScopeAll); err != nil || muted {
+					continue
+				}
+				if len(watching) < maxWatchedPosts {
+					watching[post.ID] = true
+				}
+				if err := send("post", post); err != nil {
+					return err
+				}
+
+			case EventTypeNotification:
+				notification, err := s.notificationService.GetNotification(event.NotificationID, userID)
+				if err != nil {
+					continue
+				}
+				if err := send("notification", notification); err != nil {
+					return err
+				}
+				if err := s.sendUnreadCount(userID, send); err != nil {
+					return err
+				}
+
+			case EventTypeNotificationsRead:
+				if err := s.sendUnreadCount(userID, send); err != nil {
+					return err
+				}
+
+			case EventTypePostCounts:
+				if !watching[event.PostID] || event.Counts == nil {
+					continue
+				}
+				if err := send("post_counts", PostCountsMessage{PostID: event.PostID, PostCounts: *event.Counts}); err != nil {
+					return err
+				}
+
+			case EventTypeFollowsChanged:
+				if following, err = s.followingSet(userID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *StreamService) sendUnreadCount(userID uuid.UUID, send StreamSender) error {
+	count, err := s.notificationService.GetUnreadNotificationsCount(userID)
+	if err != nil {
+		return err
+	}
+	return send("unread_count", UnreadCountMessage{Count: count})
+}
+
+func (s *StreamService) followingSet(userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var followingIDs []uuid.UUID
+	if err := s.db.Model(&models.Follow{}).
+		Where("follower_id = ?", userID).
+		Pluck("following_id", &followingIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch following: %w", err)
+	}
+	return toIDSet(followingIDs), nil
+}
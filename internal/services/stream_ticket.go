@@ -0,0 +1,74 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"digeon-backend/internal/models"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How long a stream ticket can be used for after it is issued
+const streamTicketTTL = 30 * time.Second
+
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func hashStreamTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateStreamTicket issues a single-use ticket for opening the user's event
+// stream, so that clients never have to put their access token in a URL
+func (s *StreamService) CreateStreamTicket(userID uuid.UUID, username string) (*StreamTicketResponse, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate stream ticket: %w", err)
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(secret)
+
+	// Tickets nobody redeemed are cleaned up as new ones are issued
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.StreamTicket{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete expired stream tickets: %w", err)
+	}
+
+	record := models.StreamTicket{
+		TokenHash: hashStreamTicket(ticket),
+		UserID:    userID,
+		Username:  username,
+		ExpiresAt: time.Now().Add(streamTicketTTL),
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, fmt.Errorf("failed to create stream ticket: %w", err)
+	}
+
+	return &StreamTicketResponse{Ticket: ticket, ExpiresAt: record.ExpiresAt}, nil
+}
+
+// RedeemStreamTicket uses up a ticket, returning who it was issued to. A
+// ticket can only be redeemed once, even by concurrent requests.
+func (s *StreamService) RedeemStreamTicket(ticket string) (uuid.UUID, string, error) {
+	var redeemed struct {
+		UserID   uuid.UUID
+		Username string
+	}
+	result := s.db.Raw(
+		"DELETE FROM stream_tickets WHERE token_hash = ? AND expires_at > ? RETURNING user_id, username",
+		hashStreamTicket(ticket), time.Now(),
+	).Scan(&redeemed)
+	if result.Error != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to redeem stream ticket: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return uuid.Nil, "", errors.New("invalid stream ticket")
+	}
+	return redeemed.UserID, redeemed.Username, nil
+}
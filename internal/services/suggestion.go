@@ -0,0 +1,411 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Cached suggestions older than this are recomputed
+	suggestionCacheTTL = 6 * time.Hour
+	// Only users who asked for suggestions this recently are refreshed in
+	// the background
+	suggestionActiveWindow = 7 * 24 * time.Hour
+	// Number of suggestions cached per user
+	suggestionCacheSize = 50
+	// Upper bound on candidates scored per refresh
+	suggestionCandidateLimit = 500
+	// How far back hashtag interests and engagement count
+	suggestionSignalWindow = 30 * 24 * time.Hour
+	// Users refreshed per background run
+	suggestionRefreshBatch = 100
+
+	suggestionWeightMutual     = 1.0
+	suggestionWeightEngagement = 0.6
+	suggestionWeightHashtag    = 0.4
+)
+
+// SuggestedUser is a user the viewer might want to follow, with a short
+// explanation of why they were suggested
+type SuggestedUser struct {
+	models.UserPublic
+	Reason      string `json:"reason"`
+	MutualCount int    `json:"mutual_count"`
+}
+
+// suggestionCandidate is a user scored for suggestions together with the
+// signals it is scored on
+type suggestionCandidate struct {
+	CandidateID    uuid.UUID
+	MutualCount    int
+	SharedHashtags int
+	Engagements    int
+	CreatedAt      time.Time
+}
+
+func (c suggestionCandidate) score() float64 {
+	return suggestionWeightMutual*math.Log1p(float64(c.MutualCount)) +
+		suggestionWeightEngagement*math.Log1p(float64(c.Engagements)) +
+		suggestionWeightHashtag*math.Log1p(float64(c.SharedHashtags))
+}
+
+// GetSuggestedUsers returns users the user might want to follow, ranked by
+// mutual connections, engagement with their posts and shared hashtags.
+// Suggestions are cached; stale caches are served while a refresh runs in
+// the background. Follows, blocks, mutes and dismissals made since the
+// cache was built are applied when reading it.
+func (s *FollowService) GetSuggestedUsers(userID uuid.UUID, limit int) ([]SuggestedUser, error) {
+	var state models.SuggestionState
+	err := s.db.Where("user_id = ?", userID).First(&state).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := s.RefreshSuggestions(userID); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch suggestion state: %w", err)
+	default:
+		if time.Since(state.RefreshedAt) > suggestionCacheTTL {
+			s.RefreshSuggestionsAsync(userID)
+		}
+	}
+
+	if err := s.db.Model(&models.SuggestionState{}).Where("user_id = ?", userID).
+		Update("requested_at", time.Now()).Error; err != nil {
+		return nil, fmt.Errorf("failed to update suggestion state: %w", err)
+	}
+
+	var cached []models.UserSuggestion
+	query := s.db.Joins("JOIN users ON users.id = user_suggestions.suggested_user_id").
+		Where("user_suggestions.user_id = ?", userID)
+	if err := s.suggestableUsers(query, userID).
+		Preload("SuggestedUser").
+		Order("user_suggestions.rank").
+		Limit(limit).
+		Find(&cached).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch suggested users: %w", err)
+	}
+
+	suggestions := make([]SuggestedUser, 0, len(cached))
+	for _, suggestion := range cached {
+		suggestions = append(suggestions, SuggestedUser{
+			UserPublic:  toUserPublic(suggestion.SuggestedUser),
+			Reason:      suggestion.Reason,
+			MutualCount: suggestion.MutualCount,
+		})
+	}
+
+	return suggestions, nil
+}
+
+// DismissSuggestion stops suggesting a user to the user
+func (s *FollowService) DismissSuggestion(userID, dismissedUserID uuid.UUID) error {
+	if userID == dismissedUserID {
+		return errors.New("cannot dismiss yourself")
+	}
+
+	var dismissedUser models.User
+	if err := s.db.First(&dismissedUser, dismissedUserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	dismissal := models.DismissedSuggestion{
+		UserID:          userID,
+		DismissedUserID: dismissedUserID,
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&dismissal).Error; err != nil {
+		return fmt.Errorf("failed to dismiss suggestion: %w", err)
+	}
+
+	if err := s.db.Where("user_id = ? AND suggested_user_id = ?", userID, dismissedUserID).
+		Delete(&models.UserSuggestion{}).Error; err != nil {
+		return fmt.Errorf("failed to remove suggestion: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshSuggestionsAsync recomputes the user's suggestions in the
+// background unless a refresh for them is already running
+func (s *FollowService) RefreshSuggestionsAsync(userID uuid.UUID) {
+	if _, running := s.refreshingSuggestions.LoadOrStore(userID, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer s.refreshingSuggestions.Delete(userID)
+		if err := s.RefreshSuggestions(userID); err != nil {
+			log.Printf("Suggestion refresh for user %s failed: %v", userID, err)
+		}
+	}()
+}
+
+// RefreshSuggestions recomputes and caches the user's suggestions. When
+// there aren't enough connected candidates the rest are filled with the
+// newest accounts.
+func (s *FollowService) RefreshSuggestions(userID uuid.UUID) error {
+	candidates, err := s.suggestionCandidates(userID)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].score(), candidates[j].score()
+		if a != b {
+			return a > b
+		}
+		if !candidates[i].CreatedAt.Equal(candidates[j].CreatedAt) {
+			return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+		}
+		return candidates[i].CandidateID.String() < candidates[j].CandidateID.String()
+	})
+	if len(candidates) > suggestionCacheSize {
+		candidates = candidates[:suggestionCacheSize]
+	}
+
+	mutualNames, err := s.mutualFollowerNames(userID, candidates)
+	if err != nil {
+		return err
+	}
+
+	suggestions := make([]models.UserSuggestion, 0, suggestionCacheSize)
+	for i, c := range candidates {
+		suggestions = append(suggestions, models.UserSuggestion{
+			UserID:          userID,
+			SuggestedUserID: c.CandidateID,
+			Rank:            i,
+			Score:           c.score(),
+			Reason:          suggestionReason(c, mutualNames[c.CandidateID]),
+			MutualCount:     c.MutualCount,
+		})
+	}
+
+	if len(suggestions) < suggestionCacheSize {
+		picked := make([]uuid.UUID, 0, len(suggestions))
+		for _, suggestion := range suggestions {
+			picked = append(picked, suggestion.SuggestedUserID)
+		}
+
+		query := s.suggestableUsers(s.db.Model(&models.User{}), userID)
+		if len(picked) > 0 {
+			query = query.Where("users.id NOT IN ?", picked)
+		}
+		var newest []uuid.UUID
+		if err := query.Order("users.created_at DESC").
+			Limit(suggestionCacheSize-len(suggestions)).
+			Pluck("users.id", &newest).Error; err != nil {
+			return fmt.Errorf("failed to fetch new users: %w", err)
+		}
+		for _, id := range newest {
+			suggestions = append(suggestions, models.UserSuggestion{
+				UserID:          userID,
+				SuggestedUserID: id,
+				Rank:            len(suggestions),
+				Reason:          "New to Digeon",
+			})
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserSuggestion{}).Error; err != nil {
+			return fmt.Errorf("failed to clear suggestions: %w", err)
+		}
+		if len(suggestions) > 0 {
+			if err := tx.Omit("SuggestedUser").Create(&suggestions).Error; err != nil {
+				return fmt.Errorf("failed to cache suggestions: %w", err)
+			}
+		}
+
+		now := time.Now()
+		state := models.SuggestionState{UserID: userID, RefreshedAt: now, RequestedAt: now}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"refreshed_at"}),
+		}).Create(&state).Error; err != nil {
+			return fmt.Errorf("failed to update suggestion state: %w", err)
+		}
+		return nil
+	})
+}
+
+// StartSuggestionRefreshJob refreshes the stale suggestions of recently
+// active users every interval in the background
+func (s *FollowService) StartSuggestionRefreshJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.refreshStaleSuggestions(); err != nil {
+				log.Printf("Suggestion refresh failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *FollowService) refreshStaleSuggestions() error {
+	now := time.Now()
+
+	var userIDs []uuid.UUID
+	if err := s.db.Model(&models.SuggestionState{}).
+		Where("refreshed_at < ? AND requested_at > ?", now.Add(-suggestionCacheTTL), now.Add(-suggestionActiveWindow)).
+		Order("requested_at DESC").
+		Limit(suggestionRefreshBatch).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return fmt.Errorf("failed to find stale suggestions: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		if err := s.RefreshSuggestions(userID); err != nil {
+			log.Printf("Suggestion refresh for user %s failed: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// suggestionCandidates finds the users connected to the user through the
+// accounts they follow, the hashtags they use or like, or the posts they
+// engage with
+func (s *FollowService) suggestionCandidates(userID uuid.UUID) ([]suggestionCandidate, error) {
+	since := time.Now().Add(-suggestionSignalWindow)
+	following := s.db.Model(&models.Follow{}).Select("following_id").Where("follower_id = ?", userID)
+
+	// Accounts followed by the accounts the user follows
+	mutual := s.db.Model(&models.Follow{}).
+		Select("following_id AS candidate_id, COUNT(*) AS mutual_count").
+		Where("follower_id IN (?)", following).
+		Group("following_id")
+
+	// Authors of recent posts tagged with hashtags the user posted or liked
+	liked := s.db.Model(&models.Like{}).Select("post_id").Where("user_id = ? AND created_at > ?", userID, since)
+	interests := s.db.Table("post_hashtags").
+		Select("post_hashtags.hashtag_id").
+		Joins("JOIN posts ON posts.id = post_hashtags.post_id").
+		Where("posts.deleted_at IS NULL AND posts.created_at > ?", since).
+		Where("posts.author_id = ? OR posts.id IN (?)", userID, liked)
+	shared := s.db.Table("posts").
+		Select("posts.author_id AS candidate_id, COUNT(DISTINCT post_hashtags.hashtag_id) AS shared_hashtags").
+		Joins("JOIN post_hashtags ON post_hashtags.post_id = posts.id").
+		Where("post_hashtags.hashtag_id IN (?)", interests).
+		Where("posts.deleted_at IS NULL AND posts.is_draft = false AND posts.created_at > ?", since).
+		Group("posts.author_id")
+
+	// Authors whose posts the user liked or replied to, e.g. after seeing
+	// them reposted
+	engagement := s.db.Raw(`
+		SELECT author_id AS candidate_id, COUNT(*) AS engagements FROM (
+			SELECT posts.author_id FROM likes
+			JOIN posts ON posts.id = likes.post_id
+			WHERE likes.user_id = ? AND likes.created_at > ? AND likes.deleted_at IS NULL
+			UNION ALL
+			SELECT parent.author_id FROM posts reply
+			JOIN posts parent ON parent.id = reply.parent_post_id
+			WHERE reply.author_id = ? AND reply.created_at > ? AND reply.deleted_at IS NULL
+		) engaged
+		GROUP BY author_id
+	`, userID, since, userID, since)
+
+	query := s.db.Table("users").
+		Select(`users.id AS candidate_id, users.created_at,
+			COALESCE(mutual.mutual_count, 0) AS mutual_count,
+			COALESCE(shared.shared_hashtags, 0) AS shared_hashtags,
+			COALESCE(engagement.engagements, 0) AS engagements`).
+		Joins("LEFT JOIN (?) mutual ON mutual.candidate_id = users.id", mutual).
+		Joins("LEFT JOIN (?) shared ON shared.candidate_id = users.id", shared).
+		Joins("LEFT JOIN (?) engagement ON engagement.candidate_id = users.id", engagement).
+		Where("(mutual.candidate_id IS NOT NULL OR shared.candidate_id IS NOT NULL OR engagement.candidate_id IS NOT NULL)")
+
+	var candidates []suggestionCandidate
+	if err := s.suggestableUsers(query, userID).
+		Order("mutual_count DESC, engagements DESC, shared_hashtags DESC").
+		Limit(suggestionCandidateLimit).
+		Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch suggestion candidates: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// mutualFollowerNames returns, for each candidate with mutual connections,
+// the username of the account the user follows that most recently followed
+// the candidate
+func (s *FollowService) mutualFollowerNames(userID uuid.UUID, candidates []suggestionCandidate) (map[uuid.UUID]string, error) {
+	var candidateIDs []uuid.UUID
+	for _, c := range candidates {
+		if c.MutualCount > 0 {
+			candidateIDs = append(candidateIDs, c.CandidateID)
+		}
+	}
+	names := make(map[uuid.UUID]string, len(candidateIDs))
+	if len(candidateIDs) == 0 {
+		return names, nil
+	}
+
+	var rows []struct {
+		CandidateID uuid.UUID
+		Username    string
+	}
+	following := s.db.Model(&models.Follow{}).Select("following_id").Where("follower_id = ?", userID)
+	if err := s.db.Raw(`
+		SELECT DISTINCT ON (follows.following_id) follows.following_id AS candidate_id, users.username
+		FROM follows
+		JOIN users ON users.id = follows.follower_id
+		WHERE follows.deleted_at IS NULL AND follows.following_id IN ? AND follows.follower_id IN (?)
+		ORDER BY follows.following_id, follows.created_at DESC
+	`, candidateIDs, following).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch mutual followers: %w", err)
+	}
+
+	for _, row := range rows {
+		names[row.CandidateID] = row.Username
+	}
+	return names, nil
+}
+
+// suggestableUsers restricts a query joined on users to accounts that can
+// be suggested to the user: active, not the user, not already followed or
+// requested, not dismissed, and not blocked or muted
+func (s *FollowService) suggestableUsers(query *gorm.DB, userID uuid.UUID) *gorm.DB {
+	following := s.db.Model(&models.Follow{}).Select("following_id").Where("follower_id = ?", userID)
+	requested := s.db.Model(&models.FollowRequest{}).Select("target_id").Where("requester_id = ?", userID)
+	dismissed := s.db.Model(&models.DismissedSuggestion{}).Select("dismissed_user_id").Where("user_id = ?", userID)
+
+	query = query.Where("users.id <> ? AND users.is_active = true AND users.deleted_at IS NULL", userID).
+		Where("users.id NOT IN (?)", following).
+		Where("users.id NOT IN (?)", requested).
+		Where("users.id NOT IN (?)", dismissed)
+	query = excludeBlocked(query, userID, "users.id")
+	return excludeMutedUsers(query, userID, "users.id")
+}
+
+// suggestionReason explains a suggestion using its strongest signal
+func suggestionReason(c suggestionCandidate, mutualName string) string {
+	switch {
+	case c.MutualCount > 0 && mutualName != "":
+		switch others := c.MutualCount - 1; others {
+		case 0:
+			return fmt.Sprintf("Followed by @%s", mutualName)
+		case 1:
+			return fmt.Sprintf("Followed by @%s and 1 other", mutualName)
+		default:
+			return fmt.Sprintf("Followed by @%s and %d others", mutualName, others)
+		}
+	case c.Engagements > 0:
+		return "You interacted with their posts"
+	case c.SharedHashtags > 0:
+		return "Posts about hashtags you use"
+	}
+	return "Suggested for you"
+}
@@ -2,124 +2,490 @@ package services
 
 import (
 	"digeon-backend/internal/models"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"strconv"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const defaultFanOutThreshold = 10000
+
 type TimelineService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	store TimelineStore
+
+	// Authors with at least this many followers are not fanned out on
+	// write; their posts are merged into home timelines at read time
+	fanOutThreshold int64
+
+	rankingWeights RankingWeights
 }
 
-func NewTimelineService(db *gorm.DB) *TimelineService {
-	return &TimelineService{db: db}
+func NewTimelineService(db *gorm.DB, store TimelineStore) *TimelineService {
+	fanOutThreshold, err := strconv.ParseInt(os.Getenv("TIMELINE_FANOUT_THRESHOLD"), 10, 64)
+	if err != nil || fanOutThreshold <= 0 {
+		fanOutThreshold = defaultFanOutThreshold
+	}
+
+	return &TimelineService{
+		db:              db,
+		store:           store,
+		fanOutThreshold: fanOutThreshold,
+		rankingWeights:  NewRankingWeights(),
+	}
 }
 
 type TimelineResponse struct {
 	Posts  []models.PostWithDetails `json:"posts"`
 	Limit  int                      `json:"limit"`
-	Offset int                      `json:"offset"`
-	Total  int64                    `json:"total"`
+	Offset int                      `json:"offset,omitempty"` // only used by the trending timeline
+	PageInfo
 }
 
-// GetHomeTimeline returns posts from users that the current user follows
-func (s *TimelineService) GetHomeTimeline(userID uuid.UUID, limit, offset int) (*TimelineResponse, error) {
-	var posts []models.Post
-	var total int64
+// maxNewPostsCount caps GetNewHomePostsCount so it stays cheap
+const maxNewPostsCount = 1000
+
+// GetHomeTimeline returns posts from users that the current user follows.
+// Pages are read from the materialized timeline when possible and from the
+// posts table otherwise. A non-nil sinceID limits the timeline to posts
+// newer than that post.
+func (s *TimelineService) GetHomeTimeline(userID uuid.UUID, page PageRequest, sinceID uuid.UUID) (*TimelineResponse, error) {
+	preference := sensitiveContentPreference(s.db, userID)
+
+	if sinceID != uuid.Nil {
+		since, err := s.sinceFilter(sinceID)
+		if err != nil {
+			return nil, err
+		}
+		query := since(s.homePosts(userID, preference, true))
+		return s.paginatePosts(query, page, false, userID, preference)
+	}
+
+	if s.store != nil && !page.IncludeTotal {
+		response, ok, err := s.getMaterializedHomeTimeline(userID, page, preference)
+		if err != nil {
+			if err.Error() == "invalid cursor" {
+				return nil, err
+			}
+			log.Printf("Materialized timeline for user %s failed, falling back: %v", userID, err)
+		} else if ok {
+			return response, nil
+		}
+	}
+
+	query := s.homePosts(userID, preference, true)
+
+	return s.paginatePosts(query, page, false, userID, preference)
+}
+
+// GetListTimeline returns posts by the members of a list
+func (s *TimelineService) GetListTimeline(listID, userID uuid.UUID, page PageRequest) (*TimelineResponse, error) {
+	if _, err := findVisibleList(s.db, listID, userID); err != nil {
+		return nil, err
+	}
+
+	preference := sensitiveContentPreference(s.db, userID)
+
+	query := s.visiblePosts(userID, preference).Where(`
+		posts.author_id IN (
+			SELECT user_id FROM list_members
+			WHERE list_id = ? AND deleted_at IS NULL
+		)
+	`, listID)
+
+	return s.paginatePosts(query, page, false, userID, preference)
+}
+
+// GetNewHomePostsCount counts the home timeline posts newer than sinceID,
+// up to maxNewPostsCount
+func (s *TimelineService) GetNewHomePostsCount(userID, sinceID uuid.UUID) (int64, error) {
+	preference := sensitiveContentPreference(s.db, userID)
+
+	since, err := s.sinceFilter(sinceID)
+	if err != nil {
+		return 0, err
+	}
+	newPosts := since(s.homePosts(userID, preference, false)).
+		Select("posts.id").
+		Limit(maxNewPostsCount)
+
+	var count int64
+	if err := s.db.Table("(?) AS new_posts", newPosts).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count new posts: %w", err)
+	}
+	return count, nil
+}
+
+// homePosts returns a query over the posts that belong on the user's home
+// timeline: visible posts by the accounts they follow and by themselves
+func (s *TimelineService) homePosts(userID uuid.UUID, preference models.SensitiveContentPreference, withRelations bool) *gorm.DB {
+	query := s.publishedPosts(userID, preference)
+	query = excludeMutedWords(query, userID, "posts.id", models.MuteScopeHome)
+	if withRelations {
+		query = preloadPostRelations(query)
+	}
 
 	// Get posts from followed users + user's own posts
-	query := s.db.Where(`
-		author_id IN (
+	return query.Where(`
+		posts.author_id IN (
 			SELECT following_id FROM follows 
 			WHERE follower_id = ? AND deleted_at IS NULL
-		) OR author_id = ?
-	`, userID, userID).
-		Where("is_draft = false AND is_public = true").
-		Preload("Author").
-		Preload("Media").
-		Preload("OriginalPost").
-		Preload("OriginalPost.Author").
-		Preload("ParentPost").
-		Preload("ParentPost.Author").
-		Order("created_at DESC")
+		) OR posts.author_id = ?
+	`, userID, userID)
+}
 
-	// Get total count
-	if err := query.Model(&models.Post{}).Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count posts: %w", err)
+// sinceFilter returns a scope keeping the posts newer than sinceID. The post
+// may have been deleted since the client saw it.
+func (s *TimelineService) sinceFilter(sinceID uuid.UUID) (func(*gorm.DB) *gorm.DB, error) {
+	var since models.Post
+	if err := s.db.Unscoped().Select("id", "created_at").First(&since, sinceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("post not found")
+		}
+		return nil, fmt.Errorf("failed to find post: %w", err)
 	}
 
-	// Get posts with pagination
-	if err := query.Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Where("(posts.created_at, posts.id) > (?, ?)", since.CreatedAt, since.ID)
+	}, nil
+}
+
+// getMaterializedHomeTimeline builds a home timeline page from the timeline
+// store plus the posts of followed high-follower authors. ok is false when
+// the store cannot answer the page on its own, e.g. when a user scrolls
+// past the oldest stored entry.
+func (s *TimelineService) getMaterializedHomeTimeline(userID uuid.UUID, page PageRequest, preference models.SensitiveContentPreference) (*TimelineResponse, bool, error) {
+	c, err := decodePageCursor(page)
+	if err != nil {
+		return nil, false, err
 	}
 
-	// Convert to PostWithDetails
-	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID)
+	built, err := s.store.Built(userID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if !built {
+		if err := s.RebuildHomeTimeline(userID); err != nil {
+			return nil, false, err
+		}
 	}
 
-	return &TimelineResponse{
-		Posts:  postsWithDetails,
-		Limit:  limit,
-		Offset: offset,
-		Total:  total,
-	}, nil
-}
+	forward := isForward(c)
+	var from *models.TimelineEntry
+	if c != nil {
+		from = &models.TimelineEntry{PostID: c.ID, CreatedAt: c.CreatedAt}
+	}
 
-// GetExploreTimeline returns all public posts
-func (s *TimelineService) GetExploreTimeline(userID uuid.UUID, limit, offset int) (*TimelineResponse, error) {
+	// Entries can point at posts that were since deleted or are filtered
+	// out for this viewer, so keep reading until the page is full
+	window := page.Limit + 1
 	var posts []models.Post
-	var total int64
+	hasMore := false
+	for attempt := 0; len(posts) < window; attempt++ {
+		stored, err := s.store.Range(userID, from, !forward, window)
+		if err != nil {
+			return nil, false, err
+		}
 
-	query := s.db.Where("is_draft = false AND is_public = true").
-		Preload("Author").
-		Preload("Media").
-		Preload("OriginalPost").
-		Preload("OriginalPost.Author").
-		Preload("ParentPost").
-		Preload("ParentPost.Author").
-		Order("created_at DESC")
+		// The store is trimmed, so running out of entries while scrolling
+		// back in time doesn't mean there are no older posts
+		if forward && from != nil && len(stored) < window {
+			return nil, false, nil
+		}
 
-	// Get total count
-	if err := query.Model(&models.Post{}).Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count posts: %w", err)
+		fannedIn, err := s.fanInEntries(userID, from, !forward, window)
+		if err != nil {
+			return nil, false, err
+		}
+
+		candidates, discarded := mergeTimelineEntries(stored, fannedIn, !forward, window)
+		if len(candidates) == 0 {
+			break
+		}
+
+		visible, err := s.loadTimelinePosts(userID, candidates, preference)
+		if err != nil {
+			return nil, false, err
+		}
+		posts = append(posts, visible...)
+		from = &candidates[len(candidates)-1]
+
+		// Done once both sources ran out, unless the merge left entries
+		// for the next round
+		if !discarded && len(stored) < window && len(fannedIn) < window {
+			break
+		}
+		if attempt == 2 {
+			hasMore = true
+			break
+		}
 	}
 
-	// Get posts with pagination
-	if err := query.Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	if len(posts) > page.Limit {
+		posts = posts[:page.Limit]
+		hasMore = true
 	}
+	posts, pageInfo := finishPage(posts, page, c, hasMore, postCursorKey)
 
 	// Convert to PostWithDetails
-	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID)
+	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID, preference)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	return &TimelineResponse{
-		Posts:  postsWithDetails,
-		Limit:  limit,
-		Offset: offset,
-		Total:  total,
-	}, nil
+		Posts:    postsWithDetails,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
+	}, true, nil
+}
+
+// loadTimelinePosts fetches the visible posts among entries, in entry order
+func (s *TimelineService) loadTimelinePosts(userID uuid.UUID, entries []models.TimelineEntry, preference models.SensitiveContentPreference) ([]models.Post, error) {
+	postIDs := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		postIDs = append(postIDs, entry.PostID)
+	}
+	return loadPostsInOrder(s.homePosts(userID, preference, true), postIDs)
+}
+
+// loadPostsInOrder fetches the posts among postIDs that query matches,
+// keeping the order of postIDs
+func loadPostsInOrder(query *gorm.DB, postIDs []uuid.UUID) ([]models.Post, error) {
+	var posts []models.Post
+	if err := query.
+		Where("posts.id IN ?", postIDs).
+		Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	ordered := make([]models.Post, 0, len(posts))
+	for _, postID := range postIDs {
+		if post, ok := byID[postID]; ok {
+			ordered = append(ordered, post)
+		}
+	}
+	return ordered, nil
+}
+
+// fanInEntries returns entries for posts by followed authors that are too
+// popular to fan out on write, in the same order and window as Range
+func (s *TimelineService) fanInEntries(userID uuid.UUID, from *models.TimelineEntry, newer bool, limit int) ([]models.TimelineEntry, error) {
+	query := s.db.Model(&models.Post{}).
+		Select("id AS post_id, author_id, created_at").
+		Where("author_id IN (?)", s.fanInAuthors(userID)).
+		Where("is_draft = false AND is_public = true")
+
+	if newer {
+		if from != nil {
+			query = query.Where("(created_at, id) > (?, ?)", from.CreatedAt, from.PostID)
+		}
+		query = query.Order("created_at ASC, id ASC")
+	} else {
+		if from != nil {
+			query = query.Where("(created_at, id) < (?, ?)", from.CreatedAt, from.PostID)
+		}
+		query = query.Order("created_at DESC, id DESC")
+	}
+
+	var entries []models.TimelineEntry
+	if err := query.Limit(limit).Scan(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch fan-in posts: %w", err)
+	}
+	return entries, nil
+}
+
+// fanInAuthors is a subquery selecting the accounts the user follows whose
+// posts are not fanned out on write
+func (s *TimelineService) fanInAuthors(userID uuid.UUID) *gorm.DB {
+	return s.db.Model(&models.Follow{}).
+		Select("following_id").
+		Joins("JOIN users ON users.id = follows.following_id").
+		Where("follows.follower_id = ? AND users.followers_count >= ?", userID, s.fanOutThreshold)
+}
+
+// mergeTimelineEntries merges two entry lists sorted in the same direction,
+// dropping duplicates and keeping at most limit entries. discarded reports
+// whether entries were left out to keep to the limit.
+func mergeTimelineEntries(a, b []models.TimelineEntry, ascending bool, limit int) (merged []models.TimelineEntry, discarded bool) {
+	merged = make([]models.TimelineEntry, 0, min(len(a)+len(b), limit))
+	seen := make(map[uuid.UUID]bool, len(a)+len(b))
+
+	i, j := 0, 0
+	for len(merged) < limit && (i < len(a) || j < len(b)) {
+		var next models.TimelineEntry
+		switch {
+		case j >= len(b):
+			next, i = a[i], i+1
+		case i >= len(a):
+			next, j = b[j], j+1
+		case entryBefore(a[i], b[j]) == ascending:
+			next, i = a[i], i+1
+		default:
+			next, j = b[j], j+1
+		}
+
+		if seen[next.PostID] {
+			continue
+		}
+		seen[next.PostID] = true
+		merged = append(merged, next)
+	}
+
+	for _, rest := range [][]models.TimelineEntry{a[i:], b[j:]} {
+		for _, entry := range rest {
+			if !seen[entry.PostID] {
+				return merged, true
+			}
+		}
+	}
+	return merged, false
+}
+
+// DistributePostAsync pushes a new post onto home timelines in the background
+func (s *TimelineService) DistributePostAsync(postID uuid.UUID) {
+	if s.store == nil {
+		return
+	}
+	go func() {
+		if err := s.DistributePost(postID); err != nil {
+			log.Printf("Timeline fan-out for post %s failed: %v", postID, err)
+		}
+	}()
+}
+
+// DistributePost pushes a post onto the materialized timelines of its
+// author and their followers. Posts by authors at or above the fan-out
+// threshold only go to the author's own timeline.
+func (s *TimelineService) DistributePost(postID uuid.UUID) error {
+	// Reload so the entry carries the timestamp as stored by the database
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		return fmt.Errorf("failed to find post: %w", err)
+	}
+	if post.IsDraft || !post.IsPublic {
+		return nil
+	}
+
+	recipients := []uuid.UUID{post.AuthorID}
+
+	var author models.User
+	if err := s.db.Select("followers_count").First(&author, post.AuthorID).Error; err != nil {
+		return fmt.Errorf("failed to find author: %w", err)
+	}
+	if author.FollowersCount < s.fanOutThreshold {
+		var followerIDs []uuid.UUID
+		if err := s.db.Model(&models.Follow{}).
+			Where("following_id = ?", post.AuthorID).
+			Pluck("follower_id", &followerIDs).Error; err != nil {
+			return fmt.Errorf("failed to fetch followers: %w", err)
+		}
+		recipients = append(recipients, followerIDs...)
+	}
+
+	return s.store.Push(recipients, models.TimelineEntry{
+		PostID:    post.ID,
+		AuthorID:  post.AuthorID,
+		CreatedAt: post.CreatedAt,
+	})
+}
+
+// BackfillFollowAsync copies the followed user's recent posts into the
+// follower's timeline in the background
+func (s *TimelineService) BackfillFollowAsync(followerID, followingID uuid.UUID) {
+	if s.store == nil {
+		return
+	}
+	go func() {
+		if err := s.BackfillFollow(followerID, followingID); err != nil {
+			log.Printf("Timeline backfill for user %s failed: %v", followerID, err)
+		}
+	}()
+}
+
+func (s *TimelineService) BackfillFollow(followerID, followingID uuid.UUID) error {
+	var entries []models.TimelineEntry
+	if err := s.db.Model(&models.Post{}).
+		Select("id AS post_id, author_id, created_at").
+		Where("author_id = ? AND is_draft = false AND is_public = true", followingID).
+		Order("created_at DESC, id DESC").
+		Limit(timelineMaxEntries()).
+		Scan(&entries).Error; err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	return s.store.Backfill(followerID, entries)
+}
+
+// RemoveFollow drops the unfollowed user's posts from the follower's timeline
+func (s *TimelineService) RemoveFollow(followerID, followingID uuid.UUID) error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.RemoveAuthor(followerID, followingID)
+}
+
+// RebuildHomeTimeline rebuilds the user's materialized timeline from the
+// posts table
+func (s *TimelineService) RebuildHomeTimeline(userID uuid.UUID) error {
+	var entries []models.TimelineEntry
+	if err := s.db.Model(&models.Post{}).
+		Select("id AS post_id, author_id, created_at").
+		Where(`
+			author_id IN (
+				SELECT following_id FROM follows
+				WHERE follower_id = ? AND deleted_at IS NULL
+			) OR author_id = ?
+		`, userID, userID).
+		Where("is_draft = false AND is_public = true").
+		Order("created_at DESC, id DESC").
+		Limit(timelineMaxEntries()).
+		Scan(&entries).Error; err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	return s.store.Replace(userID, entries)
+}
+
+// GetExploreTimeline returns all public posts
+func (s *TimelineService) GetExploreTimeline(userID uuid.UUID, page PageRequest) (*TimelineResponse, error) {
+	preference := sensitiveContentPreference(s.db, userID)
+
+	query := s.visiblePosts(userID, preference)
+
+	return s.paginatePosts(query, page, false, userID, preference)
 }
 
 // GetTrendingTimeline returns posts sorted by engagement (likes + reposts + comments)
 func (s *TimelineService) GetTrendingTimeline(userID uuid.UUID, limit, offset int) (*TimelineResponse, error) {
 	var posts []models.Post
 	var total int64
+	preference := sensitiveContentPreference(s.db, userID)
 
 	query := s.db.Where("is_draft = false AND is_public = true").
 		Where("created_at > NOW() - INTERVAL '7 days'"). // Only posts from last 7 days
 		Preload("Author").
 		Preload("Media").
+		Preload("Mentions").
+		Preload("LinkCard").
 		Preload("OriginalPost").
 		Preload("OriginalPost.Author").
 		Preload("ParentPost").
 		Preload("ParentPost.Author").
 		Order("(likes_count + reposts_count + comments_count) DESC, created_at DESC")
+	query = filterBlockedPosts(query, userID)
+	query = filterPrivatePosts(query, userID)
+	query = filterMutedPosts(query, userID, models.MuteScopeAll)
+	query = filterSensitivePosts(query, userID, preference)
 
 	// Get total count
 	if err := query.Model(&models.Post{}).Count(&total).Error; err != nil {
@@ -132,80 +498,85 @@ func (s *TimelineService) GetTrendingTimeline(userID uuid.UUID, limit, offset in
 	}
 
 	// Convert to PostWithDetails
-	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID)
+	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID, preference)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TimelineResponse{
-		Posts:  postsWithDetails,
-		Limit:  limit,
-		Offset: offset,
-		Total:  total,
+		Posts:    postsWithDetails,
+		Limit:    limit,
+		Offset:   offset,
+		PageInfo: PageInfo{Total: &total},
 	}, nil
 }
 
-// GetPostReplies returns replies to a specific post
-func (s *TimelineService) GetPostReplies(postID, userID uuid.UUID, limit, offset int) (*TimelineResponse, error) {
-	var posts []models.Post
-	var total int64
+// GetPostReplies returns replies to a specific post, oldest first
+func (s *TimelineService) GetPostReplies(postID, userID uuid.UUID, page PageRequest) (*TimelineResponse, error) {
+	preference := sensitiveContentPreference(s.db, userID)
+
+	query := s.visiblePosts(userID, preference).Where("posts.parent_post_id = ?", postID)
+
+	return s.paginatePosts(query, page, true, userID, preference)
+}
+
+// visiblePosts returns a query over the published posts the viewer may see,
+// with the relations timelines render preloaded
+func (s *TimelineService) visiblePosts(userID uuid.UUID, preference models.SensitiveContentPreference) *gorm.DB {
+	return preloadPostRelations(s.publishedPosts(userID, preference))
+}
+
+// publishedPosts returns a query over the published posts the viewer may see
+func (s *TimelineService) publishedPosts(userID uuid.UUID, preference models.SensitiveContentPreference) *gorm.DB {
+	query := s.db.Model(&models.Post{}).
+		Where("posts.is_draft = false AND posts.is_public = true")
+	query = filterBlockedPosts(query, userID)
+	query = filterPrivatePosts(query, userID)
+	query = filterMutedPosts(query, userID, models.MuteScopeAll)
+	return filterSensitivePosts(query, userID, preference)
+}
 
-	query := s.db.Where("parent_post_id = ? AND is_draft = false AND is_public = true", postID).
+// preloadPostRelations preloads the relations timelines render
+func preloadPostRelations(query *gorm.DB) *gorm.DB {
+	return query.
 		Preload("Author").
 		Preload("Media").
+		Preload("Mentions").
+		Preload("LinkCard").
 		Preload("OriginalPost").
 		Preload("OriginalPost.Author").
 		Preload("ParentPost").
-		Preload("ParentPost.Author").
-		Order("created_at ASC") // Oldest first for replies
-
-	// Get total count
-	if err := query.Model(&models.Post{}).Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count posts: %w", err)
-	}
+		Preload("ParentPost.Author")
+}
 
-	// Get posts with pagination
-	if err := query.Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+// paginatePosts fetches one cursor page of query and converts it to a response
+func (s *TimelineService) paginatePosts(query *gorm.DB, page PageRequest, ascending bool, userID uuid.UUID, preference models.SensitiveContentPreference) (*TimelineResponse, error) {
+	posts, pageInfo, err := paginate(query, page, "posts", ascending, postCursorKey)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to PostWithDetails
-	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID)
+	postsWithDetails, err := s.convertToPostsWithDetails(posts, userID, preference)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TimelineResponse{
-		Posts:  postsWithDetails,
-		Limit:  limit,
-		Offset: offset,
-		Total:  total,
+		Posts:    postsWithDetails,
+		Limit:    page.Limit,
+		PageInfo: pageInfo,
 	}, nil
 }
 
 // Helper function to convert posts to PostWithDetails
-func (s *TimelineService) convertToPostsWithDetails(posts []models.Post, userID uuid.UUID) ([]models.PostWithDetails, error) {
-	var result []models.PostWithDetails
-
-	for _, post := range posts {
-		postWithDetails := models.PostWithDetails{
-			Post: post,
-		}
-
-		// Check if user liked this post
-		var like models.Like
-		if err := s.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error; err == nil {
-			postWithDetails.IsLiked = true
-		}
-
-		// Check if user reposted this post
-		var repost models.Post
-		if err := s.db.Where("author_id = ? AND original_post_id = ? AND type = ?", userID, post.ID, models.PostTypeRepost).First(&repost).Error; err == nil {
-			postWithDetails.IsReposted = true
-		}
-
-		result = append(result, postWithDetails)
+func (s *TimelineService) convertToPostsWithDetails(posts []models.Post, userID uuid.UUID, preference models.SensitiveContentPreference) ([]models.PostWithDetails, error) {
+	result, err := toPostsWithDetails(s.db, posts, userID)
+	if err != nil {
+		return nil, err
 	}
 
+	markBlurredPosts(result, userID, preference)
+
 	return result, nil
 }
\ No newline at end of file
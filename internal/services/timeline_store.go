@@ -0,0 +1,183 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultTimelineMaxEntries = 800
+
+// TimelineStore holds each user's materialized home timeline: the IDs of
+// the newest posts from the accounts they follow, ordered by (created_at,
+// post ID) like every other cursor-paginated listing.
+type TimelineStore interface {
+	// Push adds entry to the timelines of users and trims each of them
+	Push(userIDs []uuid.UUID, entry models.TimelineEntry) error
+	// Backfill adds entries to a single user's timeline and trims it
+	Backfill(userID uuid.UUID, entries []models.TimelineEntry) error
+	// Replace discards a user's timeline, stores entries in its place and
+	// marks the timeline as built
+	Replace(userID uuid.UUID, entries []models.TimelineEntry) error
+	// Built reports whether the user's timeline has been built since the
+	// store was introduced; unbuilt timelines are rebuilt on first read
+	Built(userID uuid.UUID) (bool, error)
+	// RemoveAuthor drops every entry by authorID from the user's timeline
+	RemoveAuthor(userID, authorID uuid.UUID) error
+	// Range returns up to limit entries next to from. Without newer they
+	// are the entries older than from, newest first; with newer they are
+	// the entries newer than from, oldest first. A nil from starts at the
+	// newest entry.
+	Range(userID uuid.UUID, from *models.TimelineEntry, newer bool, limit int) ([]models.TimelineEntry, error)
+}
+
+// timelineMaxEntries returns how many entries each materialized timeline keeps
+func timelineMaxEntries() int {
+	maxEntries, err := strconv.Atoi(os.Getenv("TIMELINE_MAX_ENTRIES"))
+	if err != nil || maxEntries <= 0 {
+		return defaultTimelineMaxEntries
+	}
+	return maxEntries
+}
+
+// entryBefore reports whether a sorts before b in (created_at, post ID) order
+func entryBefore(a, b models.TimelineEntry) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.PostID.String() < b.PostID.String()
+}
+
+type PostgresTimelineStore struct {
+	db         *gorm.DB
+	maxEntries int
+}
+
+func NewPostgresTimelineStore(db *gorm.DB) *PostgresTimelineStore {
+	return &PostgresTimelineStore{
+		db:         db,
+		maxEntries: timelineMaxEntries(),
+	}
+}
+
+func (s *PostgresTimelineStore) Push(userIDs []uuid.UUID, entry models.TimelineEntry) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	entries := make([]models.TimelineEntry, 0, len(userIDs))
+	for _, userID := range userIDs {
+		entries = append(entries, models.TimelineEntry{
+			UserID:    userID,
+			PostID:    entry.PostID,
+			AuthorID:  entry.AuthorID,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&entries, 500).Error; err != nil {
+		return fmt.Errorf("failed to push timeline entries: %w", err)
+	}
+
+	return s.trim(userIDs)
+}
+
+func (s *PostgresTimelineStore) Backfill(userID uuid.UUID, entries []models.TimelineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	for i := range entries {
+		entries[i].UserID = userID
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&entries, 500).Error; err != nil {
+		return fmt.Errorf("failed to backfill timeline: %w", err)
+	}
+
+	return s.trim([]uuid.UUID{userID})
+}
+
+func (s *PostgresTimelineStore) Replace(userID uuid.UUID, entries []models.TimelineEntry) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TimelineEntry{}).Error; err != nil {
+			return fmt.Errorf("failed to clear timeline: %w", err)
+		}
+
+		if len(entries) > 0 {
+			for i := range entries {
+				entries[i].UserID = userID
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(&entries, 500).Error; err != nil {
+				return fmt.Errorf("failed to store timeline: %w", err)
+			}
+		}
+
+		state := models.TimelineState{UserID: userID, BuiltAt: time.Now()}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&state).Error; err != nil {
+			return fmt.Errorf("failed to mark timeline as built: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *PostgresTimelineStore) Built(userID uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.TimelineState{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check timeline state: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (s *PostgresTimelineStore) RemoveAuthor(userID, authorID uuid.UUID) error {
+	return s.db.Where("user_id = ? AND author_id = ?", userID, authorID).
+		Delete(&models.TimelineEntry{}).Error
+}
+
+func (s *PostgresTimelineStore) Range(userID uuid.UUID, from *models.TimelineEntry, newer bool, limit int) ([]models.TimelineEntry, error) {
+	query := s.db.Where("user_id = ?", userID)
+
+	if newer {
+		if from != nil {
+			query = query.Where("(created_at, post_id) > (?, ?)", from.CreatedAt, from.PostID)
+		}
+		query = query.Order("created_at ASC, post_id ASC")
+	} else {
+		if from != nil {
+			query = query.Where("(created_at, post_id) < (?, ?)", from.CreatedAt, from.PostID)
+		}
+		query = query.Order("created_at DESC, post_id DESC")
+	}
+
+	var entries []models.TimelineEntry
+	if err := query.Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to read timeline: %w", err)
+	}
+	return entries, nil
+}
+
+// trim deletes everything past the newest maxEntries entries of each user
+func (s *PostgresTimelineStore) trim(userIDs []uuid.UUID) error {
+	if err := s.db.Exec(`
+		DELETE FROM timeline_entries t
+		WHERE t.user_id IN ? AND (t.created_at, t.post_id) < (
+			SELECT created_at, post_id FROM timeline_entries
+			WHERE user_id = t.user_id
+			ORDER BY created_at DESC, post_id DESC
+			OFFSET ? LIMIT 1
+		)
+	`, userIDs, s.maxEntries-1).Error; err != nil {
+		return fmt.Errorf("failed to trim timelines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"digeon-backend/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTimelineStore keeps each timeline in a sorted set scored by the
+// post's creation time in microseconds. Members are "<post ID>:<author ID>"
+// so ties on the score sort by post ID, matching the Postgres store.
+type RedisTimelineStore struct {
+	client     *redis.Client
+	maxEntries int
+}
+
+func NewRedisTimelineStore(client *redis.Client) *RedisTimelineStore {
+	return &RedisTimelineStore{
+		client:     client,
+		maxEntries: timelineMaxEntries(),
+	}
+}
+
+func (s *RedisTimelineStore) Push(userIDs []uuid.UUID, entry models.TimelineEntry) error {
+	ctx := context.Background()
+	member := timelineMember(entry)
+
+	for start := 0; start < len(userIDs); start += 500 {
+		end := min(start+500, len(userIDs))
+		_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, userID := range userIDs[start:end] {
+				key := timelineKey(userID)
+				pipe.ZAdd(ctx, key, member)
+				pipe.ZRemRangeByRank(ctx, key, 0, int64(-s.maxEntries-1))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to push timeline entries: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *RedisTimelineStore) Backfill(userID uuid.UUID, entries []models.TimelineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	key := timelineKey(userID)
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, timelineMembers(entries)...)
+		pipe.ZRemRangeByRank(ctx, key, 0, int64(-s.maxEntries-1))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to backfill timeline: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisTimelineStore) Replace(userID uuid.UUID, entries []models.TimelineEntry) error {
+	ctx := context.Background()
+	key := timelineKey(userID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(entries) > 0 {
+			pipe.ZAdd(ctx, key, timelineMembers(entries)...)
+			pipe.ZRemRangeByRank(ctx, key, 0, int64(-s.maxEntries-1))
+		}
+		pipe.Set(ctx, timelineBuiltKey(userID), time.Now().Unix(), 0)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace timeline: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisTimelineStore) Built(userID uuid.UUID) (bool, error) {
+	count, err := s.client.Exists(context.Background(), timelineBuiltKey(userID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check timeline state: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RemoveAuthor scans for the author's members with ZSCAN, matching on the
+// author ID suffix, instead of reading the whole timeline in one reply
+func (s *RedisTimelineStore) RemoveAuthor(userID, authorID uuid.UUID) error {
+	ctx := context.Background()
+	key := timelineKey(userID)
+
+	var remove []interface{}
+	// ZSCAN yields each member followed by its score
+	iter := s.client.ZScan(ctx, key, 0, "*:"+authorID.String(), 500).Iterator()
+	for i := 0; iter.Next(ctx); i++ {
+		if i%2 == 0 {
+			remove = append(remove, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to read timeline: %w", err)
+	}
+
+	for start := 0; start < len(remove); start += 500 {
+		end := min(start+500, len(remove))
+		if err := s.client.ZRem(ctx, key, remove[start:end]...).Err(); err != nil {
+			return fmt.Errorf("failed to remove author from timeline: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *RedisTimelineStore) Range(userID uuid.UUID, from *models.TimelineEntry, newer bool, limit int) ([]models.TimelineEntry, error) {
+	ctx := context.Background()
+	key := timelineKey(userID)
+
+	// Score ranges are inclusive, so fetch enough extra to skip past the
+	// entries that share from's timestamp and filter them below
+	by := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: int64(limit)}
+	if from != nil {
+		score := strconv.FormatInt(from.CreatedAt.UnixMicro(), 10)
+		ties, err := s.client.ZCount(ctx, key, score, score).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read timeline: %w", err)
+		}
+		by.Count += ties
+		if newer {
+			by.Min = score
+		} else {
+			by.Max = score
+		}
+	}
+
+	var results []redis.Z
+	var err error
+	if newer {
+		results, err = s.client.ZRangeByScoreWithScores(ctx, key, by).Result()
+	} else {
+		results, err = s.client.ZRevRangeByScoreWithScores(ctx, key, by).Result()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timeline: %w", err)
+	}
+
+	entries := make([]models.TimelineEntry, 0, len(results))
+	for _, result := range results {
+		entry, ok := parseTimelineMember(result)
+		if !ok {
+			continue
+		}
+		entry.UserID = userID
+		if from != nil {
+			if newer && !entryBefore(*from, entry) {
+				continue
+			}
+			if !newer && !entryBefore(entry, *from) {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+		if len(entries) == limit {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+func timelineKey(userID uuid.UUID) string {
+	return "timeline:" + userID.String()
+}
+
+func timelineBuiltKey(userID uuid.UUID) string {
+	return "timeline:" + userID.String() + ":built"
+}
+
+func timelineMember(entry models.TimelineEntry) redis.Z {
+	return redis.Z{
+		Score:  float64(entry.CreatedAt.UnixMicro()),
+		Member: entry.PostID.String() + ":" + entry.AuthorID.String(),
+	}
+}
+
+func timelineMembers(entries []models.TimelineEntry) []redis.Z {
+	members := make([]redis.Z, 0, len(entries))
+	for _, entry := range entries {
+		members = append(members, timelineMember(entry))
+	}
+	return members
+}
+
+func parseTimelineMember(z redis.Z) (models.TimelineEntry, bool) {
+	member, ok := z.Member.(string)
+	if !ok {
+		return models.TimelineEntry{}, false
+	}
+
+	postIDStr, authorIDStr, found := strings.Cut(member, ":")
+	if !found {
+		return models.TimelineEntry{}, false
+	}
+	postID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return models.TimelineEntry{}, false
+	}
+	authorID, err := uuid.Parse(authorIDStr)
+	if err != nil {
+		return models.TimelineEntry{}, false
+	}
+
+	return models.TimelineEntry{
+		PostID:    postID,
+		AuthorID:  authorID,
+		CreatedAt: time.UnixMicro(int64(z.Score)).UTC(),
+	}, true
+}
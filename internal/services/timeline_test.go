@@ -0,0 +1,66 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMergeTimelineEntries(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(n int) models.TimelineEntry {
+		return models.TimelineEntry{PostID: testPostID(n), CreatedAt: base.Add(time.Duration(n) * time.Minute)}
+	}
+	// Newest first, as home timeline pages are read
+	entries := func(ns ...int) []models.TimelineEntry {
+		list := make([]models.TimelineEntry, len(ns))
+		for i, n := range ns {
+			list[i] = entry(n)
+		}
+		return list
+	}
+
+	tests := []struct {
+		name          string
+		a, b          []models.TimelineEntry
+		limit         int
+		want          []int
+		wantDiscarded bool
+	}{
+		{"both empty", nil, nil, 3, nil, false},
+		{"interleaved", entries(6, 4, 2), entries(5, 3, 1), 10, []int{6, 5, 4, 3, 2, 1}, false},
+		{"duplicates dropped", entries(3, 2), entries(3, 1), 10, []int{3, 2, 1}, false},
+		{"cut at the limit", entries(6, 4, 2), entries(5, 3, 1), 4, []int{6, 5, 4, 3}, true},
+		{"each source under the limit", entries(4, 2), entries(3, 1), 3, []int{4, 3, 2}, true},
+		{"only duplicates left over", entries(3, 2), entries(3, 2), 2, []int{3, 2}, false},
+		{"exactly the limit", entries(2), entries(1), 2, []int{2, 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, discarded := mergeTimelineEntries(tt.a, tt.b, false, tt.limit)
+
+			got := make([]uuid.UUID, len(merged))
+			for i, e := range merged {
+				got[i] = e.PostID
+			}
+			want := make([]uuid.UUID, len(tt.want))
+			for i, n := range tt.want {
+				want[i] = testPostID(n)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("merged = %v, want %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("merged = %v, want %v", got, want)
+				}
+			}
+			if discarded != tt.wantDiscarded {
+				t.Errorf("discarded = %v, want %v", discarded, tt.wantDiscarded)
+			}
+		})
+	}
+}
@@ -31,6 +31,14 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type PreferencesResponse struct {
+	SensitiveContent models.SensitiveContentPreference `json:"sensitive_content"`
+}
+
+type UpdatePreferencesRequest struct {
+	SensitiveContent *string `json:"sensitive_content,omitempty"`
+}
+
 type LoginResponse struct {
 	Token string             `json:"token"`
 	User  models.UserPublic `json:"user"`
@@ -113,40 +121,40 @@ func (s *UserService) Login(req LoginRequest) (*LoginResponse, error) {
 	}, nil
 }
 
-func (s *UserService) GetUserByID(userID uuid.UUID) (*models.UserPublic, error) {
+// GetUserByID returns the user as seen by the viewer. Users in a block with
+// the viewer are reported as not found.
+func (s *UserService) GetUserByID(userID, viewerID uuid.UUID) (*models.UserPublic, error) {
 	var user models.User
 	if err := s.db.First(&user, userID).Error; err != nil {
 		return nil, err
 	}
+	if blocked, err := isBlockedBetween(s.db, user.ID, viewerID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, errors.New("user not found")
+	}
 
 	userPublic := s.toUserPublic(user)
-	
-	// Get counts
-	s.db.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&userPublic.FollowingCount)
-	s.db.Model(&models.Follow{}).Where("following_id = ?", userID).Count(&userPublic.FollowersCount)
-	s.db.Model(&models.Post{}).Where("author_id = ?", userID).Count(&userPublic.PostsCount)
-
 	return &userPublic, nil
 }
 
-func (s *UserService) GetUserByUsername(username string) (*models.UserPublic, error) {
+func (s *UserService) GetUserByUsername(username string, viewerID uuid.UUID) (*models.UserPublic, error) {
 	var user models.User
 	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
 		return nil, err
 	}
+	if blocked, err := isBlockedBetween(s.db, user.ID, viewerID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, errors.New("user not found")
+	}
 
 	userPublic := s.toUserPublic(user)
-	
-	// Get counts
-	s.db.Model(&models.Follow{}).Where("follower_id = ?", user.ID).Count(&userPublic.FollowingCount)
-	s.db.Model(&models.Follow{}).Where("following_id = ?", user.ID).Count(&userPublic.FollowersCount)
-	s.db.Model(&models.Post{}).Where("author_id = ?", user.ID).Count(&userPublic.PostsCount)
-
 	return &userPublic, nil
 }
 
 func (s *UserService) UpdateProfile(userID uuid.UUID, updates map[string]interface{}) error {
-	allowedFields := []string{"display_name", "bio", "location", "website", "profile_image_url", "cover_image_url"}
+	allowedFields := []string{"display_name", "bio", "location", "website", "profile_image_url", "cover_image_url", "is_private"}
 	
 	filteredUpdates := make(map[string]interface{})
 	for _, field := range allowedFields {
@@ -159,7 +167,57 @@ func (s *UserService) UpdateProfile(userID uuid.UUID, updates map[string]interfa
 		return errors.New("no valid fields to update")
 	}
 
-	return s.db.Model(&models.User{}).Where("id = ?", userID).Updates(filteredUpdates).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(filteredUpdates).Error; err != nil {
+			return err
+		}
+
+		// A public account has nothing left to approve, so pending requests
+		// are dropped and the requesters can follow directly
+		if isPrivate, ok := filteredUpdates["is_private"].(bool); ok && !isPrivate {
+			if err := tx.Where("target_id = ?", userID).Delete(&models.FollowRequest{}).Error; err != nil {
+				return fmt.Errorf("failed to clear follow requests: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *UserService) GetPreferences(userID uuid.UUID) (*PreferencesResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	preference := user.SensitiveContent
+	if !preference.IsValid() {
+		preference = models.SensitiveContentBlur
+	}
+
+	return &PreferencesResponse{
+		SensitiveContent: preference,
+	}, nil
+}
+
+func (s *UserService) UpdatePreferences(userID uuid.UUID, req UpdatePreferencesRequest) (*PreferencesResponse, error) {
+	updates := make(map[string]interface{})
+	if req.SensitiveContent != nil {
+		preference := models.SensitiveContentPreference(*req.SensitiveContent)
+		if !preference.IsValid() {
+			return nil, errors.New("sensitive_content must be one of show, blur or hide")
+		}
+		updates["sensitive_content"] = preference
+	}
+
+	if len(updates) == 0 {
+		return nil, errors.New("no valid fields to update")
+	}
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	return s.GetPreferences(userID)
 }
 
 func (s *UserService) validateRegisterRequest(req RegisterRequest) error {
@@ -189,6 +247,10 @@ func (s *UserService) toUserPublic(user models.User) models.UserPublic {
 		Location:        user.Location,
 		Website:         user.Website,
 		IsVerified:      user.IsVerified,
+		IsPrivate:       user.IsPrivate,
 		CreatedAt:       user.CreatedAt,
+		FollowersCount:  user.FollowersCount,
+		FollowingCount:  user.FollowingCount,
+		PostsCount:      user.PostsCount,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"digeon-backend/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// toPostsWithDetails wraps posts and fills in the viewer's state for them
+func toPostsWithDetails(db *gorm.DB, posts []models.Post, viewerID uuid.UUID) ([]models.PostWithDetails, error) {
+	result := make([]models.PostWithDetails, 0, len(posts))
+	for _, post := range posts {
+		result = append(result, models.PostWithDetails{Post: post})
+	}
+
+	if err := loadViewerState(db, result, viewerID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadViewerState fills in whether the viewer liked, reposted and
+// bookmarked each post and follows its author. It runs a fixed number of
+// queries regardless of how many posts are on the page, and none at all for
+// anonymous viewers.
+func loadViewerState(db *gorm.DB, posts []models.PostWithDetails, viewerID uuid.UUID) error {
+	if viewerID == uuid.Nil || len(posts) == 0 {
+		return nil
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(posts))
+	authorIDs := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		authorIDs = append(authorIDs, post.AuthorID)
+	}
+
+	var likedIDs []uuid.UUID
+	if err := db.Model(&models.Like{}).
+		Where("user_id = ? AND post_id IN ?", viewerID, postIDs).
+		Pluck("post_id", &likedIDs).Error; err != nil {
+		return fmt.Errorf("failed to load likes: %w", err)
+	}
+
+	var repostedIDs []uuid.UUID
+	if err := db.Model(&models.Post{}).
+		Where("author_id = ? AND original_post_id IN ? AND type = ?", viewerID, postIDs, models.PostTypeRepost).
+		Pluck("original_post_id", &repostedIDs).Error; err != nil {
+		return fmt.Errorf("failed to load reposts: %w", err)
+	}
+
+	var bookmarkedIDs []uuid.UUID
+	if err := db.Model(&models.Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", viewerID, postIDs).
+		Pluck("post_id", &bookmarkedIDs).Error; err != nil {
+		return fmt.Errorf("failed to load bookmarks: %w", err)
+	}
+
+	var followedIDs []uuid.UUID
+	if err := db.Model(&models.Follow{}).
+		Where("follower_id = ? AND following_id IN ?", viewerID, authorIDs).
+		Pluck("following_id", &followedIDs).Error; err != nil {
+		return fmt.Errorf("failed to load follows: %w", err)
+	}
+
+	liked := toIDSet(likedIDs)
+	reposted := toIDSet(repostedIDs)
+	bookmarked := toIDSet(bookmarkedIDs)
+	followed := toIDSet(followedIDs)
+
+	for i := range posts {
+		posts[i].IsLiked = liked[posts[i].ID]
+		posts[i].IsReposted = reposted[posts[i].ID]
+		posts[i].IsBookmarked = bookmarked[posts[i].ID]
+		posts[i].IsFollowingAuthor = followed[posts[i].AuthorID]
+	}
+
+	return nil
+}
+
+func toIDSet(ids []uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,206 @@
+// Package text implements the character counting and entity extraction
+// rules shared by posts and comments.
+//
+// Lengths follow the weighted counting scheme used by twitter-text: most
+// Latin characters weigh 1, CJK and other characters weigh 2, emoji
+// sequences count as a single weight-2 character and every URL counts as a
+// fixed length regardless of how long it actually is. Offsets returned by
+// the extractors are code point offsets into NFC-normalized text.
+package text
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// WeightedRange assigns Weight to code points in [Start, End]
+type WeightedRange struct {
+	Start  rune
+	End    rune
+	Weight int
+}
+
+type Config struct {
+	// MaxWeightedLength is the maximum allowed length in display characters
+	MaxWeightedLength int
+	// Scale is the weight of one display character
+	Scale int
+	// DefaultWeight applies to code points outside every range
+	DefaultWeight int
+	Ranges        []WeightedRange
+	// TransformedURLLength is the length every URL counts as
+	TransformedURLLength int
+	// MaxBytes caps the UTF-8 size of s outright. URLs count as a fixed
+	// length however long they are, so without it the weighted limit alone
+	// would let a post carry any amount of text.
+	MaxBytes int
+}
+
+// DefaultConfig mirrors the twitter-text v3 configuration: 280 Latin
+// characters or 140 Japanese characters fit in a post.
+var DefaultConfig = Config{
+	MaxWeightedLength: 280,
+	Scale:             100,
+	DefaultWeight:     200,
+	Ranges: []WeightedRange{
+		{Start: 0, End: 4351, Weight: 100},
+		{Start: 8192, End: 8205, Weight: 100},
+		{Start: 8208, End: 8223, Weight: 100},
+		{Start: 8242, End: 8247, Weight: 100},
+	},
+	TransformedURLLength: 23,
+	MaxBytes:             8192,
+}
+
+// Normalize returns s in Unicode NFC form. Content should be normalized
+// before it is stored so offsets stay stable.
+func Normalize(s string) string {
+	return norm.NFC.String(s)
+}
+
+// WeightedLength returns the length of s using DefaultConfig
+func WeightedLength(s string) int {
+	return DefaultConfig.WeightedLength(s)
+}
+
+// IsValidLength reports whether s fits within DefaultConfig's limit
+func IsValidLength(s string) bool {
+	return DefaultConfig.IsValidLength(s)
+}
+
+// IsValidLength reports whether s fits within the configured limits
+func (c Config) IsValidLength(s string) bool {
+	if c.MaxBytes > 0 && len(s) > c.MaxBytes {
+		return false
+	}
+	return c.WeightedLength(s) <= c.MaxWeightedLength
+}
+
+// WeightedLength returns the length of s in display characters
+func (c Config) WeightedLength(s string) int {
+	runes := []rune(Normalize(s))
+
+	weight := 0
+	urls := ExtractURLs(string(runes))
+	next := 0
+	for _, url := range urls {
+		weight += c.clustersWeight(runes[next:url.Start])
+		weight += c.TransformedURLLength * c.Scale
+		next = url.End
+	}
+	weight += c.clustersWeight(runes[next:])
+
+	return weight / c.Scale
+}
+
+// clustersWeight sums the weight of each grapheme cluster in runes
+func (c Config) clustersWeight(runes []rune) int {
+	weight := 0
+	for i := 0; i < len(runes); {
+		size := clusterSize(runes[i:])
+		if isEmojiCluster(runes[i : i+size]) {
+			weight += c.DefaultWeight
+		} else {
+			weight += c.runeWeight(runes[i])
+		}
+		i += size
+	}
+	return weight
+}
+
+func (c Config) runeWeight(r rune) int {
+	for _, rng := range c.Ranges {
+		if r >= rng.Start && r <= rng.End {
+			return rng.Weight
+		}
+	}
+	return c.DefaultWeight
+}
+
+// CharCount returns the number of grapheme clusters in s
+func CharCount(s string) int {
+	runes := []rune(Normalize(s))
+	count := 0
+	for i := 0; i < len(runes); i += clusterSize(runes[i:]) {
+		count++
+	}
+	return count
+}
+
+// clusterSize returns the number of runes in the grapheme cluster that
+// starts at runes[0]. It is a pragmatic approximation of UAX #29 that keeps
+// combining marks, variation selectors, skin tone modifiers, ZWJ sequences,
+// tag sequences and regional indicator pairs together.
+func clusterSize(runes []rune) int {
+	if len(runes) == 0 {
+		return 0
+	}
+
+	// CR LF is a single cluster
+	if runes[0] == '\r' && len(runes) > 1 && runes[1] == '\n' {
+		return 2
+	}
+
+	// Flags are pairs of regional indicators
+	if isRegionalIndicator(runes[0]) {
+		if len(runes) > 1 && isRegionalIndicator(runes[1]) {
+			return 2
+		}
+		return 1
+	}
+
+	i := 1
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case isExtender(r):
+			i++
+		case r == zeroWidthJoiner:
+			i++
+			if i < len(runes) {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+const zeroWidthJoiner = '\u200d'
+
+func isExtender(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me) ||
+		(r >= 0xFE00 && r <= 0xFE0F) || // variation selectors
+		(r >= 0xE0100 && r <= 0xE01EF) || // variation selectors supplement
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // skin tone modifiers
+		(r >= 0xE0020 && r <= 0xE007F) // tag characters
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isEmojiCluster(cluster []rune) bool {
+	base := cluster[0]
+	if isRegionalIndicator(base) {
+		return true
+	}
+	if (base >= 0x1F000 && base <= 0x1FAFF) || (base >= 0x2600 && base <= 0x27BF) {
+		return true
+	}
+	// Text-default symbols rendered as emoji with VS16
+	for _, r := range cluster[1:] {
+		if r == '\ufe0f' {
+			return true
+		}
+	}
+	return false
+}
+
+// runeOffset converts a byte offset in s to a code point offset
+func runeOffset(s string, byteOffset int) int {
+	return utf8.RuneCountInString(s[:byteOffset])
+}
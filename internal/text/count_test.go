@@ -0,0 +1,92 @@
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeightedLength(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"Latin", "hello", 5},
+		{"Japanese weighs 2", "こんにちは", 10},
+		{"mixed scripts", "日本語abc", 9},
+		{"curly quotes weigh 1", "“a”", 3},
+		{"emoji", "👍", 2},
+		{"emoji with skin tone", "👍🏽", 2},
+		{"ZWJ family", "👨‍👩‍👧‍👦", 2},
+		{"flag", "🇯🇵", 2},
+		{"two flags", "🇯🇵🇺🇸", 4},
+		{"lone regional indicator", "🇯", 2},
+		{"symbol with VS16", "❤️", 2},
+		{"combining mark is normalized", "é", 1},
+		{"URL counts as 23", "https://example.com/" + strings.Repeat("a", 100), 23},
+		{"short URL counts as 23", "http://a.co", 23},
+		{"www URL", "www.example.com", 23},
+		{"text around a URL", "see https://example.com/path now", 4 + 23 + 4},
+		{"two URLs", "https://a.com https://b.com", 23 + 1 + 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeightedLength(tt.s); got != tt.want {
+				t.Errorf("WeightedLength(%q) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidLength(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"280 Latin characters", strings.Repeat("a", 280), true},
+		{"281 Latin characters", strings.Repeat("a", 281), false},
+		{"140 Japanese characters", strings.Repeat("あ", 140), true},
+		{"141 Japanese characters", strings.Repeat("あ", 141), false},
+		{"140 emoji", strings.Repeat("👨‍👩‍👧‍👦", 140), true},
+		{"141 emoji", strings.Repeat("👨‍👩‍👧‍👦", 141), false},
+		{"long URLs within the byte cap", strings.Repeat("https://example.com/"+strings.Repeat("a", 500)+" ", 10), true},
+		{"URL past the byte cap", "https://example.com/" + strings.Repeat("a", 9000), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidLength(tt.s); got != tt.want {
+				t.Errorf("IsValidLength() = %v, want %v (weighted length %d, %d bytes)", got, tt.want, WeightedLength(tt.s), len(tt.s))
+			}
+		})
+	}
+}
+
+func TestCharCount(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"日本", 2},
+		{"a👍🏽b", 3},
+		{"👨‍👩‍👧‍👦", 1},
+		{"🏳️‍🌈", 1},
+		{"🏴󠁧󠁢󠁳󠁣󠁴󠁿", 1},
+		{"🇯🇵🇺🇸", 2},
+		{"🇯🇵🇺", 2},
+		{"é", 1},
+		{"が", 1},
+		{"\r\n", 1},
+	}
+
+	for _, tt := range tests {
+		if got := CharCount(tt.s); got != tt.want {
+			t.Errorf("CharCount(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
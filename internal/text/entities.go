@@ -0,0 +1,200 @@
+package text
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type EntityType string
+
+const (
+	EntityHashtag EntityType = "hashtag"
+	EntityMention EntityType = "mention"
+	EntityURL     EntityType = "url"
+	EntityCashtag EntityType = "cashtag"
+)
+
+// Entity is a hashtag, mention, URL or cashtag found in a piece of text.
+// Start and End are code point offsets with End exclusive.
+type Entity struct {
+	Type  EntityType `json:"type"`
+	Text  string     `json:"text"`  // as written, including the # @ or $ sigil
+	Value string     `json:"value"` // without the sigil
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+}
+
+var (
+	urlPattern = regexp.MustCompile(`(?i)(?:^|[^\w@.])((?:https?://|www\.)[^\s<>"「」『』（）、。，！？]+)`)
+
+	// Hashtags may contain letters, marks, numbers and underscores in any
+	// script, so #ゴールデンウィーク and #東京2025 are both valid. Japanese
+	// is written without spaces, so a hashtag may directly follow kana or
+	// kanji; only alphabetic scripts need a separator before the #.
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{Latin}\p{Greek}\p{Cyrillic}\p{M}\p{N}_&/])([#＃]([\p{L}\p{M}\p{N}_]+))`)
+
+	mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_!@#$%&*])([@＠]([A-Za-z0-9_]{1,50}))`)
+
+	cashtagPattern = regexp.MustCompile(`(?:^|\s)(\$([A-Za-z]{1,6}(?:[._][A-Za-z]{1,2})?))`)
+)
+
+const urlTrailingPunctuation = `.,;:!?'"*…`
+
+// Extract returns every entity in s ordered by position. Hashtags, mentions
+// and cashtags that fall inside a URL are dropped.
+func Extract(s string) []Entity {
+	var entities []Entity
+	entities = append(entities, ExtractURLs(s)...)
+	entities = append(entities, ExtractHashtags(s)...)
+	entities = append(entities, ExtractMentions(s)...)
+	entities = append(entities, ExtractCashtags(s)...)
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Start < entities[j].Start
+	})
+	return entities
+}
+
+// ExtractURLs returns the http(s) and www. URLs in s
+func ExtractURLs(s string) []Entity {
+	var entities []Entity
+	for _, loc := range urlPattern.FindAllStringSubmatchIndex(s, -1) {
+		url := trimURL(s[loc[2]:loc[3]])
+		if url == "" || strings.HasSuffix(strings.ToLower(url), "://") {
+			continue
+		}
+		start := runeOffset(s, loc[2])
+		entities = append(entities, Entity{
+			Type:  EntityURL,
+			Text:  url,
+			Value: url,
+			Start: start,
+			End:   start + utf8.RuneCountInString(url),
+		})
+	}
+	return entities
+}
+
+// ExtractHashtags returns the hashtags in s, excluding those inside URLs
+func ExtractHashtags(s string) []Entity {
+	urls := ExtractURLs(s)
+
+	var entities []Entity
+	for _, loc := range hashtagPattern.FindAllStringSubmatchIndex(s, -1) {
+		value := s[loc[4]:loc[5]]
+		if isAllDigits(value) {
+			continue
+		}
+		entity := newEntity(s, EntityHashtag, loc)
+		if !overlapsAny(entity, urls) {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// ExtractMentions returns the @username mentions in s. Email addresses and
+// names followed by another @ are not treated as mentions.
+func ExtractMentions(s string) []Entity {
+	urls := ExtractURLs(s)
+
+	var entities []Entity
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(s, -1) {
+		if rest := s[loc[1]:]; rest != "" {
+			next, _ := utf8.DecodeRuneInString(rest)
+			if next == '@' || next == '＠' || next == '_' || isASCIIAlnum(next) || strings.HasPrefix(rest, "://") {
+				continue
+			}
+		}
+		entity := newEntity(s, EntityMention, loc)
+		if !overlapsAny(entity, urls) {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// ExtractCashtags returns the $SYMBOL cashtags in s
+func ExtractCashtags(s string) []Entity {
+	urls := ExtractURLs(s)
+
+	var entities []Entity
+	for _, loc := range cashtagPattern.FindAllStringSubmatchIndex(s, -1) {
+		if rest := s[loc[1]:]; rest != "" {
+			next, _ := utf8.DecodeRuneInString(rest)
+			if next == '_' || unicode.IsLetter(next) || unicode.IsDigit(next) {
+				continue
+			}
+		}
+		entity := newEntity(s, EntityCashtag, loc)
+		if !overlapsAny(entity, urls) {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// NormalizeHashtag returns the canonical form used to store and look up a
+// hashtag: NFKC-folded (so full-width ＧＷ equals GW) and lower-cased.
+func NormalizeHashtag(tag string) string {
+	tag = strings.TrimLeft(tag, "#＃")
+	return strings.ToLower(norm.NFKC.String(tag))
+}
+
+// newEntity builds an entity from a match whose first group is the full
+// entity text and whose second group is its value
+func newEntity(s string, entityType EntityType, loc []int) Entity {
+	start := runeOffset(s, loc[2])
+	return Entity{
+		Type:  entityType,
+		Text:  s[loc[2]:loc[3]],
+		Value: s[loc[4]:loc[5]],
+		Start: start,
+		End:   start + utf8.RuneCountInString(s[loc[2]:loc[3]]),
+	}
+}
+
+// trimURL strips trailing punctuation and unbalanced closing brackets
+func trimURL(url string) string {
+	for url != "" {
+		last, size := utf8.DecodeLastRuneInString(url)
+		switch {
+		case strings.ContainsRune(urlTrailingPunctuation, last):
+			url = url[:len(url)-size]
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+			url = url[:len(url)-size]
+		case last == ']' && strings.Count(url, "[") < strings.Count(url, "]"):
+			url = url[:len(url)-size]
+		default:
+			return url
+		}
+	}
+	return url
+}
+
+func overlapsAny(entity Entity, others []Entity) bool {
+	for _, other := range others {
+		if entity.Start < other.End && other.Start < entity.End {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
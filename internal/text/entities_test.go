@@ -0,0 +1,136 @@
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []Entity
+	}{
+		{
+			name: "hashtag",
+			s:    "#golang is fun",
+			want: []Entity{{Type: EntityHashtag, Text: "#golang", Value: "golang", Start: 0, End: 7}},
+		},
+		{
+			name: "hashtag straight after Japanese text",
+			s:    "今日は#ゴールデンウィーク",
+			want: []Entity{{Type: EntityHashtag, Text: "#ゴールデンウィーク", Value: "ゴールデンウィーク", Start: 3, End: 13}},
+		},
+		{
+			name: "full-width hashtag with digits",
+			s:    "＃東京2025",
+			want: []Entity{{Type: EntityHashtag, Text: "＃東京2025", Value: "東京2025", Start: 0, End: 7}},
+		},
+		{
+			name: "no hashtag straight after a Latin letter",
+			s:    "abc#tag",
+		},
+		{
+			name: "no all-digit hashtag",
+			s:    "#123",
+		},
+		{
+			name: "mention",
+			s:    "hi @alice!",
+			want: []Entity{{Type: EntityMention, Text: "@alice", Value: "alice", Start: 3, End: 9}},
+		},
+		{
+			name: "full-width mention",
+			s:    "＠bob さん",
+			want: []Entity{{Type: EntityMention, Text: "＠bob", Value: "bob", Start: 0, End: 4}},
+		},
+		{
+			name: "no mention in an email address",
+			s:    "mail a@b.com",
+		},
+		{
+			name: "no mention followed by another @",
+			s:    "@alice@bob",
+		},
+		{
+			name: "URL ends before Japanese punctuation",
+			s:    "見て https://example.com/path。",
+			want: []Entity{{Type: EntityURL, Text: "https://example.com/path", Value: "https://example.com/path", Start: 3, End: 27}},
+		},
+		{
+			name: "URL keeps balanced parentheses",
+			s:    "(see https://en.wikipedia.org/wiki/Go_(game))",
+			want: []Entity{{Type: EntityURL, Text: "https://en.wikipedia.org/wiki/Go_(game)", Value: "https://en.wikipedia.org/wiki/Go_(game)", Start: 5, End: 44}},
+		},
+		{
+			name: "www URL drops trailing punctuation",
+			s:    "visit www.example.com.",
+			want: []Entity{{Type: EntityURL, Text: "www.example.com", Value: "www.example.com", Start: 6, End: 21}},
+		},
+		{
+			name: "no hashtag or mention inside a URL",
+			s:    "https://example.com/@user/#anchor",
+			want: []Entity{{Type: EntityURL, Text: "https://example.com/@user/#anchor", Value: "https://example.com/@user/#anchor", Start: 0, End: 33}},
+		},
+		{
+			name: "cashtag",
+			s:    "$AAPL is up",
+			want: []Entity{{Type: EntityCashtag, Text: "$AAPL", Value: "AAPL", Start: 0, End: 5}},
+		},
+		{
+			name: "offsets count code points, not grapheme clusters",
+			s:    "👨‍👩‍👧 #tag",
+			want: []Entity{{Type: EntityHashtag, Text: "#tag", Value: "tag", Start: 6, End: 10}},
+		},
+		{
+			name: "offsets after a flag",
+			s:    "🇯🇵 @taro",
+			want: []Entity{{Type: EntityMention, Text: "@taro", Value: "taro", Start: 3, End: 8}},
+		},
+		{
+			name: "entities ordered by position",
+			s:    "@bob #go https://x.com",
+			want: []Entity{
+				{Type: EntityMention, Text: "@bob", Value: "bob", Start: 0, End: 4},
+				{Type: EntityHashtag, Text: "#go", Value: "go", Start: 5, End: 8},
+				{Type: EntityURL, Text: "https://x.com", Value: "https://x.com", Start: 9, End: 22},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract(%q) = %+v, want %+v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntityOffsetsSliceText(t *testing.T) {
+	s := Normalize("今日は🇯🇵で #東京 @taro と https://example.com/a へ")
+	runes := []rune(s)
+	for _, entity := range Extract(s) {
+		if got := string(runes[entity.Start:entity.End]); got != entity.Text {
+			t.Errorf("runes[%d:%d] = %q, want %q", entity.Start, entity.End, got, entity.Text)
+		}
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"#Go", "go"},
+		{"＃ＧＷ", "gw"},
+		{"東京", "東京"},
+		{"#ｶﾀｶﾅ", "カタカナ"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeHashtag(tt.tag); got != tt.want {
+			t.Errorf("NormalizeHashtag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+)
+
+// DetectLanguage guesses the language of s from the scripts it is written
+// in, returning an ISO 639-1 code, or "" when the script is shared by many
+// languages (such as Latin) or s has no letters. Kana means Japanese even
+// alongside kanji.
+func DetectLanguage(s string) string {
+	var han, hangul, thai, arabic, hebrew, greek bool
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			return "ja"
+		case unicode.Is(unicode.Han, r):
+			han = true
+		case unicode.Is(unicode.Hangul, r):
+			hangul = true
+		case unicode.Is(unicode.Thai, r):
+			thai = true
+		case unicode.Is(unicode.Arabic, r):
+			arabic = true
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew = true
+		case unicode.Is(unicode.Greek, r):
+			greek = true
+		}
+	}
+
+	switch {
+	case hangul:
+		return "ko"
+	case han:
+		return "zh"
+	case thai:
+		return "th"
+	case arabic:
+		return "ar"
+	case hebrew:
+		return "he"
+	case greek:
+		return "el"
+	}
+	return ""
+}
+
+// NormalizeLanguage returns the ISO 639 base language of a BCP 47 tag such
+// as "ja" or "en-US", or false when code is not a well-formed tag
+func NormalizeLanguage(code string) (string, bool) {
+	tag, err := language.Parse(strings.TrimSpace(code))
+	if err != nil {
+		return "", false
+	}
+	base, confidence := tag.Base()
+	if confidence == language.No {
+		return "", false
+	}
+	return base.String(), true
+}
@@ -0,0 +1,89 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordRanges are the code points that make up words in scripts written
+// with spaces between words. A muted word in one of these scripts only
+// matches where it isn't directly next to another such character.
+var wordRanges = []struct {
+	Lo, Hi rune
+}{
+	{'0', '9'},
+	{'A', 'Z'},
+	{'_', '_'},
+	{'a', 'z'},
+	{'À', 'Ö'},
+	{'Ø', 'ö'},
+	{'ø', 'ɏ'},
+	{'Ͱ', 'Ͽ'},
+	{'Ѐ', 'ӿ'},
+}
+
+// NormalizeMuteText returns the form muted words and the text they are
+// matched against are compared in: NFKC-folded (so full-width ＡＢＣ and
+// half-width ｶﾀｶﾅ match their usual forms) and lower-cased.
+func NormalizeMuteText(s string) string {
+	return strings.ToLower(norm.NFKC.String(s))
+}
+
+// MutePattern returns a regular expression that finds word in text
+// normalized with NormalizeMuteText. Japanese and Chinese are written
+// without spaces, so words in those scripts match anywhere: ネタバレ
+// matches ネタバレ注意. Words in alphabetic scripts need a boundary on
+// their alphabetic side, so cat doesn't match category but iphone still
+// matches iphoneを買った. Whitespace inside word matches any run of
+// whitespace.
+//
+// The pattern only uses syntax that Go's regexp and PostgreSQL's regular
+// expressions agree on, so it can be evaluated in either.
+func MutePattern(word string) string {
+	fields := strings.Fields(NormalizeMuteText(word))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	pattern := strings.Join(quoted, `\s+`)
+
+	first, _ := utf8.DecodeRuneInString(fields[0])
+	last, _ := utf8.DecodeLastRuneInString(fields[len(fields)-1])
+	if isWordRune(first) {
+		pattern = "(^|[^" + wordClass() + "])" + pattern
+	}
+	if isWordRune(last) {
+		pattern += "($|[^" + wordClass() + "])"
+	}
+	return pattern
+}
+
+func isWordRune(r rune) bool {
+	for _, rng := range wordRanges {
+		if r >= rng.Lo && r <= rng.Hi {
+			return true
+		}
+	}
+	return false
+}
+
+// wordClass returns the contents of a bracket expression matching any
+// rune in wordRanges
+func wordClass() string {
+	var b strings.Builder
+	for _, rng := range wordRanges {
+		b.WriteRune(rng.Lo)
+		if rng.Hi != rng.Lo {
+			b.WriteByte('-')
+			b.WriteRune(rng.Hi)
+		}
+	}
+	return b.String()
+}
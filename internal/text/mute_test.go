@@ -0,0 +1,109 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMuteText(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Spoiler", "spoiler"},
+		{"ＡＢＣ１２３", "abc123"},
+		{"ｶﾀｶﾅ", "カタカナ"},
+		{"ﾈﾀﾊﾞﾚ", "ネタバレ"},
+		{"ＩＰｈｏｎｅ", "iphone"},
+		{"ネタバレ", "ネタバレ"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeMuteText(tt.s); got != tt.want {
+			t.Errorf("NormalizeMuteText(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMutePattern(t *testing.T) {
+	word := "(^|[^" + wordClass() + "])"
+	end := "($|[^" + wordClass() + "])"
+
+	tests := []struct {
+		name string
+		word string
+		want string
+	}{
+		{"empty", "", ""},
+		{"whitespace only", "  \t", ""},
+		{"Japanese has no boundaries", "ネタバレ", "ネタバレ"},
+		{"alphabetic word", "cat", word + "cat" + end},
+		{"alphabetic then Japanese", "iphoneを", word + "iphoneを"},
+		{"Japanese then alphabetic", "新型iphone", "新型iphone" + end},
+		{"normalized first", "ＣＡＴ", word + "cat" + end},
+		{"multi-word", "game  of\tthrones", word + `game\s+of\s+thrones` + end},
+		{"metacharacters are quoted", "c++", word + `c\+\+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MutePattern(tt.word); got != tt.want {
+				t.Errorf("MutePattern(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutePatternMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		word string
+		text string
+		want bool
+	}{
+		{"Japanese inside text", "ネタバレ", "最終回のネタバレ注意", true},
+		{"Japanese at the start", "ネタバレ", "ネタバレあり", true},
+		{"Japanese not present", "ネタバレ", "ネタ元はこちら", false},
+		{"whole word", "cat", "my cat sleeps", true},
+		{"word at the start and end", "cat", "cat", true},
+		{"word with punctuation", "cat", "cat!", true},
+		{"prefix of a longer word", "cat", "category", false},
+		{"suffix of a longer word", "cat", "bobcat", false},
+		{"next to a digit", "cat", "cat2", false},
+		{"next to Japanese", "iphone", "iphoneを買った", true},
+		{"Japanese before", "iphone", "新しいiphone", true},
+		{"accented letter is part of the word", "caf", "café", false},
+		{"case-insensitive", "cat", "My CAT", true},
+		{"full-width text", "cat", "ｃａｔ大好き", true},
+		{"full-width word", "ＣＡＴ", "a cat", true},
+		{"half-width katakana text", "ネタバレ", "ﾈﾀﾊﾞﾚ注意", true},
+		{"half-width katakana word", "ﾈﾀﾊﾞﾚ", "ネタバレ注意", true},
+		{"multi-word", "game of thrones", "watching Game of Thrones tonight", true},
+		{"multi-word across whitespace runs", "game of thrones", "game  of\nthrones", true},
+		{"multi-word needs every word", "game of thrones", "game thrones", false},
+		{"multi-word inside a longer word", "game of thrones", "endgame of thrones", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := regexp.MustCompile(MutePattern(tt.word))
+			if got := pattern.MatchString(NormalizeMuteText(tt.text)); got != tt.want {
+				t.Errorf("MutePattern(%q) matching %q = %v, want %v", tt.word, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// PostgreSQL's regular expressions don't share Go's flags, Perl classes or
+// word boundaries, so the pattern must not use them
+func TestMutePatternIsPortable(t *testing.T) {
+	for _, word := range []string{"cat", "ネタバレ", "game of thrones", "iphoneを", "a.b*c"} {
+		pattern := MutePattern(word)
+		for _, syntax := range []string{"(?", `\b`, `\w`, `\d`, `\p`, `\z`, `\A`} {
+			if strings.Contains(pattern, syntax) {
+				t.Errorf("MutePattern(%q) = %q uses %s", word, pattern, syntax)
+			}
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package text
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lexemes longer than this are left out of the index; PostgreSQL rejects
+// them anyway
+const maxLexemeBytes = 2046
+
+// PostgreSQL clamps tsvector positions to this value
+const maxLexemePosition = 16383
+
+// SearchTerm is one term of a full-text query: a word, or a phrase whose
+// words must appear next to each other. A prefix term also matches words
+// that start with its last word.
+type SearchTerm struct {
+	Text   string
+	Prefix bool
+}
+
+// Highlight marks where a search term matched. Start and End are code
+// point offsets with End exclusive, like Entity.
+type Highlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// searchToken is a run of word characters in normalized text. Words in
+// Japanese and Chinese aren't separated by spaces, so those scripts form
+// runs of their own which are indexed as overlapping bigrams.
+type searchToken struct {
+	text string
+	cjk  bool
+}
+
+// isCJKRune reports whether r belongs to a script written without spaces
+// between words
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+func isSearchWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.M, r)
+}
+
+// searchTokens splits s, normalized like NormalizeMuteText, into word and
+// CJK runs. Everything else separates tokens.
+func searchTokens(s string) []searchToken {
+	var tokens []searchToken
+	var current strings.Builder
+	currentCJK := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, searchToken{text: current.String(), cjk: currentCJK})
+			current.Reset()
+		}
+	}
+
+	for _, r := range NormalizeMuteText(s) {
+		if !isSearchWordRune(r) {
+			flush()
+			continue
+		}
+		cjk := isCJKRune(r)
+		if cjk != currentCJK {
+			flush()
+			currentCJK = cjk
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return tokens
+}
+
+// SearchVector returns the tsvector literal indexing s for full-text
+// search. Words are indexed as they are; a CJK run is indexed as its
+// overlapping bigrams, with its last character also indexed alone at the
+// position of the last bigram, so that phrase queries built by SearchQuery
+// line up and single characters can be found by prefix.
+func SearchVector(s string) string {
+	positions := make(map[string][]int)
+	add := func(lexeme string, position int) {
+		if len(lexeme) > maxLexemeBytes {
+			return
+		}
+		positions[lexeme] = append(positions[lexeme], min(position, maxLexemePosition))
+	}
+
+	position := 1
+	for _, token := range searchTokens(s) {
+		if !token.cjk {
+			add(token.text, position)
+			position++
+			continue
+		}
+
+		runes := []rune(token.text)
+		if len(runes) == 1 {
+			add(token.text, position)
+			position++
+			continue
+		}
+		for i := 0; i < len(runes)-1; i++ {
+			add(string(runes[i:i+2]), position+i)
+		}
+		add(string(runes[len(runes)-1]), position+len(runes)-2)
+		position += len(runes) - 1
+	}
+
+	lexemes := make([]string, 0, len(positions))
+	for lexeme := range positions {
+		lexemes = append(lexemes, lexeme)
+	}
+	sort.Strings(lexemes)
+
+	var b strings.Builder
+	for i, lexeme := range lexemes {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(quoteLexeme(lexeme))
+		b.WriteByte(':')
+		for j, position := range positions[lexeme] {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.Itoa(position))
+		}
+	}
+	return b.String()
+}
+
+// SearchTermQuery returns the tsquery literal matching term in vectors
+// built by SearchVector, or "" when the term has no word characters
+func SearchTermQuery(term SearchTerm) string {
+	var lexemes []string
+	for _, token := range searchTokens(term.Text) {
+		if !token.cjk {
+			lexemes = append(lexemes, quoteLexeme(token.text))
+			continue
+		}
+
+		runes := []rune(token.text)
+		if len(runes) == 1 {
+			// A lone character is indexed alone only at the end of a run;
+			// elsewhere it starts a bigram
+			lexemes = append(lexemes, quoteLexeme(token.text)+":*")
+			continue
+		}
+		for i := 0; i < len(runes)-1; i++ {
+			lexemes = append(lexemes, quoteLexeme(string(runes[i:i+2])))
+		}
+	}
+	if len(lexemes) == 0 {
+		return ""
+	}
+
+	if term.Prefix && !strings.HasSuffix(lexemes[len(lexemes)-1], ":*") {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+	return strings.Join(lexemes, " <-> ")
+}
+
+// SearchQuery returns the tsquery literal matching vectors that contain
+// every term, or "" when no term has word characters
+func SearchQuery(terms []SearchTerm) string {
+	var parts []string
+	for _, term := range terms {
+		if query := SearchTermQuery(term); query != "" {
+			parts = append(parts, "("+query+")")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+func quoteLexeme(lexeme string) string {
+	lexeme = strings.ReplaceAll(lexeme, `\`, `\\`)
+	return "'" + strings.ReplaceAll(lexeme, "'", "''") + "'"
+}
+
+// FindHighlights returns where the terms appear in s, sorted and with
+// overlapping matches merged. Matching follows the search index: case and
+// width are ignored, words must match whole unless the term is a prefix,
+// and CJK text matches anywhere.
+func FindHighlights(s string, terms []SearchTerm) []Highlight {
+	normalized, startAt, endAt := normalizeWithOffsets(s)
+
+	var highlights []Highlight
+	for _, term := range terms {
+		tokens := searchTokens(term.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		var pattern strings.Builder
+		for i, token := range tokens {
+			if i > 0 {
+				// Two words need a separator between them; a change of
+				// script doesn't
+				if !tokens[i-1].cjk && !token.cjk {
+					pattern.WriteString(`[^\pL\pN\pM]+`)
+				} else {
+					pattern.WriteString(`[^\pL\pN\pM]*`)
+				}
+			}
+			pattern.WriteString(regexp.QuoteMeta(token.text))
+		}
+		re, err := regexp.Compile(pattern.String())
+		if err != nil {
+			continue
+		}
+
+		checkStart := !tokens[0].cjk
+		checkEnd := !tokens[len(tokens)-1].cjk && !term.Prefix
+		for _, match := range re.FindAllStringIndex(normalized, -1) {
+			if checkStart && match[0] > 0 {
+				if r, _ := utf8.DecodeLastRuneInString(normalized[:match[0]]); isSearchWordRune(r) && !isCJKRune(r) {
+					continue
+				}
+			}
+			if checkEnd && match[1] < len(normalized) {
+				if r, _ := utf8.DecodeRuneInString(normalized[match[1]:]); isSearchWordRune(r) && !isCJKRune(r) {
+					continue
+				}
+			}
+			highlights = append(highlights, Highlight{Start: startAt[match[0]], End: endAt[match[1]-1]})
+		}
+	}
+
+	return mergeHighlights(highlights)
+}
+
+// normalizeWithOffsets normalizes s like NormalizeMuteText and maps each
+// byte of the result to the code points of s it came from, as a start
+// offset and an exclusive end offset. Combining marks are normalized
+// together with the character they follow.
+func normalizeWithOffsets(s string) (string, []int, []int) {
+	var b strings.Builder
+	var startAt, endAt []int
+
+	runes := []rune(s)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		// Half-width voiced sound marks combine with the kana before them
+		for end < len(runes) && (unicode.Is(unicode.M, runes[end]) || runes[end] == '\uFF9E' || runes[end] == '\uFF9F') {
+			end++
+		}
+
+		segment := NormalizeMuteText(string(runes[start:end]))
+		for i := 0; i < len(segment); i++ {
+			startAt = append(startAt, start)
+			endAt = append(endAt, end)
+		}
+		b.WriteString(segment)
+		start = end
+	}
+
+	return b.String(), startAt, endAt
+}
+
+func mergeHighlights(highlights []Highlight) []Highlight {
+	if len(highlights) == 0 {
+		return nil
+	}
+	sort.Slice(highlights, func(i, j int) bool {
+		return highlights[i].Start < highlights[j].Start
+	})
+
+	merged := []Highlight{highlights[0]}
+	for _, highlight := range highlights[1:] {
+		last := &merged[len(merged)-1]
+		if highlight.Start <= last.End {
+			last.End = max(last.End, highlight.End)
+			continue
+		}
+		merged = append(merged, highlight)
+	}
+	return merged
+}
+
+// Snippet returns at most maxLength code points of s around the first
+// highlight, with … marking cut text, and the highlights moved to match
+func Snippet(s string, highlights []Highlight, maxLength int) (string, []Highlight) {
+	runes := []rune(s)
+	if len(runes) <= maxLength {
+		return s, highlights
+	}
+
+	// Start a little before the first match so it has some context
+	start := 0
+	if len(highlights) > 0 {
+		start = max(0, min(highlights[0].Start-maxLength/4, len(runes)-maxLength))
+	}
+	end := start + maxLength
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(runes) {
+		suffix = "…"
+	}
+	snippet := prefix + string(runes[start:end]) + suffix
+	shift := utf8.RuneCountInString(prefix) - start
+
+	var moved []Highlight
+	for _, highlight := range highlights {
+		if highlight.End <= start || highlight.Start >= end {
+			continue
+		}
+		moved = append(moved, Highlight{
+			Start: max(highlight.Start, start) + shift,
+			End:   min(highlight.End, end) + shift,
+		})
+	}
+	return snippet, moved
+}
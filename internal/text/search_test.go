@@ -0,0 +1,202 @@
+package text
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchVector(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"empty", "", ""},
+		{"punctuation only", "!!! ...", ""},
+		{"repeated word", "Hello world hello", "'hello':1,3 'world':2"},
+		{"apostrophe separates words", "It's", "'it':1 's':2"},
+		{"full-width letters", "ＡＢＣ", "'abc':1"},
+		{"lone CJK character", "食", "'食':1"},
+		{"CJK bigrams", "東京", "'京':1 '東京':1"},
+		{"CJK run then a number", "東京タワー 2025", "'2025':5 'タワ':3 'ワー':4 'ー':4 '京タ':2 '東京':1"},
+		{"change of script", "iphoneを買った", "'iphone':1 'た':4 'った':4 'を買':2 '買っ':3"},
+		{"overlong lexeme is dropped", strings.Repeat("a", maxLexemeBytes+1) + " b", "'b':2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchVector(tt.s); got != tt.want {
+				t.Errorf("SearchVector(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWithOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		startAt []int
+		endAt   []int
+	}{
+		{"full-width letters", "ＡＢ", "ab", []int{0, 1}, []int{1, 2}},
+		{"half-width voiced mark joins its kana", "ｶﾞｷ", "ガキ", []int{0, 0, 0, 2, 2, 2}, []int{2, 2, 2, 3, 3, 3}},
+		{"ligature expands", "ﬁx", "fix", []int{0, 0, 1}, []int{1, 1, 2}},
+		{"combining mark joins its letter", "e\u0301!", "é!", []int{0, 0, 2}, []int{2, 2, 3}},
+		{"emoji keeps its code point", "😀a", "😀a", []int{0, 0, 0, 0, 1}, []int{1, 1, 1, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, startAt, endAt := normalizeWithOffsets(tt.s)
+			if got != tt.want || !slices.Equal(startAt, tt.startAt) || !slices.Equal(endAt, tt.endAt) {
+				t.Errorf("normalizeWithOffsets(%q) = %q, %v, %v, want %q, %v, %v", tt.s, got, startAt, endAt, tt.want, tt.startAt, tt.endAt)
+			}
+		})
+	}
+}
+
+func TestFindHighlights(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		terms []SearchTerm
+		want  []Highlight
+	}{
+		{"word", "Hello World", []SearchTerm{{Text: "world"}}, []Highlight{{6, 11}}},
+		{"whole words only", "category cat", []SearchTerm{{Text: "cat"}}, []Highlight{{9, 12}}},
+		{"prefix", "category", []SearchTerm{{Text: "cat", Prefix: true}}, []Highlight{{0, 3}}},
+		{"no match", "dog", []SearchTerm{{Text: "cat"}}, nil},
+		{"full-width text", "ＨＥＬＬＯ there", []SearchTerm{{Text: "hello"}}, []Highlight{{0, 5}}},
+		{"half-width kana", "ｶﾞｷの話", []SearchTerm{{Text: "ガキ"}}, []Highlight{{0, 3}}},
+		{"ligature", "ﬁx it", []SearchTerm{{Text: "fix"}}, []Highlight{{0, 2}}},
+		{"after an emoji", "😀 cat", []SearchTerm{{Text: "cat"}}, []Highlight{{2, 5}}},
+		{"phrase across punctuation", "say hello, world!", []SearchTerm{{Text: "hello world"}}, []Highlight{{4, 16}}},
+		{"CJK inside a run", "東京タワー", []SearchTerm{{Text: "タワー"}}, []Highlight{{2, 5}}},
+		{"change of script", "iphoneを買った", []SearchTerm{{Text: "iphone"}}, []Highlight{{0, 6}}},
+		{"sorted", "b a", []SearchTerm{{Text: "a"}, {Text: "b"}}, []Highlight{{0, 1}, {2, 3}}},
+		{"overlaps merged", "hello world", []SearchTerm{{Text: "hello world"}, {Text: "world"}}, []Highlight{{0, 11}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindHighlights(tt.s, tt.terms); !slices.Equal(got, tt.want) {
+				t.Errorf("FindHighlights(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	const alphabet = "abcdefghijklmnopqrst"
+
+	tests := []struct {
+		name           string
+		s              string
+		highlights     []Highlight
+		maxLength      int
+		want           string
+		wantHighlights []Highlight
+	}{
+		{"short text is unchanged", "hello", []Highlight{{0, 5}}, 10, "hello", []Highlight{{0, 5}}},
+		{"cut at the end", "abcdefghij", []Highlight{{1, 3}}, 5, "abcde…", []Highlight{{1, 3}}},
+		{"cut at the start", alphabet, []Highlight{{14, 16}}, 8, "…mnopqrst", []Highlight{{3, 5}}},
+		{"cut at both ends", alphabet, []Highlight{{8, 12}, {18, 20}}, 6, "…hijklm…", []Highlight{{2, 6}}},
+		{"highlight clipped", alphabet, []Highlight{{5, 15}}, 6, "…efghij…", []Highlight{{2, 7}}},
+		{"no highlights", alphabet, nil, 4, "abcd…", nil},
+		{"code points, not bytes", "日本語のテキストです", []Highlight{{5, 8}}, 4, "…テキスト…", []Highlight{{2, 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, highlights := Snippet(tt.s, tt.highlights, tt.maxLength)
+			if got != tt.want || !slices.Equal(highlights, tt.wantHighlights) {
+				t.Errorf("Snippet(%q) = %q, %v, want %q, %v", tt.s, got, highlights, tt.want, tt.wantHighlights)
+			}
+		})
+	}
+}
+
+// The highlights returned by Snippet must slice the snippet at the matched
+// text
+func TestSnippetHighlightsSliceText(t *testing.T) {
+	s := "ｶﾞｷの頃から東京タワーが好きで、何度も見に行きました"
+	highlights := FindHighlights(s, []SearchTerm{{Text: "東京タワー"}})
+	snippet, moved := Snippet(s, highlights, 12)
+	if len(moved) != 1 {
+		t.Fatalf("Snippet() highlights = %v, want one", moved)
+	}
+	if got := string([]rune(snippet)[moved[0].Start:moved[0].End]); got != "東京タワー" {
+		t.Errorf("highlight slices %q out of %q, want 東京タワー", got, snippet)
+	}
+}
+
+func TestSearchTermQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		term SearchTerm
+		want string
+	}{
+		{"word", SearchTerm{Text: "Hello"}, "'hello'"},
+		{"prefix", SearchTerm{Text: "hel", Prefix: true}, "'hel':*"},
+		{"phrase", SearchTerm{Text: "hello world"}, "'hello' <-> 'world'"},
+		{"phrase prefix", SearchTerm{Text: "hello wor", Prefix: true}, "'hello' <-> 'wor':*"},
+		{"CJK bigrams", SearchTerm{Text: "東京タワー"}, "'東京' <-> '京タ' <-> 'タワ' <-> 'ワー'"},
+		{"lone CJK character", SearchTerm{Text: "東"}, "'東':*"},
+		{"CJK prefix", SearchTerm{Text: "東京", Prefix: true}, "'東京':*"},
+		{"change of script", SearchTerm{Text: "iphoneを買"}, "'iphone' <-> 'を買'"},
+		{"apostrophe", SearchTerm{Text: "o'reilly"}, "'o' <-> 'reilly'"},
+		{"backslash", SearchTerm{Text: `back\slash`}, "'back' <-> 'slash'"},
+		{"quote and backslash only", SearchTerm{Text: `'\`}, ""},
+		{"punctuation only", SearchTerm{Text: "!!!"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchTermQuery(tt.term); got != tt.want {
+				t.Errorf("SearchTermQuery(%+v) = %q, want %q", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []SearchTerm
+		want  string
+	}{
+		{"no terms", nil, ""},
+		{"one term", []SearchTerm{{Text: "go"}}, "('go')"},
+		{"every term", []SearchTerm{{Text: "hello"}, {Text: "wor", Prefix: true}}, "('hello') & ('wor':*)"},
+		{"terms without words are skipped", []SearchTerm{{Text: "!!!"}, {Text: "go"}}, "('go')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchQuery(tt.terms); got != tt.want {
+				t.Errorf("SearchQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteLexeme(t *testing.T) {
+	tests := []struct {
+		lexeme string
+		want   string
+	}{
+		{"go", "'go'"},
+		{"it's", "'it''s'"},
+		{`a\b`, `'a\\b'`},
+		{`\'`, `'\\'''`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteLexeme(tt.lexeme); got != tt.want {
+			t.Errorf("quoteLexeme(%q) = %s, want %s", tt.lexeme, got, tt.want)
+		}
+	}
+}